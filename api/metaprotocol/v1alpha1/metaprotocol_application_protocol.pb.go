@@ -60,8 +60,11 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 // +k8s:deepcopy-gen=true
 // -->
 type ApplicationProtocol struct {
-	Protocol             string   `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
-	Codec                string   `protobuf:"bytes,2,opt,name=codec,proto3" json:"codec,omitempty"`
+	Protocol string `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Codec    string `protobuf:"bytes,2,opt,name=codec,proto3" json:"codec,omitempty"`
+	// The names of the attributes extracted from the requests by the codec, in addition to the ones of the built-in
+	// codecs. Only the declared attributes can be used as the hash key of a MetaRoute.
+	Attributes           []string `protobuf:"bytes,3,rep,name=attributes,proto3" json:"attributes,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -114,6 +117,13 @@ func (m *ApplicationProtocol) GetCodec() string {
 	return ""
 }
 
+func (m *ApplicationProtocol) GetAttributes() []string {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ApplicationProtocol)(nil), "metaprotocol.aeraki.io.v1alpha1.ApplicationProtocol")
 }
@@ -123,18 +133,19 @@ func init() {
 }
 
 var fileDescriptor_54bc1cd743033a01 = []byte{
-	// 172 bytes of a gzipped FileDescriptorProto
+	// 192 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x72, 0x4c, 0x2c, 0xc8, 0xd4,
 	0xcf, 0x4d, 0x2d, 0x49, 0x2c, 0x28, 0xca, 0x2f, 0xc9, 0x4f, 0xce, 0xcf, 0xd1, 0x2f, 0x33, 0x4c,
 	0xcc, 0x29, 0xc8, 0x48, 0x34, 0x44, 0x11, 0x8d, 0x4f, 0x2c, 0x28, 0xc8, 0xc9, 0x4c, 0x4e, 0x2c,
 	0xc9, 0xcc, 0xcf, 0x8b, 0x87, 0x09, 0xea, 0x81, 0x19, 0x42, 0xf2, 0xc8, 0x0a, 0xf5, 0x12, 0x53,
-	0x8b, 0x12, 0xb3, 0x33, 0xf5, 0x32, 0xf3, 0xf5, 0x60, 0x06, 0x29, 0xb9, 0x73, 0x09, 0x3b, 0x22,
+	0x8b, 0x12, 0xb3, 0x33, 0xf5, 0x32, 0xf3, 0xf5, 0x60, 0x06, 0x29, 0xa5, 0x73, 0x09, 0x3b, 0x22,
 	0xb4, 0x07, 0x40, 0x55, 0x0a, 0x49, 0x71, 0x71, 0xc0, 0x74, 0x49, 0x30, 0x2a, 0x30, 0x6a, 0x70,
 	0x06, 0xc1, 0xf9, 0x42, 0x22, 0x5c, 0xac, 0xc9, 0xf9, 0x29, 0xa9, 0xc9, 0x12, 0x4c, 0x60, 0x09,
-	0x08, 0xc7, 0xc9, 0xf5, 0xc4, 0x23, 0x39, 0xc6, 0x0b, 0x8f, 0xe4, 0x18, 0x1f, 0x3c, 0x92, 0x63,
-	0x8c, 0x32, 0x4f, 0xcf, 0x2c, 0xc9, 0x28, 0x4d, 0xd2, 0x4b, 0xce, 0xcf, 0xd5, 0x87, 0xd8, 0xaa,
-	0x9b, 0x9b, 0x5a, 0x9c, 0x01, 0x65, 0xeb, 0xe3, 0xf4, 0x58, 0x12, 0x1b, 0x58, 0xc8, 0x18, 0x10,
-	0x00, 0x00, 0xff, 0xff, 0x0b, 0xd7, 0x29, 0x1b, 0xfc, 0x00, 0x00, 0x00,
+	0x08, 0x47, 0x48, 0x8e, 0x8b, 0x2b, 0xb1, 0xa4, 0xa4, 0x28, 0x33, 0xa9, 0xb4, 0x24, 0xb5, 0x58,
+	0x82, 0x59, 0x81, 0x59, 0x83, 0x33, 0x08, 0x49, 0xc4, 0xc9, 0xf5, 0xc4, 0x23, 0x39, 0xc6, 0x0b,
+	0x8f, 0xe4, 0x18, 0x1f, 0x3c, 0x92, 0x63, 0x8c, 0x32, 0x4f, 0xcf, 0x2c, 0xc9, 0x28, 0x4d, 0xd2,
+	0x4b, 0xce, 0xcf, 0xd5, 0x87, 0xb8, 0x4a, 0x37, 0x37, 0xb5, 0x38, 0x03, 0xca, 0xd6, 0xc7, 0xe9,
+	0xf1, 0x24, 0x36, 0xb0, 0x90, 0x31, 0x60, 0x00, 0x86, 0x21, 0x74, 0x8b, 0x1c, 0x01, 0x00, 0x00,
 }
 
 func (m *ApplicationProtocol) Marshal() (dAtA []byte, err error) {
@@ -161,6 +172,15 @@ func (m *ApplicationProtocol) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.Attributes) > 0 {
+		for iNdEx := len(m.Attributes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Attributes[iNdEx])
+			copy(dAtA[i:], m.Attributes[iNdEx])
+			i = encodeVarintMetaprotocolApplicationProtocol(dAtA, i, uint64(len(m.Attributes[iNdEx])))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
 	if len(m.Codec) > 0 {
 		i -= len(m.Codec)
 		copy(dAtA[i:], m.Codec)
@@ -203,6 +223,12 @@ func (m *ApplicationProtocol) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovMetaprotocolApplicationProtocol(uint64(l))
 	}
+	if len(m.Attributes) > 0 {
+		for _, s := range m.Attributes {
+			l = len(s)
+			n += 1 + l + sovMetaprotocolApplicationProtocol(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -308,6 +334,38 @@ func (m *ApplicationProtocol) Unmarshal(dAtA []byte) error {
 			}
 			m.Codec = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Attributes", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolApplicationProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaprotocolApplicationProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolApplicationProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Attributes = append(m.Attributes, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetaprotocolApplicationProtocol(dAtA[iNdEx:])
@@ -123,6 +123,15 @@ type MetaRouter struct {
 	LocalRateLimit *LocalRateLimit `protobuf:"bytes,4,opt,name=local_rate_limit,json=localRateLimit,proto3" json:"local_rate_limit,omitempty"`
 	// Global rate limit policy.
 	GlobalRateLimit *GlobalRateLimit `protobuf:"bytes,5,opt,name=global_rate_limit,json=globalRateLimit,proto3" json:"global_rate_limit,omitempty"`
+	// Outlier detection policy of the destination service.
+	OutlierDetection *OutlierDetection `protobuf:"bytes,6,opt,name=outlier_detection,json=outlierDetection,proto3" json:"outlier_detection,omitempty"`
+	// The destination of the requests which match none of the routes. A catch-all route to it is added after the
+	// routes, otherwise the requests matching no route are rejected.
+	FallbackCluster *Destination `protobuf:"bytes,7,opt,name=fallback_cluster,json=fallbackCluster,proto3" json:"fallback_cluster,omitempty"`
+	// Forward the requests which match none of the routes to their original destination through the
+	// PassthroughCluster, so only the routed methods of the service are changed. It can't be used together with the
+	// fallback cluster.
+	PassthroughUnmatched bool `protobuf:"varint,8,opt,name=passthrough_unmatched,json=passthroughUnmatched,proto3" json:"passthrough_unmatched,omitempty"`
 	// A list of namespaces to which this MetaRouter is exported. Exporting a
 	// MetaRouter allows it to be used by sidecars defined in other namespaces.
 	// This feature provides a mechanism for service owners and mesh administrators
@@ -208,6 +217,27 @@ func (m *MetaRouter) GetGlobalRateLimit() *GlobalRateLimit {
 	return nil
 }
 
+func (m *MetaRouter) GetOutlierDetection() *OutlierDetection {
+	if m != nil {
+		return m.OutlierDetection
+	}
+	return nil
+}
+
+func (m *MetaRouter) GetFallbackCluster() *Destination {
+	if m != nil {
+		return m.FallbackCluster
+	}
+	return nil
+}
+
+func (m *MetaRouter) GetPassthroughUnmatched() bool {
+	if m != nil {
+		return m.PassthroughUnmatched
+	}
+	return false
+}
+
 func (m *MetaRouter) GetExportTo() []string {
 	if m != nil {
 		return m.ExportTo
@@ -232,6 +262,16 @@ type MetaRoute struct {
 	// If this field is absent, all the traffic (100%) will be mirrored.
 	// Max value is 100.
 	MirrorPercentage *Percent `protobuf:"bytes,6,opt,name=mirror_percentage,json=mirrorPercentage,proto3" json:"mirror_percentage,omitempty"`
+	// The shadow destinations the traffic is also mirrored to, each with its own percentage, such as for comparing
+	// several candidate versions with the same requests. It can be used together with the `mirror` field.
+	Mirrors []*MetaRouteMirror `protobuf:"bytes,14,rep,name=mirrors,proto3" json:"mirrors,omitempty"`
+	// Consistent hash policy of the route. Requests with the same values of the hash attributes are sent to the same
+	// upstream host.
+	HashPolicy *HashPolicy `protobuf:"bytes,7,opt,name=hash_policy,json=hashPolicy,proto3" json:"hash_policy,omitempty"`
+	// The priority of the route. The routes are matched in the descending order of their priorities, and the routes
+	// with the same priority are matched in the order they are defined in the MetaRouter. The first matched route is
+	// used. Defaults to 0.
+	Priority uint32 `protobuf:"varint,9,opt,name=priority,proto3" json:"priority,omitempty"`
 	// Specifies a list of key-value pairs that should be mutated for each request. How to interpret the key-value pairs
 	// depends on the codec implementation
 	RequestMutation []*KeyValue `protobuf:"bytes,19,rep,name=request_mutation,json=requestMutation,proto3" json:"request_mutation,omitempty"`
@@ -311,6 +351,27 @@ func (m *MetaRoute) GetMirrorPercentage() *Percent {
 	return nil
 }
 
+func (m *MetaRoute) GetMirrors() []*MetaRouteMirror {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+func (m *MetaRoute) GetHashPolicy() *HashPolicy {
+	if m != nil {
+		return m.HashPolicy
+	}
+	return nil
+}
+
+func (m *MetaRoute) GetPriority() uint32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
 func (m *MetaRoute) GetRequestMutation() []*KeyValue {
 	if m != nil {
 		return m.RequestMutation
@@ -325,6 +386,116 @@ func (m *MetaRoute) GetResponseMutation() []*KeyValue {
 	return nil
 }
 
+// HashPolicy defines the hash key of the consistent hash load balancing.
+type HashPolicy struct {
+	// The names of the meta protocol attributes used as the hash key. The attributes must be declared by the
+	// ApplicationProtocol of the service.
+	Attributes           []string `protobuf:"bytes,1,rep,name=attributes,proto3" json:"attributes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HashPolicy) Reset()         { *m = HashPolicy{} }
+func (m *HashPolicy) String() string { return proto.CompactTextString(m) }
+func (*HashPolicy) ProtoMessage()    {}
+func (*HashPolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e2715e051935576, []int{2}
+}
+func (m *HashPolicy) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HashPolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HashPolicy.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HashPolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HashPolicy.Merge(m, src)
+}
+func (m *HashPolicy) XXX_Size() int {
+	return m.Size()
+}
+func (m *HashPolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_HashPolicy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HashPolicy proto.InternalMessageInfo
+
+func (m *HashPolicy) GetAttributes() []string {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+// MetaRouteMirror defines a shadow destination the traffic of a route is mirrored to.
+type MetaRouteMirror struct {
+	// The destination the traffic is mirrored to.
+	Destination *Destination `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"`
+	// Percentage of the traffic to be mirrored to the destination.
+	// If this field is absent, all the traffic (100%) will be mirrored.
+	// Max value is 100.
+	Percentage           *Percent `protobuf:"bytes,2,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MetaRouteMirror) Reset()         { *m = MetaRouteMirror{} }
+func (m *MetaRouteMirror) String() string { return proto.CompactTextString(m) }
+func (*MetaRouteMirror) ProtoMessage()    {}
+func (*MetaRouteMirror) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e2715e051935576, []int{3}
+}
+func (m *MetaRouteMirror) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MetaRouteMirror) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MetaRouteMirror.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MetaRouteMirror) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetaRouteMirror.Merge(m, src)
+}
+func (m *MetaRouteMirror) XXX_Size() int {
+	return m.Size()
+}
+func (m *MetaRouteMirror) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetaRouteMirror.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetaRouteMirror proto.InternalMessageInfo
+
+func (m *MetaRouteMirror) GetDestination() *Destination {
+	if m != nil {
+		return m.Destination
+	}
+	return nil
+}
+
+func (m *MetaRouteMirror) GetPercentage() *Percent {
+	if m != nil {
+		return m.Percentage
+	}
+	return nil
+}
+
 // KeyValue defines a Key /value pair.
 type KeyValue struct {
 	// Key name.
@@ -340,7 +511,7 @@ func (m *KeyValue) Reset()         { *m = KeyValue{} }
 func (m *KeyValue) String() string { return proto.CompactTextString(m) }
 func (*KeyValue) ProtoMessage()    {}
 func (*KeyValue) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{2}
+	return fileDescriptor_1e2715e051935576, []int{4}
 }
 func (m *KeyValue) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -395,7 +566,7 @@ func (m *MetaRouteMatch) Reset()         { *m = MetaRouteMatch{} }
 func (m *MetaRouteMatch) String() string { return proto.CompactTextString(m) }
 func (*MetaRouteMatch) ProtoMessage()    {}
 func (*MetaRouteMatch) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{3}
+	return fileDescriptor_1e2715e051935576, []int{5}
 }
 func (m *MetaRouteMatch) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -438,6 +609,7 @@ type StringMatch struct {
 	//	*StringMatch_Exact
 	//	*StringMatch_Prefix
 	//	*StringMatch_Regex
+	//	*StringMatch_Range
 	MatchType            isStringMatch_MatchType `protobuf_oneof:"match_type"`
 	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
 	XXX_unrecognized     []byte                  `json:"-"`
@@ -448,7 +620,7 @@ func (m *StringMatch) Reset()         { *m = StringMatch{} }
 func (m *StringMatch) String() string { return proto.CompactTextString(m) }
 func (*StringMatch) ProtoMessage()    {}
 func (*StringMatch) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{4}
+	return fileDescriptor_1e2715e051935576, []int{6}
 }
 func (m *StringMatch) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -492,10 +664,14 @@ type StringMatch_Prefix struct {
 type StringMatch_Regex struct {
 	Regex string `protobuf:"bytes,3,opt,name=regex,proto3,oneof" json:"regex,omitempty"`
 }
+type StringMatch_Range struct {
+	Range *Int64Range `protobuf:"bytes,4,opt,name=range,proto3,oneof" json:"range,omitempty"`
+}
 
 func (*StringMatch_Exact) isStringMatch_MatchType()  {}
 func (*StringMatch_Prefix) isStringMatch_MatchType() {}
 func (*StringMatch_Regex) isStringMatch_MatchType()  {}
+func (*StringMatch_Range) isStringMatch_MatchType()  {}
 
 func (m *StringMatch) GetMatchType() isStringMatch_MatchType {
 	if m != nil {
@@ -525,13 +701,79 @@ func (m *StringMatch) GetRegex() string {
 	return ""
 }
 
+func (m *StringMatch) GetRange() *Int64Range {
+	if x, ok := m.GetMatchType().(*StringMatch_Range); ok {
+		return x.Range
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*StringMatch) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*StringMatch_Exact)(nil),
 		(*StringMatch_Prefix)(nil),
 		(*StringMatch_Regex)(nil),
+		(*StringMatch_Range)(nil),
+	}
+}
+
+// Specifies the int64 range [start, end).
+type Int64Range struct {
+	// Start of the range, inclusive.
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	// End of the range, exclusive, it must be greater than the start.
+	End                  int64    `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Int64Range) Reset()         { *m = Int64Range{} }
+func (m *Int64Range) String() string { return proto.CompactTextString(m) }
+func (*Int64Range) ProtoMessage()    {}
+func (*Int64Range) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e2715e051935576, []int{7}
+}
+func (m *Int64Range) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Int64Range) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Int64Range.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Int64Range) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Int64Range.Merge(m, src)
+}
+func (m *Int64Range) XXX_Size() int {
+	return m.Size()
+}
+func (m *Int64Range) XXX_DiscardUnknown() {
+	xxx_messageInfo_Int64Range.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Int64Range proto.InternalMessageInfo
+
+func (m *Int64Range) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *Int64Range) GetEnd() int64 {
+	if m != nil {
+		return m.End
 	}
+	return 0
 }
 
 type MetaRouteDestination struct {
@@ -552,7 +794,7 @@ func (m *MetaRouteDestination) Reset()         { *m = MetaRouteDestination{} }
 func (m *MetaRouteDestination) String() string { return proto.CompactTextString(m) }
 func (*MetaRouteDestination) ProtoMessage()    {}
 func (*MetaRouteDestination) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{5}
+	return fileDescriptor_1e2715e051935576, []int{8}
 }
 func (m *MetaRouteDestination) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -628,7 +870,7 @@ func (m *Destination) Reset()         { *m = Destination{} }
 func (m *Destination) String() string { return proto.CompactTextString(m) }
 func (*Destination) ProtoMessage()    {}
 func (*Destination) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{6}
+	return fileDescriptor_1e2715e051935576, []int{9}
 }
 func (m *Destination) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -692,7 +934,7 @@ func (m *PortSelector) Reset()         { *m = PortSelector{} }
 func (m *PortSelector) String() string { return proto.CompactTextString(m) }
 func (*PortSelector) ProtoMessage()    {}
 func (*PortSelector) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{7}
+	return fileDescriptor_1e2715e051935576, []int{10}
 }
 func (m *PortSelector) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -750,7 +992,7 @@ func (m *LocalRateLimit) Reset()         { *m = LocalRateLimit{} }
 func (m *LocalRateLimit) String() string { return proto.CompactTextString(m) }
 func (*LocalRateLimit) ProtoMessage()    {}
 func (*LocalRateLimit) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{8}
+	return fileDescriptor_1e2715e051935576, []int{11}
 }
 func (m *LocalRateLimit) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -814,7 +1056,7 @@ func (m *LocalRateLimit_TokenBucket) Reset()         { *m = LocalRateLimit_Token
 func (m *LocalRateLimit_TokenBucket) String() string { return proto.CompactTextString(m) }
 func (*LocalRateLimit_TokenBucket) ProtoMessage()    {}
 func (*LocalRateLimit_TokenBucket) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{8, 0}
+	return fileDescriptor_1e2715e051935576, []int{11, 0}
 }
 func (m *LocalRateLimit_TokenBucket) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -881,7 +1123,7 @@ func (m *LocalRateLimit_Condition) Reset()         { *m = LocalRateLimit_Conditi
 func (m *LocalRateLimit_Condition) String() string { return proto.CompactTextString(m) }
 func (*LocalRateLimit_Condition) ProtoMessage()    {}
 func (*LocalRateLimit_Condition) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{8, 1}
+	return fileDescriptor_1e2715e051935576, []int{11, 1}
 }
 func (m *LocalRateLimit_Condition) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -952,7 +1194,7 @@ func (m *GlobalRateLimit) Reset()         { *m = GlobalRateLimit{} }
 func (m *GlobalRateLimit) String() string { return proto.CompactTextString(m) }
 func (*GlobalRateLimit) ProtoMessage()    {}
 func (*GlobalRateLimit) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{9}
+	return fileDescriptor_1e2715e051935576, []int{12}
 }
 func (m *GlobalRateLimit) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1040,7 +1282,7 @@ func (m *GlobalRateLimit_Descriptor) Reset()         { *m = GlobalRateLimit_Desc
 func (m *GlobalRateLimit_Descriptor) String() string { return proto.CompactTextString(m) }
 func (*GlobalRateLimit_Descriptor) ProtoMessage()    {}
 func (*GlobalRateLimit_Descriptor) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{9, 0}
+	return fileDescriptor_1e2715e051935576, []int{12, 0}
 }
 func (m *GlobalRateLimit_Descriptor) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1083,6 +1325,85 @@ func (m *GlobalRateLimit_Descriptor) GetDescriptorKey() string {
 	return ""
 }
 
+// OutlierDetection configures the passive health checking of the upstream hosts. The hosts which keep failing are
+// ejected from the load balancing pool for a period of time.
+type OutlierDetection struct {
+	// Number of consecutive errors before a host is ejected from the load balancing pool. Both the failures of the
+	// connections to the host and the error responses are counted. The value must be greater than 0.
+	ConsecutiveErrors uint32 `protobuf:"varint,1,opt,name=consecutive_errors,json=consecutiveErrors,proto3" json:"consecutive_errors,omitempty"`
+	// Time interval between ejection sweep analysis. format: 1h/1m/1s/1ms. MUST BE >=1ms. Default is 10s.
+	Interval *types.Duration `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Minimum ejection duration. A host will remain ejected for a period equal to the product of minimum ejection
+	// duration and the number of times the host has been ejected. format: 1h/1m/1s/1ms. MUST BE >=1ms. Default is 30s.
+	BaseEjectionTime *types.Duration `protobuf:"bytes,3,opt,name=base_ejection_time,json=baseEjectionTime,proto3" json:"base_ejection_time,omitempty"`
+	// Maximum % of hosts in the load balancing pool for the destination service that can be ejected. Defaults to 10%.
+	MaxEjectionPercent   uint32   `protobuf:"varint,4,opt,name=max_ejection_percent,json=maxEjectionPercent,proto3" json:"max_ejection_percent,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OutlierDetection) Reset()         { *m = OutlierDetection{} }
+func (m *OutlierDetection) String() string { return proto.CompactTextString(m) }
+func (*OutlierDetection) ProtoMessage()    {}
+func (*OutlierDetection) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e2715e051935576, []int{13}
+}
+func (m *OutlierDetection) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *OutlierDetection) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_OutlierDetection.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *OutlierDetection) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OutlierDetection.Merge(m, src)
+}
+func (m *OutlierDetection) XXX_Size() int {
+	return m.Size()
+}
+func (m *OutlierDetection) XXX_DiscardUnknown() {
+	xxx_messageInfo_OutlierDetection.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OutlierDetection proto.InternalMessageInfo
+
+func (m *OutlierDetection) GetConsecutiveErrors() uint32 {
+	if m != nil {
+		return m.ConsecutiveErrors
+	}
+	return 0
+}
+
+func (m *OutlierDetection) GetInterval() *types.Duration {
+	if m != nil {
+		return m.Interval
+	}
+	return nil
+}
+
+func (m *OutlierDetection) GetBaseEjectionTime() *types.Duration {
+	if m != nil {
+		return m.BaseEjectionTime
+	}
+	return nil
+}
+
+func (m *OutlierDetection) GetMaxEjectionPercent() uint32 {
+	if m != nil {
+		return m.MaxEjectionPercent
+	}
+	return 0
+}
+
 // Percent specifies a percentage in the range of [0.0, 100.0].
 type Percent struct {
 	Value                float64  `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
@@ -1095,7 +1416,7 @@ func (m *Percent) Reset()         { *m = Percent{} }
 func (m *Percent) String() string { return proto.CompactTextString(m) }
 func (*Percent) ProtoMessage()    {}
 func (*Percent) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1e2715e051935576, []int{10}
+	return fileDescriptor_1e2715e051935576, []int{14}
 }
 func (m *Percent) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1134,10 +1455,13 @@ func (m *Percent) GetValue() float64 {
 func init() {
 	proto.RegisterType((*MetaRouter)(nil), "metaprotocol.aeraki.io.v1alpha1.MetaRouter")
 	proto.RegisterType((*MetaRoute)(nil), "metaprotocol.aeraki.io.v1alpha1.MetaRoute")
+	proto.RegisterType((*HashPolicy)(nil), "metaprotocol.aeraki.io.v1alpha1.HashPolicy")
+	proto.RegisterType((*MetaRouteMirror)(nil), "metaprotocol.aeraki.io.v1alpha1.MetaRouteMirror")
 	proto.RegisterType((*KeyValue)(nil), "metaprotocol.aeraki.io.v1alpha1.KeyValue")
 	proto.RegisterType((*MetaRouteMatch)(nil), "metaprotocol.aeraki.io.v1alpha1.MetaRouteMatch")
 	proto.RegisterMapType((map[string]*StringMatch)(nil), "metaprotocol.aeraki.io.v1alpha1.MetaRouteMatch.AttributesEntry")
 	proto.RegisterType((*StringMatch)(nil), "metaprotocol.aeraki.io.v1alpha1.StringMatch")
+	proto.RegisterType((*Int64Range)(nil), "metaprotocol.aeraki.io.v1alpha1.Int64Range")
 	proto.RegisterType((*MetaRouteDestination)(nil), "metaprotocol.aeraki.io.v1alpha1.MetaRouteDestination")
 	proto.RegisterType((*Destination)(nil), "metaprotocol.aeraki.io.v1alpha1.Destination")
 	proto.RegisterType((*PortSelector)(nil), "metaprotocol.aeraki.io.v1alpha1.PortSelector")
@@ -1146,6 +1470,7 @@ func init() {
 	proto.RegisterType((*LocalRateLimit_Condition)(nil), "metaprotocol.aeraki.io.v1alpha1.LocalRateLimit.Condition")
 	proto.RegisterType((*GlobalRateLimit)(nil), "metaprotocol.aeraki.io.v1alpha1.GlobalRateLimit")
 	proto.RegisterType((*GlobalRateLimit_Descriptor)(nil), "metaprotocol.aeraki.io.v1alpha1.GlobalRateLimit.Descriptor")
+	proto.RegisterType((*OutlierDetection)(nil), "metaprotocol.aeraki.io.v1alpha1.OutlierDetection")
 	proto.RegisterType((*Percent)(nil), "metaprotocol.aeraki.io.v1alpha1.Percent")
 }
 
@@ -1154,76 +1479,96 @@ func init() {
 }
 
 var fileDescriptor_1e2715e051935576 = []byte{
-	// 1096 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x96, 0x5b, 0x6f, 0x1b, 0x45,
-	0x14, 0xc7, 0xbb, 0xbe, 0x11, 0x1f, 0xe7, 0xe2, 0x0e, 0xa1, 0x6c, 0x5d, 0x48, 0x22, 0x3f, 0x85,
-	0x8a, 0xae, 0xa9, 0x2b, 0x54, 0xa0, 0x48, 0xa8, 0x6e, 0x5a, 0x7a, 0x15, 0xd1, 0x26, 0xad, 0x14,
-	0x84, 0x58, 0x8d, 0xd7, 0x27, 0xf6, 0x28, 0xbb, 0x3b, 0xcb, 0xec, 0x38, 0xb1, 0x1f, 0x11, 0x1f,
-	0x8a, 0x17, 0x1e, 0x79, 0xe0, 0x11, 0xf1, 0xc4, 0x13, 0xaa, 0xf2, 0x41, 0x10, 0x9a, 0x8b, 0xed,
-	0x75, 0xa0, 0x72, 0x0c, 0xbc, 0xf9, 0x9c, 0xe3, 0xff, 0x6f, 0xe6, 0xcc, 0x9c, 0x3d, 0x67, 0xe0,
-	0x2e, 0x4d, 0x59, 0x2b, 0x46, 0x49, 0x53, 0xc1, 0x25, 0x0f, 0x79, 0xd4, 0x3a, 0xbd, 0x4d, 0xa3,
-	0x74, 0x40, 0x6f, 0xcf, 0x79, 0x03, 0x65, 0x08, 0x3e, 0x94, 0x28, 0x3c, 0xed, 0x23, 0xdb, 0xf9,
-	0xb0, 0x47, 0x51, 0xd0, 0x13, 0xe6, 0x31, 0xee, 0x4d, 0xe4, 0x8d, 0xed, 0x3e, 0xe7, 0xfd, 0x08,
-	0x5b, 0x6a, 0x81, 0x63, 0x86, 0x51, 0x2f, 0xe8, 0xe2, 0x80, 0x9e, 0x32, 0x6e, 0x09, 0x8d, 0x2d,
-	0xfb, 0x07, 0x6d, 0x75, 0x87, 0xc7, 0xad, 0xde, 0x50, 0x50, 0xc9, 0x78, 0xf2, 0xa6, 0xf8, 0x99,
-	0xa0, 0x69, 0x8a, 0x22, 0x33, 0xf1, 0xe6, 0xef, 0x05, 0x80, 0x17, 0x28, 0xa9, 0xaf, 0xb7, 0x45,
-	0x36, 0xa1, 0x3c, 0xe0, 0x99, 0xcc, 0x5c, 0x67, 0xa7, 0xb8, 0x5b, 0xf5, 0x8d, 0x41, 0x1a, 0xb0,
-	0xd2, 0xa7, 0x12, 0xcf, 0xe8, 0x38, 0x73, 0x0b, 0x3a, 0x30, 0xb5, 0x49, 0x07, 0x2a, 0x3a, 0xa5,
-	0xcc, 0x2d, 0xee, 0x14, 0x77, 0x6b, 0xed, 0x9b, 0xde, 0x82, 0x9c, 0xbc, 0xe9, 0x72, 0xbe, 0x55,
-	0x92, 0x23, 0xa8, 0x47, 0x3c, 0xa4, 0x51, 0x20, 0xa8, 0xc4, 0x20, 0x62, 0x31, 0x93, 0x6e, 0x69,
-	0xc7, 0xd9, 0xad, 0xb5, 0x5b, 0x0b, 0x69, 0xcf, 0x95, 0xd0, 0xa7, 0x12, 0x9f, 0x2b, 0x99, 0xbf,
-	0x1e, 0xcd, 0xd9, 0xe4, 0x1b, 0xb8, 0xda, 0x8f, 0x78, 0x77, 0x9e, 0x5d, 0xd6, 0xec, 0x8f, 0x16,
-	0xb2, 0xbf, 0xd4, 0xca, 0x19, 0x7c, 0xa3, 0x3f, 0xef, 0x20, 0x37, 0xa0, 0x8a, 0xa3, 0x94, 0x0b,
-	0x19, 0x48, 0xee, 0x6e, 0x9a, 0x93, 0x31, 0x8e, 0x43, 0xde, 0xfc, 0xb3, 0x08, 0xd5, 0x69, 0xae,
-	0x84, 0x40, 0x29, 0xa1, 0x31, 0xba, 0xce, 0x8e, 0xb3, 0x5b, 0xf5, 0xf5, 0x6f, 0xf2, 0x10, 0xca,
-	0x31, 0x95, 0xe1, 0xc0, 0x2d, 0x5c, 0x32, 0xd9, 0x29, 0xee, 0x85, 0x92, 0xf9, 0x46, 0x4d, 0x9e,
-	0x41, 0x59, 0x1f, 0xa4, 0xbd, 0x81, 0x8f, 0x2f, 0x8f, 0xd9, 0xc3, 0x4c, 0xb2, 0x44, 0xd7, 0x8b,
-	0x6f, 0x18, 0x64, 0x0f, 0x2a, 0x31, 0x13, 0x82, 0x0b, 0x7b, 0x4a, 0x1f, 0x2e, 0xa4, 0xe5, 0x21,
-	0x56, 0x4b, 0x5e, 0xc2, 0x55, 0xf3, 0x2b, 0x48, 0x51, 0x84, 0x98, 0x48, 0xda, 0x47, 0xb7, 0xa2,
-	0x81, 0xbb, 0x0b, 0x81, 0xfb, 0x46, 0xe2, 0xd7, 0x0d, 0x62, 0x7f, 0x4a, 0x20, 0x87, 0x50, 0x17,
-	0xf8, 0xdd, 0x10, 0x33, 0x19, 0xc4, 0x43, 0xa9, 0x97, 0x74, 0xdf, 0xd6, 0x49, 0x7f, 0xb0, 0x90,
-	0xfa, 0x0c, 0xc7, 0xaf, 0x68, 0x34, 0x44, 0x7f, 0xc3, 0x22, 0x5e, 0x58, 0x02, 0x79, 0x05, 0x57,
-	0x05, 0x66, 0x29, 0x4f, 0x32, 0x9c, 0x61, 0x37, 0x97, 0xc5, 0xd6, 0x27, 0x8c, 0x09, 0xb7, 0xf9,
-	0x39, 0xac, 0x4c, 0xa2, 0xe4, 0x1d, 0x28, 0x9e, 0xe0, 0xd8, 0xdc, 0x7e, 0xa7, 0xf8, 0xfa, 0x7e,
-	0xc1, 0x57, 0x36, 0xb9, 0x0e, 0xe5, 0x53, 0x15, 0xd7, 0x15, 0x60, 0x03, 0xc6, 0xd3, 0xfc, 0xc3,
-	0x81, 0xf5, 0xf9, 0xfb, 0x26, 0x01, 0x00, 0x95, 0x52, 0xb0, 0xae, 0xfe, 0xde, 0x1c, 0xbd, 0xc3,
-	0x2f, 0x96, 0x2c, 0x1a, 0xef, 0xfe, 0x94, 0xf0, 0x30, 0x91, 0x62, 0xec, 0xe7, 0x90, 0x8d, 0x13,
-	0xd8, 0xb8, 0x10, 0x26, 0xf5, 0xdc, 0xc6, 0xcd, 0x9e, 0x3b, 0xf9, 0x3d, 0x5f, 0xa6, 0x40, 0x0e,
-	0xa4, 0x60, 0x49, 0xdf, 0x96, 0xac, 0x96, 0x7e, 0x56, 0xf8, 0xc4, 0x69, 0x22, 0xd4, 0x72, 0x11,
-	0x72, 0x0d, 0xca, 0x38, 0xa2, 0xa1, 0x34, 0x4b, 0x3d, 0xbe, 0xe2, 0x1b, 0x93, 0xb8, 0x50, 0x49,
-	0x05, 0x1e, 0xb3, 0x91, 0x39, 0xa3, 0xc7, 0x57, 0x7c, 0x6b, 0x2b, 0x85, 0xc0, 0x3e, 0x8e, 0xdc,
-	0xe2, 0x44, 0xa1, 0xcd, 0xce, 0x2a, 0x80, 0xfe, 0x30, 0x02, 0x39, 0x4e, 0xb1, 0xf9, 0x83, 0x03,
-	0x9b, 0xff, 0x54, 0xf0, 0xe4, 0x00, 0x6a, 0xbd, 0x99, 0xa9, 0x97, 0x5d, 0xb2, 0xdc, 0xcd, 0x7d,
-	0xe5, 0x29, 0xe4, 0x1a, 0x54, 0xce, 0x90, 0xf5, 0x07, 0x52, 0xef, 0x76, 0xcd, 0xb7, 0x56, 0xf3,
-	0x7b, 0x07, 0x6a, 0xf9, 0xc5, 0xdf, 0x85, 0x92, 0xea, 0xad, 0xf9, 0x82, 0xd0, 0x0e, 0x05, 0xc8,
-	0x86, 0xdd, 0x0c, 0x0d, 0xa0, 0xea, 0x5b, 0x8b, 0xdc, 0x87, 0x92, 0xea, 0x2b, 0x3a, 0xd7, 0x5a,
-	0xfb, 0xd6, 0xe2, 0x8f, 0x88, 0x0b, 0x79, 0x80, 0x11, 0x86, 0x92, 0x0b, 0x5f, 0x4b, 0x9b, 0x6d,
-	0x58, 0xcd, 0x7b, 0xd5, 0x52, 0xc9, 0x30, 0xee, 0xa2, 0xd0, 0xbb, 0x58, 0xf3, 0xad, 0xf5, 0xb4,
-	0xb4, 0x52, 0xa8, 0x17, 0x4d, 0x8b, 0x6a, 0xfe, 0x5c, 0x82, 0xf5, 0xf9, 0x16, 0x4b, 0xbe, 0x85,
-	0x55, 0xc9, 0x4f, 0x30, 0x09, 0xba, 0xc3, 0xf0, 0x04, 0xa5, 0x3d, 0xb8, 0x7b, 0x4b, 0x76, 0x6a,
-	0xef, 0x50, 0x31, 0x3a, 0x1a, 0xe1, 0xd7, 0xe4, 0xcc, 0x20, 0x47, 0x00, 0x21, 0x4f, 0x7a, 0x4c,
-	0x9d, 0x93, 0x99, 0x37, 0xb5, 0xf6, 0xa7, 0xcb, 0xd2, 0x1f, 0x4c, 0x08, 0x7e, 0x0e, 0xd6, 0xf8,
-	0xd1, 0x81, 0x5a, 0x6e, 0x5d, 0xf2, 0xbe, 0xaa, 0x94, 0x51, 0xa0, 0x57, 0xcf, 0xec, 0x29, 0x54,
-	0x63, 0x3a, 0xd2, 0xff, 0xc9, 0xc8, 0x1e, 0x6c, 0x98, 0x90, 0xea, 0x62, 0xc1, 0x31, 0x8b, 0x22,
-	0x5b, 0xf3, 0xef, 0x79, 0x66, 0xac, 0x7a, 0x93, 0xb1, 0xea, 0xbd, 0x7c, 0x92, 0xc8, 0x3b, 0x6d,
-	0xd3, 0x09, 0xd6, 0x8c, 0x68, 0x1f, 0xc5, 0x23, 0x16, 0x45, 0xe4, 0x01, 0xac, 0x29, 0x69, 0xc0,
-	0x12, 0x89, 0xe2, 0x94, 0x46, 0xf6, 0x0a, 0xaf, 0xff, 0x8d, 0xb1, 0x67, 0x47, 0xb7, 0x29, 0x87,
-	0x55, 0x25, 0x7a, 0x62, 0x35, 0x8d, 0x9f, 0x1c, 0xa8, 0x4e, 0x73, 0x22, 0x4f, 0x27, 0x83, 0xc3,
-	0xf9, 0x57, 0x83, 0xc3, 0xf6, 0x19, 0x33, 0x3d, 0xc2, 0x0b, 0xd7, 0x59, 0xf8, 0xcf, 0xd7, 0x69,
-	0x3f, 0x8b, 0xdc, 0x9d, 0x36, 0x7f, 0x2b, 0xc2, 0xc6, 0x85, 0x69, 0xfa, 0xbf, 0x26, 0x71, 0x03,
-	0x2a, 0x3d, 0x1e, 0x53, 0x96, 0xe4, 0x1b, 0xa9, 0x75, 0x91, 0x0e, 0x4c, 0x5a, 0x7e, 0x20, 0x59,
-	0x8c, 0x7c, 0x28, 0x17, 0x5e, 0x81, 0xbf, 0x6e, 0x15, 0x87, 0x46, 0x40, 0x76, 0x60, 0xb5, 0x87,
-	0xc9, 0x38, 0xe0, 0x49, 0x70, 0x4c, 0x59, 0xa4, 0x9f, 0x27, 0x2b, 0x3e, 0x28, 0xdf, 0x57, 0xc9,
-	0x23, 0xca, 0x22, 0x72, 0x1b, 0xc8, 0xec, 0x89, 0x11, 0x64, 0x28, 0x4e, 0x59, 0x88, 0x7a, 0x88,
-	0xda, 0xed, 0xd4, 0xc5, 0x24, 0xf5, 0x03, 0x13, 0x24, 0x5d, 0xdd, 0x81, 0x42, 0xc1, 0x52, 0xc9,
-	0x45, 0xe6, 0x56, 0x74, 0xa9, 0xdf, 0x5b, 0xf6, 0x59, 0xa2, 0x3a, 0x92, 0x65, 0xcc, 0x1a, 0xd2,
-	0x04, 0xda, 0x38, 0x02, 0x98, 0xc5, 0xc9, 0x36, 0xac, 0xa4, 0x82, 0xa7, 0x28, 0xe4, 0xdc, 0x2c,
-	0x9a, 0x3a, 0xc9, 0x4d, 0x58, 0x9f, 0xa9, 0x03, 0xd5, 0xf9, 0x73, 0x07, 0xba, 0x36, 0x0b, 0x3d,
-	0xc3, 0x71, 0x73, 0x1b, 0xde, 0xb2, 0xb3, 0x59, 0xbd, 0x1b, 0xcd, 0x4c, 0x50, 0x50, 0xc7, 0x76,
-	0xf9, 0xce, 0xc3, 0x5f, 0xce, 0xb7, 0x9c, 0x5f, 0xcf, 0xb7, 0x9c, 0xd7, 0xe7, 0x5b, 0xce, 0xd7,
-	0x77, 0xfb, 0x4c, 0x0e, 0x86, 0x5d, 0x2f, 0xe4, 0x71, 0xcb, 0x64, 0x75, 0x2b, 0xc6, 0x6c, 0x60,
-	0x7f, 0xb7, 0xde, 0xf8, 0x86, 0xee, 0x56, 0xb4, 0xeb, 0xce, 0x5f, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x81, 0x99, 0x95, 0xdc, 0x67, 0x0b, 0x00, 0x00,
+	// 1413 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0x4b, 0x73, 0x1b, 0x45,
+	0x10, 0xce, 0xea, 0x15, 0xab, 0xe5, 0x87, 0x3c, 0x98, 0xd4, 0x22, 0x82, 0xa3, 0xda, 0xe2, 0x60,
+	0x08, 0x91, 0x13, 0x39, 0x21, 0x3c, 0xaa, 0xa0, 0xe2, 0xd8, 0x89, 0xf3, 0xaa, 0xb8, 0x26, 0x4e,
+	0xa8, 0x00, 0x95, 0xad, 0xd1, 0x6a, 0x2c, 0x0d, 0x5e, 0xed, 0x2c, 0xb3, 0xb3, 0x8e, 0x75, 0xa5,
+	0xf8, 0x35, 0xdc, 0xa8, 0xe2, 0xc0, 0x89, 0x23, 0x70, 0xe4, 0xc8, 0x89, 0xa2, 0xfc, 0x2f, 0xb8,
+	0x51, 0xf3, 0x58, 0x69, 0xa5, 0x90, 0x92, 0x1d, 0xe0, 0xb6, 0xdd, 0x3d, 0xdf, 0xd7, 0x33, 0xdd,
+	0x3d, 0x3d, 0xbd, 0x70, 0x9d, 0xc4, 0x6c, 0x7d, 0x40, 0x25, 0x89, 0x05, 0x97, 0x3c, 0xe0, 0xe1,
+	0xfa, 0xe1, 0x15, 0x12, 0xc6, 0x7d, 0x72, 0x65, 0x42, 0xeb, 0x2b, 0x41, 0xf0, 0x54, 0x52, 0xd1,
+	0xd2, 0x3a, 0x74, 0x21, 0x6f, 0x6e, 0x11, 0x2a, 0xc8, 0x01, 0x6b, 0x31, 0xde, 0xca, 0xe0, 0x8d,
+	0x0b, 0x3d, 0xce, 0x7b, 0x21, 0x5d, 0x57, 0x0e, 0xf6, 0x19, 0x0d, 0xbb, 0x7e, 0x87, 0xf6, 0xc9,
+	0x21, 0xe3, 0x96, 0xa1, 0xb1, 0x6a, 0x17, 0x68, 0xa9, 0x93, 0xee, 0xaf, 0x77, 0x53, 0x41, 0x24,
+	0xe3, 0xd1, 0xcb, 0xec, 0xcf, 0x05, 0x89, 0x63, 0x2a, 0x12, 0x63, 0xf7, 0x7e, 0x2e, 0x01, 0x3c,
+	0xa0, 0x92, 0x60, 0xbd, 0x2d, 0xb4, 0x02, 0xe5, 0x3e, 0x4f, 0x64, 0xe2, 0x3a, 0xcd, 0xe2, 0x5a,
+	0x15, 0x1b, 0x01, 0x35, 0x60, 0xae, 0x47, 0x24, 0x7d, 0x4e, 0x86, 0x89, 0x5b, 0xd0, 0x86, 0x91,
+	0x8c, 0x36, 0xa1, 0xa2, 0x8f, 0x94, 0xb8, 0xc5, 0x66, 0x71, 0xad, 0xd6, 0x7e, 0xb7, 0x35, 0xe3,
+	0x4c, 0xad, 0x91, 0x3b, 0x6c, 0x91, 0xe8, 0x29, 0xd4, 0x43, 0x1e, 0x90, 0xd0, 0x17, 0x44, 0x52,
+	0x3f, 0x64, 0x03, 0x26, 0xdd, 0x52, 0xd3, 0x59, 0xab, 0xb5, 0xd7, 0x67, 0xb2, 0xdd, 0x57, 0x40,
+	0x4c, 0x24, 0xbd, 0xaf, 0x60, 0x78, 0x31, 0x9c, 0x90, 0xd1, 0x97, 0xb0, 0xdc, 0x0b, 0x79, 0x67,
+	0x92, 0xbb, 0xac, 0xb9, 0x2f, 0xcf, 0xe4, 0xbe, 0xad, 0x91, 0x63, 0xf2, 0xa5, 0xde, 0xa4, 0x02,
+	0x3d, 0x83, 0x65, 0x9e, 0xca, 0x90, 0x51, 0xe1, 0x77, 0xa9, 0xa4, 0x81, 0x0a, 0xbc, 0x5b, 0xd1,
+	0xec, 0x57, 0x66, 0xb2, 0x3f, 0x34, 0xc8, 0xad, 0x0c, 0x88, 0xeb, 0x7c, 0x4a, 0x83, 0x3e, 0x83,
+	0xfa, 0x3e, 0x09, 0xc3, 0x0e, 0x09, 0x0e, 0xfc, 0x20, 0x4c, 0x13, 0x49, 0x85, 0x7b, 0x56, 0xd3,
+	0xbf, 0x37, 0x93, 0x7e, 0x8b, 0x26, 0x92, 0x45, 0xba, 0x16, 0xf0, 0x52, 0xc6, 0x72, 0xd3, 0x90,
+	0xa0, 0x0d, 0x78, 0x3d, 0x26, 0x49, 0x22, 0xfb, 0x82, 0xa7, 0xbd, 0xbe, 0x9f, 0x46, 0x03, 0x22,
+	0x83, 0x3e, 0xed, 0xba, 0x73, 0x4d, 0x67, 0x6d, 0x0e, 0xaf, 0xe4, 0x8c, 0x8f, 0x33, 0x1b, 0x7a,
+	0x13, 0xaa, 0xf4, 0x28, 0xe6, 0x42, 0xfa, 0x92, 0xbb, 0x2b, 0xa6, 0x0e, 0x8c, 0x62, 0x8f, 0x7b,
+	0x3f, 0x94, 0xa1, 0x3a, 0xca, 0x2c, 0x42, 0x50, 0x8a, 0xc8, 0x80, 0xba, 0x4e, 0xd3, 0x59, 0xab,
+	0x62, 0xfd, 0x8d, 0xb6, 0xa1, 0xac, 0x99, 0xdc, 0xc2, 0x09, 0x53, 0x3b, 0xa2, 0x7b, 0xa0, 0x60,
+	0xd8, 0xa0, 0xd1, 0x3d, 0x28, 0xeb, 0xb2, 0xb1, 0xf5, 0x76, 0xed, 0xe4, 0x34, 0xf9, 0x88, 0x18,
+	0x0e, 0xb4, 0x05, 0x95, 0x01, 0x13, 0x82, 0x0b, 0xb7, 0xfc, 0x0a, 0x61, 0xb5, 0x58, 0xf4, 0x18,
+	0x96, 0xcd, 0x97, 0x1f, 0x53, 0x11, 0xd0, 0x48, 0x92, 0x1e, 0xb5, 0x65, 0xb0, 0x36, 0x93, 0x70,
+	0xd7, 0x40, 0x70, 0xdd, 0x50, 0xec, 0x8e, 0x18, 0xd0, 0x5d, 0x38, 0x6b, 0x74, 0x89, 0xbb, 0xd8,
+	0x2c, 0x9e, 0xa8, 0x62, 0xc7, 0x21, 0xd3, 0x40, 0x9c, 0x11, 0xa0, 0xfb, 0x50, 0xeb, 0x93, 0xa4,
+	0xef, 0xc7, 0x3c, 0x64, 0xc1, 0xd0, 0x16, 0xd1, 0xc5, 0x99, 0x7c, 0x3b, 0x24, 0xe9, 0xef, 0x6a,
+	0x08, 0x86, 0xfe, 0xe8, 0x5b, 0x35, 0x84, 0x58, 0x30, 0x2e, 0x98, 0x1c, 0xba, 0xd5, 0xa6, 0xb3,
+	0xb6, 0x80, 0x47, 0x32, 0xda, 0x83, 0xba, 0xa0, 0x5f, 0xa7, 0x34, 0x91, 0xfe, 0x20, 0x95, 0x3a,
+	0x50, 0xee, 0x6b, 0x7a, 0xfb, 0xef, 0xcc, 0x74, 0x77, 0x8f, 0x0e, 0x9f, 0x90, 0x30, 0xa5, 0x78,
+	0xc9, 0x52, 0x3c, 0xb0, 0x0c, 0xe8, 0x09, 0x2c, 0x0b, 0x9a, 0xc4, 0x3c, 0x4a, 0xe8, 0x98, 0x76,
+	0xe5, 0xb4, 0xb4, 0xf5, 0x8c, 0x23, 0xe3, 0xf5, 0xda, 0x00, 0xe3, 0x33, 0xa2, 0xb7, 0x01, 0x88,
+	0x94, 0x82, 0x75, 0x74, 0x43, 0xd3, 0x3d, 0x70, 0xb3, 0x74, 0x7c, 0xc3, 0x29, 0xe0, 0x9c, 0xde,
+	0xfb, 0xde, 0x81, 0xa5, 0xa9, 0x40, 0xa3, 0x3d, 0xa8, 0x75, 0xc7, 0x95, 0xe1, 0x3a, 0xa7, 0xaf,
+	0x26, 0xeb, 0x28, 0x4f, 0x83, 0x76, 0x00, 0x72, 0x15, 0x55, 0x38, 0x65, 0x45, 0xe5, 0xb0, 0xde,
+	0x27, 0x30, 0x97, 0x45, 0x01, 0x9d, 0x83, 0xe2, 0x01, 0x1d, 0x9a, 0xbb, 0x69, 0xbd, 0x2a, 0x05,
+	0x6a, 0x40, 0xf9, 0x50, 0x2d, 0x70, 0x0b, 0x39, 0x8b, 0x51, 0x79, 0x7f, 0x38, 0xb0, 0x38, 0x79,
+	0x1f, 0x91, 0xff, 0x42, 0xb0, 0x6a, 0xed, 0x4f, 0x4f, 0x79, 0xa9, 0x5b, 0x37, 0x46, 0x0c, 0xdb,
+	0x91, 0x14, 0xc3, 0x7c, 0x9c, 0x1b, 0x07, 0xb0, 0x34, 0x65, 0x46, 0xf5, 0xdc, 0xd6, 0xcd, 0xa6,
+	0x37, 0xf3, 0x9b, 0x3e, 0x49, 0xc8, 0x1f, 0x49, 0xc1, 0xa2, 0x9e, 0x6d, 0x29, 0x1a, 0xfa, 0x51,
+	0xe1, 0x03, 0xc7, 0xfb, 0xce, 0x81, 0x5a, 0xce, 0x84, 0xce, 0x41, 0x99, 0x1e, 0x91, 0x40, 0x1a,
+	0x5f, 0x3b, 0x67, 0xb0, 0x11, 0x91, 0x0b, 0x95, 0x58, 0xd0, 0x7d, 0x76, 0x64, 0xa2, 0xb4, 0x73,
+	0x06, 0x5b, 0x59, 0x21, 0x04, 0xed, 0xd1, 0x23, 0xb7, 0x98, 0x21, 0xb4, 0x88, 0x6e, 0x42, 0x59,
+	0x90, 0xa8, 0x47, 0xdd, 0xd2, 0x09, 0x2f, 0xdd, 0x9d, 0x48, 0xbe, 0x7f, 0x15, 0x2b, 0x88, 0x26,
+	0x51, 0x1f, 0x9b, 0xf3, 0x00, 0xba, 0xfd, 0xf9, 0x72, 0x18, 0x53, 0xef, 0x2a, 0xc0, 0x78, 0x91,
+	0x7a, 0xb4, 0x13, 0x49, 0x84, 0xd9, 0x6a, 0x11, 0x1b, 0x41, 0x85, 0x8a, 0x46, 0x5d, 0xbd, 0xcb,
+	0x22, 0x56, 0x9f, 0xde, 0xb7, 0x0e, 0xac, 0xfc, 0x53, 0x33, 0xfc, 0x9f, 0x8a, 0xf7, 0x1c, 0x54,
+	0x9e, 0x53, 0xd6, 0xeb, 0x4b, 0xbd, 0x87, 0x05, 0x6c, 0x25, 0xef, 0x1b, 0x07, 0x6a, 0x79, 0xef,
+	0x2e, 0x94, 0xd4, 0x98, 0x31, 0x51, 0x8f, 0x5a, 0xa3, 0x18, 0x92, 0xb4, 0x93, 0x50, 0xc3, 0x50,
+	0xc5, 0x56, 0x42, 0x37, 0xa0, 0xa4, 0x5e, 0x1d, 0x1d, 0xe8, 0x5a, 0xfb, 0xd2, 0xec, 0x0b, 0xc1,
+	0x85, 0x7c, 0x44, 0x43, 0x1a, 0x48, 0x2e, 0xb0, 0x86, 0x7a, 0x6d, 0x98, 0xcf, 0x6b, 0x95, 0xab,
+	0x28, 0x1d, 0x74, 0xa8, 0xd0, 0xdb, 0x58, 0xc0, 0x56, 0xba, 0x5b, 0x9a, 0x2b, 0xd4, 0x8b, 0xe6,
+	0x01, 0xf3, 0x7e, 0x29, 0xc1, 0xe2, 0xe4, 0xb8, 0x81, 0x9e, 0xc1, 0xbc, 0xe4, 0x07, 0x34, 0xf2,
+	0x3b, 0x69, 0x70, 0x40, 0xa5, 0x0d, 0xdd, 0xc7, 0xa7, 0x9c, 0x5a, 0x5a, 0x7b, 0x8a, 0x63, 0x53,
+	0x53, 0xe0, 0x9a, 0x1c, 0x0b, 0xe8, 0x29, 0x40, 0xc0, 0xa3, 0x2e, 0x53, 0x81, 0x32, 0xb3, 0x57,
+	0xad, 0xfd, 0xe1, 0x69, 0xd9, 0x6f, 0x66, 0x0c, 0x38, 0x47, 0xd6, 0xf8, 0xd1, 0x81, 0x5a, 0xce,
+	0x2f, 0x7a, 0x4b, 0x55, 0xd8, 0x91, 0xaf, 0xbd, 0x27, 0x36, 0x0a, 0xd5, 0x01, 0x39, 0xd2, 0x6b,
+	0x12, 0xb4, 0x05, 0x4b, 0xc6, 0xa4, 0xde, 0x38, 0x7f, 0x9f, 0x85, 0xa1, 0xbd, 0x71, 0xe7, 0x5b,
+	0x66, 0xc4, 0x6c, 0x65, 0x23, 0x66, 0xeb, 0xf1, 0x9d, 0x48, 0x6e, 0xb4, 0x4d, 0xc7, 0x5d, 0x30,
+	0xa0, 0x5d, 0x2a, 0x6e, 0xb1, 0x30, 0x44, 0x5b, 0xb0, 0xa0, 0xa0, 0x3e, 0x8b, 0x24, 0x15, 0x87,
+	0x24, 0xb4, 0x29, 0x7c, 0xe3, 0x05, 0x8e, 0x2d, 0x3b, 0xc6, 0xda, 0x7a, 0x98, 0x57, 0xa8, 0x3b,
+	0x16, 0xd4, 0xf8, 0xc9, 0x81, 0xea, 0xe8, 0x50, 0x6a, 0x20, 0x30, 0x73, 0x85, 0xf3, 0x4a, 0x73,
+	0x45, 0xd6, 0xe7, 0xcc, 0x74, 0xd1, 0x9d, 0x4a, 0x68, 0xe1, 0x5f, 0x27, 0x34, 0xbb, 0x1a, 0xb9,
+	0xb4, 0x7a, 0xbf, 0x17, 0x61, 0x69, 0x6a, 0xb8, 0xfc, 0x6f, 0x8f, 0x71, 0x1e, 0x2a, 0x5d, 0x3e,
+	0x20, 0x2c, 0x9a, 0xe8, 0xe5, 0x56, 0x87, 0x36, 0x21, 0x7b, 0x5f, 0x7d, 0xc9, 0x06, 0x94, 0xa7,
+	0x72, 0x66, 0x1e, 0xf0, 0xa2, 0x45, 0xec, 0x19, 0x00, 0x6a, 0xc2, 0x7c, 0x97, 0x46, 0x43, 0x9f,
+	0x47, 0xfe, 0x3e, 0x61, 0xa1, 0x6e, 0x6e, 0x73, 0x18, 0x94, 0xee, 0x61, 0x74, 0x8b, 0xb0, 0x10,
+	0xb5, 0x01, 0x8d, 0x67, 0x6e, 0x3f, 0xa1, 0xe2, 0x90, 0x05, 0xd4, 0x2d, 0xe7, 0xf6, 0x53, 0x17,
+	0xd9, 0xe9, 0x1f, 0x19, 0x2b, 0x0a, 0x74, 0x27, 0x0a, 0x04, 0x8b, 0xa5, 0x1a, 0x7b, 0x2a, 0xcd,
+	0xe2, 0x89, 0xa2, 0x3f, 0x15, 0xcb, 0xd6, 0xd6, 0x88, 0x23, 0xd7, 0x98, 0x32, 0xd6, 0xc6, 0x17,
+	0x00, 0xe3, 0x05, 0xa8, 0xa9, 0x66, 0x19, 0x1e, 0x53, 0x21, 0x27, 0x9f, 0xc4, 0x91, 0x16, 0x5d,
+	0x84, 0xc5, 0x31, 0xdc, 0x57, 0xef, 0x4f, 0x3e, 0xa8, 0x0b, 0x63, 0xdb, 0x3d, 0x3a, 0xf4, 0xfe,
+	0x72, 0xa0, 0x3e, 0x3d, 0xd9, 0xa3, 0x0d, 0x40, 0x81, 0x1a, 0x3b, 0x82, 0x54, 0xb2, 0x43, 0xea,
+	0x53, 0x33, 0xd4, 0xe9, 0x3b, 0x66, 0x59, 0x96, 0x73, 0xf6, 0x6d, 0x6d, 0x46, 0xd7, 0x60, 0x6e,
+	0x74, 0x4d, 0x0a, 0xb3, 0xd2, 0x33, 0x5a, 0x8a, 0x6e, 0x03, 0xea, 0x90, 0x84, 0xfa, 0xf4, 0x2b,
+	0xe3, 0x5c, 0xa7, 0x78, 0x76, 0x7e, 0xeb, 0x0a, 0xb4, 0x6d, 0x31, 0x2a, 0xc9, 0xe8, 0x32, 0xac,
+	0xa8, 0x86, 0x30, 0xe2, 0xb1, 0xd3, 0x84, 0xce, 0xf4, 0x02, 0x46, 0x03, 0x72, 0x94, 0x2d, 0xb7,
+	0x03, 0x87, 0x77, 0x01, 0xce, 0xda, 0x4f, 0xf5, 0x26, 0x99, 0x67, 0x59, 0x1d, 0xd2, 0xb1, 0x0f,
+	0xed, 0xe6, 0xf6, 0xaf, 0xc7, 0xab, 0xce, 0x6f, 0xc7, 0xab, 0xce, 0x9f, 0xc7, 0xab, 0xce, 0xe7,
+	0xd7, 0x7b, 0x4c, 0xf6, 0xd3, 0x4e, 0x2b, 0xe0, 0x83, 0x75, 0x93, 0xd4, 0x4b, 0x03, 0x9a, 0xf4,
+	0xed, 0xf7, 0xfa, 0x4b, 0x7f, 0xaa, 0x3b, 0x15, 0xad, 0xda, 0xf8, 0x7b, 0x00, 0xbc, 0x25, 0xf4,
+	0xdf, 0x78, 0x0f, 0x00, 0x00,
 }
 
 func (m *MetaRouter) Marshal() (dAtA []byte, err error) {
@@ -1261,6 +1606,40 @@ func (m *MetaRouter) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0xa2
 		}
 	}
+	if m.PassthroughUnmatched {
+		i--
+		if m.PassthroughUnmatched {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.FallbackCluster != nil {
+		{
+			size, err := m.FallbackCluster.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.OutlierDetection != nil {
+		{
+			size, err := m.OutlierDetection.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
 	if m.GlobalRateLimit != nil {
 		{
 			size, err := m.GlobalRateLimit.MarshalToSizedBuffer(dAtA[:i])
@@ -1376,6 +1755,37 @@ func (m *MetaRoute) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x9a
 		}
 	}
+	if len(m.Mirrors) > 0 {
+		for iNdEx := len(m.Mirrors) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Mirrors[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x72
+		}
+	}
+	if m.Priority != 0 {
+		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(m.Priority))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.HashPolicy != nil {
+		{
+			size, err := m.HashPolicy.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
 	if m.MirrorPercentage != nil {
 		{
 			size, err := m.MirrorPercentage.MarshalToSizedBuffer(dAtA[:i])
@@ -1436,7 +1846,7 @@ func (m *MetaRoute) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *KeyValue) Marshal() (dAtA []byte, err error) {
+func (m *HashPolicy) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1446,12 +1856,12 @@ func (m *KeyValue) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *KeyValue) MarshalTo(dAtA []byte) (int, error) {
+func (m *HashPolicy) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *KeyValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *HashPolicy) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -1460,13 +1870,100 @@ func (m *KeyValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if len(m.Value) > 0 {
-		i -= len(m.Value)
-		copy(dAtA[i:], m.Value)
-		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(len(m.Value)))
-		i--
-		dAtA[i] = 0x12
-	}
+	if len(m.Attributes) > 0 {
+		for iNdEx := len(m.Attributes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Attributes[iNdEx])
+			copy(dAtA[i:], m.Attributes[iNdEx])
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(len(m.Attributes[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetaRouteMirror) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetaRouteMirror) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetaRouteMirror) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Percentage != nil {
+		{
+			size, err := m.Percentage.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Destination != nil {
+		{
+			size, err := m.Destination.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *KeyValue) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *KeyValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *KeyValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.Key) > 0 {
 		i -= len(m.Key)
 		copy(dAtA[i:], m.Key)
@@ -1608,6 +2105,64 @@ func (m *StringMatch_Regex) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	dAtA[i] = 0x1a
 	return len(dAtA) - i, nil
 }
+func (m *StringMatch_Range) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *StringMatch_Range) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Range != nil {
+		{
+			size, err := m.Range.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Int64Range) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Int64Range) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Int64Range) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.End != 0 {
+		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *MetaRouteDestination) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -2027,6 +2582,67 @@ func (m *GlobalRateLimit_Descriptor) MarshalToSizedBuffer(dAtA []byte) (int, err
 	return len(dAtA) - i, nil
 }
 
+func (m *OutlierDetection) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *OutlierDetection) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *OutlierDetection) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.MaxEjectionPercent != 0 {
+		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(m.MaxEjectionPercent))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.BaseEjectionTime != nil {
+		{
+			size, err := m.BaseEjectionTime.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Interval != nil {
+		{
+			size, err := m.Interval.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ConsecutiveErrors != 0 {
+		i = encodeVarintMetaprotocolMetarouter(dAtA, i, uint64(m.ConsecutiveErrors))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Percent) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -2103,6 +2719,17 @@ func (m *MetaRouter) Size() (n int) {
 		l = m.GlobalRateLimit.Size()
 		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
 	}
+	if m.OutlierDetection != nil {
+		l = m.OutlierDetection.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.FallbackCluster != nil {
+		l = m.FallbackCluster.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.PassthroughUnmatched {
+		n += 2
+	}
 	if len(m.ExportTo) > 0 {
 		for _, s := range m.ExportTo {
 			l = len(s)
@@ -2143,6 +2770,19 @@ func (m *MetaRoute) Size() (n int) {
 		l = m.MirrorPercentage.Size()
 		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
 	}
+	if m.HashPolicy != nil {
+		l = m.HashPolicy.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.Priority != 0 {
+		n += 1 + sovMetaprotocolMetarouter(uint64(m.Priority))
+	}
+	if len(m.Mirrors) > 0 {
+		for _, e := range m.Mirrors {
+			l = e.Size()
+			n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+		}
+	}
 	if len(m.RequestMutation) > 0 {
 		for _, e := range m.RequestMutation {
 			l = e.Size()
@@ -2161,6 +2801,44 @@ func (m *MetaRoute) Size() (n int) {
 	return n
 }
 
+func (m *HashPolicy) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Attributes) > 0 {
+		for _, s := range m.Attributes {
+			l = len(s)
+			n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *MetaRouteMirror) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Destination != nil {
+		l = m.Destination.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.Percentage != nil {
+		l = m.Percentage.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func (m *KeyValue) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2251,6 +2929,36 @@ func (m *StringMatch_Regex) Size() (n int) {
 	n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
 	return n
 }
+func (m *StringMatch_Range) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Range != nil {
+		l = m.Range.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	return n
+}
+func (m *Int64Range) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovMetaprotocolMetarouter(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovMetaprotocolMetarouter(uint64(m.End))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func (m *MetaRouteDestination) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2431,17 +3139,43 @@ func (m *GlobalRateLimit_Descriptor) Size() (n int) {
 	return n
 }
 
-func (m *Percent) Size() (n int) {
+func (m *OutlierDetection) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Value != 0 {
-		n += 9
+	if m.ConsecutiveErrors != 0 {
+		n += 1 + sovMetaprotocolMetarouter(uint64(m.ConsecutiveErrors))
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if m.Interval != nil {
+		l = m.Interval.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.BaseEjectionTime != nil {
+		l = m.BaseEjectionTime.Size()
+		n += 1 + l + sovMetaprotocolMetarouter(uint64(l))
+	}
+	if m.MaxEjectionPercent != 0 {
+		n += 1 + sovMetaprotocolMetarouter(uint64(m.MaxEjectionPercent))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Percent) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Value != 0 {
+		n += 9
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
 	}
 	return n
 }
@@ -2651,6 +3385,98 @@ func (m *MetaRouter) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutlierDetection", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.OutlierDetection == nil {
+				m.OutlierDetection = &OutlierDetection{}
+			}
+			if err := m.OutlierDetection.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FallbackCluster", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.FallbackCluster == nil {
+				m.FallbackCluster = &Destination{}
+			}
+			if err := m.FallbackCluster.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PassthroughUnmatched", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PassthroughUnmatched = bool(v != 0)
 		case 20:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field ExportTo", wireType)
@@ -2911,6 +3737,95 @@ func (m *MetaRoute) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HashPolicy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.HashPolicy == nil {
+				m.HashPolicy = &HashPolicy{}
+			}
+			if err := m.HashPolicy.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Priority", wireType)
+			}
+			m.Priority = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Priority |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mirrors", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Mirrors = append(m.Mirrors, &MetaRouteMirror{})
+			if err := m.Mirrors[len(m.Mirrors)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 19:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field RequestMutation", wireType)
@@ -3004,7 +3919,7 @@ func (m *MetaRoute) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *KeyValue) Unmarshal(dAtA []byte) error {
+func (m *HashPolicy) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3027,47 +3942,15 @@ func (m *KeyValue) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: KeyValue: wiretype end group for non-group")
+			return fmt.Errorf("proto: HashPolicy: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: KeyValue: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HashPolicy: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetaprotocolMetarouter
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Key = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Attributes", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3095,7 +3978,7 @@ func (m *KeyValue) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Value = string(dAtA[iNdEx:postIndex])
+			m.Attributes = append(m.Attributes, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -3122,7 +4005,7 @@ func (m *KeyValue) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetaRouteMatch) Unmarshal(dAtA []byte) error {
+func (m *MetaRouteMirror) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3145,15 +4028,15 @@ func (m *MetaRouteMatch) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetaRouteMatch: wiretype end group for non-group")
+			return fmt.Errorf("proto: MetaRouteMirror: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetaRouteMatch: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MetaRouteMirror: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Attributes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Destination", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3180,31 +4063,275 @@ func (m *MetaRouteMatch) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Attributes == nil {
-				m.Attributes = make(map[string]*StringMatch)
+			if m.Destination == nil {
+				m.Destination = &Destination{}
 			}
-			var mapkey string
-			var mapvalue *StringMatch
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowMetaprotocolMetarouter
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			if err := m.Destination.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Percentage", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
 				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Percentage == nil {
+				m.Percentage = &Percent{}
+			}
+			if err := m.Percentage.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetaprotocolMetarouter(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *KeyValue) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetaprotocolMetarouter
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: KeyValue: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: KeyValue: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetaprotocolMetarouter(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetaRouteMatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetaprotocolMetarouter
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetaRouteMatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetaRouteMatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Attributes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Attributes == nil {
+				m.Attributes = make(map[string]*StringMatch)
+			}
+			var mapkey string
+			var mapvalue *StringMatch
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetaprotocolMetarouter
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
 					for shift := uint(0); ; shift += 7 {
 						if shift >= 64 {
 							return ErrIntOverflowMetaprotocolMetarouter
@@ -3278,7 +4405,192 @@ func (m *MetaRouteMatch) Unmarshal(dAtA []byte) error {
 					iNdEx += skippy
 				}
 			}
-			m.Attributes[mapkey] = mapvalue
+			m.Attributes[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetaprotocolMetarouter(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StringMatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetaprotocolMetarouter
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StringMatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StringMatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exact", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MatchType = &StringMatch_Exact{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MatchType = &StringMatch_Prefix{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Regex", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MatchType = &StringMatch_Regex{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Range", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Int64Range{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.MatchType = &StringMatch_Range{v}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -3305,7 +4617,7 @@ func (m *MetaRouteMatch) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *StringMatch) Unmarshal(dAtA []byte) error {
+func (m *Int64Range) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3328,17 +4640,17 @@ func (m *StringMatch) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: StringMatch: wiretype end group for non-group")
+			return fmt.Errorf("proto: Int64Range: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: StringMatch: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Int64Range: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Exact", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			var stringLen uint64
+			m.Start = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetaprotocolMetarouter
@@ -3348,61 +4660,16 @@ func (m *StringMatch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Start |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.MatchType = &StringMatch_Exact{string(dAtA[iNdEx:postIndex])}
-			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetaprotocolMetarouter
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.MatchType = &StringMatch_Prefix{string(dAtA[iNdEx:postIndex])}
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Regex", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			var stringLen uint64
+			m.End = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetaprotocolMetarouter
@@ -3412,24 +4679,11 @@ func (m *StringMatch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.End |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetaprotocolMetarouter
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.MatchType = &StringMatch_Regex{string(dAtA[iNdEx:postIndex])}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetaprotocolMetarouter(dAtA[iNdEx:])
@@ -4548,6 +5802,170 @@ func (m *GlobalRateLimit_Descriptor) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *OutlierDetection) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetaprotocolMetarouter
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: OutlierDetection: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: OutlierDetection: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConsecutiveErrors", wireType)
+			}
+			m.ConsecutiveErrors = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ConsecutiveErrors |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Interval == nil {
+				m.Interval = &types.Duration{}
+			}
+			if err := m.Interval.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseEjectionTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.BaseEjectionTime == nil {
+				m.BaseEjectionTime = &types.Duration{}
+			}
+			if err := m.BaseEjectionTime.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxEjectionPercent", wireType)
+			}
+			m.MaxEjectionPercent = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaprotocolMetarouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxEjectionPercent |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetaprotocolMetarouter(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetaprotocolMetarouter
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *Percent) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
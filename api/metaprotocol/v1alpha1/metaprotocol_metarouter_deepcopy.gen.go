@@ -116,6 +116,48 @@ func (in *MetaRoute) DeepCopyInterface() interface{} {
 	return in.DeepCopy()
 }
 
+// DeepCopyInto supports using HashPolicy within kubernetes types, where deepcopy-gen is used.
+func (in *HashPolicy) DeepCopyInto(out *HashPolicy) {
+	p := proto.Clone(in).(*HashPolicy)
+	*out = *p
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HashPolicy. Required by controller-gen.
+func (in *HashPolicy) DeepCopy() *HashPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HashPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInterface is an autogenerated deepcopy function, copying the receiver, creating a new HashPolicy. Required by controller-gen.
+func (in *HashPolicy) DeepCopyInterface() interface{} {
+	return in.DeepCopy()
+}
+
+// DeepCopyInto supports using MetaRouteMirror within kubernetes types, where deepcopy-gen is used.
+func (in *MetaRouteMirror) DeepCopyInto(out *MetaRouteMirror) {
+	p := proto.Clone(in).(*MetaRouteMirror)
+	*out = *p
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetaRouteMirror. Required by controller-gen.
+func (in *MetaRouteMirror) DeepCopy() *MetaRouteMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(MetaRouteMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInterface is an autogenerated deepcopy function, copying the receiver, creating a new MetaRouteMirror. Required by controller-gen.
+func (in *MetaRouteMirror) DeepCopyInterface() interface{} {
+	return in.DeepCopy()
+}
+
 // DeepCopyInto supports using KeyValue within kubernetes types, where deepcopy-gen is used.
 func (in *KeyValue) DeepCopyInto(out *KeyValue) {
 	p := proto.Clone(in).(*KeyValue)
@@ -179,6 +221,27 @@ func (in *StringMatch) DeepCopyInterface() interface{} {
 	return in.DeepCopy()
 }
 
+// DeepCopyInto supports using Int64Range within kubernetes types, where deepcopy-gen is used.
+func (in *Int64Range) DeepCopyInto(out *Int64Range) {
+	p := proto.Clone(in).(*Int64Range)
+	*out = *p
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Int64Range. Required by controller-gen.
+func (in *Int64Range) DeepCopy() *Int64Range {
+	if in == nil {
+		return nil
+	}
+	out := new(Int64Range)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInterface is an autogenerated deepcopy function, copying the receiver, creating a new Int64Range. Required by controller-gen.
+func (in *Int64Range) DeepCopyInterface() interface{} {
+	return in.DeepCopy()
+}
+
 // DeepCopyInto supports using MetaRouteDestination within kubernetes types, where deepcopy-gen is used.
 func (in *MetaRouteDestination) DeepCopyInto(out *MetaRouteDestination) {
 	p := proto.Clone(in).(*MetaRouteDestination)
@@ -347,6 +410,27 @@ func (in *GlobalRateLimit_Descriptor) DeepCopyInterface() interface{} {
 	return in.DeepCopy()
 }
 
+// DeepCopyInto supports using OutlierDetection within kubernetes types, where deepcopy-gen is used.
+func (in *OutlierDetection) DeepCopyInto(out *OutlierDetection) {
+	p := proto.Clone(in).(*OutlierDetection)
+	*out = *p
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutlierDetection. Required by controller-gen.
+func (in *OutlierDetection) DeepCopy() *OutlierDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(OutlierDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInterface is an autogenerated deepcopy function, copying the receiver, creating a new OutlierDetection. Required by controller-gen.
+func (in *OutlierDetection) DeepCopyInterface() interface{} {
+	return in.DeepCopy()
+}
+
 // DeepCopyInto supports using Percent within kubernetes types, where deepcopy-gen is used.
 func (in *Percent) DeepCopyInto(out *Percent) {
 	p := proto.Clone(in).(*Percent)
@@ -98,6 +98,28 @@ func (this *MetaRoute) UnmarshalJSON(b []byte) error {
 	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
 }
 
+// MarshalJSON is a custom marshaler for HashPolicy
+func (this *HashPolicy) MarshalJSON() ([]byte, error) {
+	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
+	return []byte(str), err
+}
+
+// UnmarshalJSON is a custom unmarshaler for HashPolicy
+func (this *HashPolicy) UnmarshalJSON(b []byte) error {
+	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
+}
+
+// MarshalJSON is a custom marshaler for MetaRouteMirror
+func (this *MetaRouteMirror) MarshalJSON() ([]byte, error) {
+	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
+	return []byte(str), err
+}
+
+// UnmarshalJSON is a custom unmarshaler for MetaRouteMirror
+func (this *MetaRouteMirror) UnmarshalJSON(b []byte) error {
+	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
+}
+
 // MarshalJSON is a custom marshaler for KeyValue
 func (this *KeyValue) MarshalJSON() ([]byte, error) {
 	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
@@ -131,6 +153,17 @@ func (this *StringMatch) UnmarshalJSON(b []byte) error {
 	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
 }
 
+// MarshalJSON is a custom marshaler for Int64Range
+func (this *Int64Range) MarshalJSON() ([]byte, error) {
+	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
+	return []byte(str), err
+}
+
+// UnmarshalJSON is a custom unmarshaler for Int64Range
+func (this *Int64Range) UnmarshalJSON(b []byte) error {
+	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
+}
+
 // MarshalJSON is a custom marshaler for MetaRouteDestination
 func (this *MetaRouteDestination) MarshalJSON() ([]byte, error) {
 	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
@@ -219,6 +252,17 @@ func (this *GlobalRateLimit_Descriptor) UnmarshalJSON(b []byte) error {
 	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
 }
 
+// MarshalJSON is a custom marshaler for OutlierDetection
+func (this *OutlierDetection) MarshalJSON() ([]byte, error) {
+	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
+	return []byte(str), err
+}
+
+// UnmarshalJSON is a custom unmarshaler for OutlierDetection
+func (this *OutlierDetection) UnmarshalJSON(b []byte) error {
+	return MetaprotocolMetarouterUnmarshaler.Unmarshal(bytes.NewReader(b), this)
+}
+
 // MarshalJSON is a custom marshaler for Percent
 func (this *Percent) MarshalJSON() ([]byte, error) {
 	str, err := MetaprotocolMetarouterMarshaler.MarshalToString(this)
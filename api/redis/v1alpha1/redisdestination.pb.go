@@ -395,12 +395,17 @@ func (m *PlainAuth) GetUsername() string {
 }
 
 type RedisSettings struct {
-	Mode                 RedisSettings_Mode `protobuf:"varint,1,opt,name=mode,proto3,enum=redis.aeraki.io.v1alpha1.RedisSettings_Mode" json:"mode,omitempty"`
-	Auth                 *Auth              `protobuf:"bytes,2,opt,name=auth,proto3" json:"auth,omitempty"`
-	DiscoveryEndpoints   []string           `protobuf:"bytes,3,rep,name=discovery_endpoints,json=discoveryEndpoints,proto3" json:"discovery_endpoints,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
-	XXX_unrecognized     []byte             `json:"-"`
-	XXX_sizecache        int32              `json:"-"`
+	Mode               RedisSettings_Mode `protobuf:"varint,1,opt,name=mode,proto3,enum=redis.aeraki.io.v1alpha1.RedisSettings_Mode" json:"mode,omitempty"`
+	Auth               *Auth              `protobuf:"bytes,2,opt,name=auth,proto3" json:"auth,omitempty"`
+	DiscoveryEndpoints []string           `protobuf:"bytes,3,rep,name=discovery_endpoints,json=discoveryEndpoints,proto3" json:"discovery_endpoints,omitempty"`
+	// The name of the Kubernetes secret holding the client certificate, the private key and the CA certificate of the
+	// TLS connections to the Redis servers. The secret must be in the namespace of the RedisDestination, and it is
+	// fetched by the proxies over SDS instead of being inlined into the generated configuration. TLS is disabled if
+	// it's not set.
+	CredentialName       string   `protobuf:"bytes,4,opt,name=credential_name,json=credentialName,proto3" json:"credential_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *RedisSettings) Reset()         { *m = RedisSettings{} }
@@ -457,6 +462,13 @@ func (m *RedisSettings) GetDiscoveryEndpoints() []string {
 	return nil
 }
 
+func (m *RedisSettings) GetCredentialName() string {
+	if m != nil {
+		return m.CredentialName
+	}
+	return ""
+}
+
 type TrafficPolicy struct {
 	ConnectionPool       *ConnectionPoolSettings `protobuf:"bytes,1,opt,name=connection_pool,json=connectionPool,proto3" json:"connection_pool,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
@@ -520,47 +532,48 @@ func init() {
 }
 
 var fileDescriptor_237e307d114723c1 = []byte{
-	// 625 bytes of a gzipped FileDescriptorProto
+	// 643 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xdd, 0x4e, 0x13, 0x41,
-	0x14, 0x66, 0x69, 0x41, 0x7a, 0x48, 0x0b, 0x19, 0x13, 0x6d, 0x88, 0x29, 0x64, 0x8d, 0x11, 0x12,
-	0x9d, 0x85, 0x72, 0xe1, 0x85, 0xc1, 0x08, 0x15, 0xc3, 0x85, 0x4a, 0x33, 0x60, 0x22, 0xde, 0x34,
-	0xd3, 0xdd, 0x69, 0x77, 0x64, 0x3b, 0xb3, 0x99, 0x99, 0x85, 0x10, 0x5f, 0xc2, 0xc7, 0xf0, 0x51,
-	0xbc, 0xf4, 0xca, 0x6b, 0xc2, 0x93, 0x98, 0x9d, 0xd9, 0x2d, 0xe5, 0xa7, 0xd1, 0xbb, 0x99, 0x73,
-	0xbe, 0xef, 0x7c, 0xdf, 0x39, 0x73, 0x32, 0xb0, 0x41, 0x53, 0x1e, 0x28, 0x16, 0x71, 0x1d, 0x9c,
-	0x6d, 0xd1, 0x24, 0x8d, 0xe9, 0x96, 0xbb, 0x46, 0x4c, 0x1b, 0x2e, 0xa8, 0xe1, 0x52, 0xe0, 0x54,
-	0x49, 0x23, 0x51, 0xd3, 0xc6, 0x31, 0x65, 0x8a, 0x9e, 0x72, 0xcc, 0x25, 0x2e, 0x09, 0x2b, 0x4f,
-	0x86, 0x52, 0x0e, 0x13, 0x16, 0x58, 0x5c, 0x3f, 0x1b, 0x04, 0xda, 0xa8, 0x2c, 0x34, 0x8e, 0xb7,
-	0xd2, 0xba, 0x9d, 0x8d, 0x32, 0x35, 0x51, 0xf7, 0x6e, 0xfe, 0x5c, 0xd1, 0x34, 0x65, 0x4a, 0x17,
-	0xf9, 0xd5, 0x22, 0x9f, 0x3b, 0x1d, 0x70, 0x96, 0x44, 0xbd, 0x3e, 0x8b, 0xe9, 0x19, 0x97, 0xaa,
-	0x00, 0xbc, 0xe2, 0xda, 0x70, 0x89, 0xb9, 0xb4, 0x10, 0xc1, 0xcc, 0xb9, 0x54, 0xa7, 0x5c, 0x0c,
-	0xcb, 0x8e, 0xb6, 0x83, 0x89, 0x66, 0x7a, 0x2a, 0x4b, 0x98, 0x23, 0xfa, 0xdf, 0x61, 0x99, 0xe4,
-	0x3d, 0xbd, 0xbb, 0x4e, 0xa3, 0xc7, 0x50, 0x8d, 0xa5, 0x36, 0x4d, 0x6f, 0xcd, 0x5b, 0xaf, 0xed,
-	0x55, 0x2e, 0x77, 0x67, 0x89, 0x0d, 0xa0, 0x4f, 0xd0, 0x30, 0x8a, 0x0e, 0x06, 0x3c, 0xec, 0xa5,
-	0x32, 0xe1, 0xe1, 0x45, 0x73, 0x76, 0xcd, 0x5b, 0x5f, 0x6c, 0x3f, 0xc7, 0xd3, 0xe6, 0x82, 0x8f,
-	0x1d, 0xbe, 0x6b, 0xe1, 0xa4, 0x6e, 0x26, 0xaf, 0xfe, 0x4f, 0x0f, 0x1e, 0x75, 0xa4, 0x10, 0x2c,
-	0xcc, 0x75, 0xbb, 0x52, 0x26, 0x47, 0xcc, 0x18, 0x2e, 0x86, 0x1a, 0x1d, 0x42, 0xc5, 0x84, 0xa9,
-	0xb5, 0xb0, 0xd8, 0xde, 0xc1, 0xae, 0xbd, 0xeb, 0xbe, 0x4a, 0x81, 0x6d, 0x7c, 0x3f, 0x1f, 0x1f,
-	0x77, 0xba, 0xe5, 0x99, 0xe4, 0x95, 0xd0, 0x0e, 0xcc, 0x59, 0x93, 0xff, 0xb6, 0x6c, 0xe7, 0x31,
-	0x26, 0x3b, 0x96, 0xff, 0xc3, 0x83, 0xea, 0x6e, 0x66, 0x62, 0xd4, 0x81, 0x79, 0xcd, 0x42, 0xc5,
-	0x4c, 0xe1, 0x6d, 0x63, 0x7a, 0xa1, 0x23, 0x8b, 0x23, 0x6c, 0xc0, 0x14, 0x13, 0x21, 0x3b, 0x98,
-	0x21, 0x05, 0x15, 0xbd, 0x86, 0xb9, 0x34, 0xa1, 0x5c, 0x14, 0x66, 0x9e, 0x4e, 0xaf, 0xd1, 0xcd,
-	0x61, 0xb9, 0xf0, 0xc1, 0x0c, 0x71, 0x9c, 0xbd, 0x79, 0xa8, 0xd2, 0xcc, 0xc4, 0xbe, 0x86, 0xa5,
-	0x5b, 0x0a, 0x08, 0x41, 0x55, 0xd0, 0x11, 0x73, 0x2f, 0x47, 0xec, 0x19, 0x3d, 0x83, 0x46, 0x4a,
-	0xb5, 0x3e, 0x97, 0x2a, 0xea, 0xd9, 0xdd, 0xb1, 0xa2, 0x35, 0x52, 0x2f, 0xa3, 0xef, 0xf3, 0x60,
-	0x0e, 0xcb, 0x34, 0x53, 0x39, 0xa5, 0x80, 0x55, 0x1c, 0xac, 0x8c, 0x5a, 0x98, 0x7f, 0x00, 0xb5,
-	0xb1, 0x25, 0xb4, 0x0a, 0x0b, 0x65, 0x91, 0xc9, 0x65, 0x19, 0x07, 0xd1, 0x0a, 0x2c, 0x94, 0xf4,
-	0x42, 0x75, 0x7c, 0xf7, 0xff, 0x78, 0x50, 0xbf, 0x31, 0x6a, 0xf4, 0x16, 0xaa, 0x23, 0x19, 0x39,
-	0xf7, 0x8d, 0xf6, 0x8b, 0xff, 0x7c, 0x21, 0xfc, 0x51, 0x46, 0x8c, 0x58, 0x26, 0x6a, 0xbb, 0xd1,
-	0x14, 0x63, 0x6d, 0x4d, 0xaf, 0x90, 0xdb, 0x27, 0x16, 0x8b, 0x02, 0x78, 0x18, 0x71, 0x1d, 0xca,
-	0x33, 0xa6, 0x2e, 0x7a, 0x4c, 0x44, 0xa9, 0xe4, 0xc2, 0xe8, 0x66, 0x65, 0xad, 0xb2, 0x5e, 0x23,
-	0x68, 0x9c, 0xda, 0x2f, 0x33, 0x7e, 0x0b, 0xaa, 0xb9, 0x24, 0xaa, 0xc1, 0x5c, 0x97, 0x1c, 0x7e,
-	0x39, 0x59, 0x9e, 0x41, 0x8b, 0xf0, 0xa0, 0xf3, 0xe1, 0xf3, 0xd1, 0xf1, 0x3e, 0x59, 0xf6, 0xfc,
-	0x6f, 0x50, 0xbf, 0xb1, 0xf5, 0xe8, 0x04, 0x96, 0xc2, 0xf1, 0x96, 0xf6, 0x52, 0x29, 0x93, 0x62,
-	0x77, 0x36, 0xa7, 0x1b, 0xbc, 0x7f, 0xad, 0x49, 0x23, 0xbc, 0x11, 0xdf, 0x7b, 0xf3, 0xeb, 0xaa,
-	0xe5, 0xfd, 0xbe, 0x6a, 0x79, 0x97, 0x57, 0x2d, 0xef, 0xeb, 0xe6, 0x90, 0x9b, 0x38, 0xeb, 0xe3,
-	0x50, 0x8e, 0x02, 0x57, 0xf3, 0xe5, 0x88, 0xe9, 0xb8, 0x38, 0x07, 0x77, 0xff, 0xb9, 0xfe, 0xbc,
-	0xfd, 0x05, 0xb6, 0xff, 0x06, 0x00, 0x00, 0xff, 0xff, 0x95, 0x13, 0x2f, 0xd6, 0x04, 0x05, 0x00,
-	0x00,
+	0x14, 0x66, 0xe9, 0x82, 0xf4, 0x90, 0x16, 0x32, 0x26, 0xa6, 0x21, 0xa6, 0x36, 0x6b, 0x0c, 0x90,
+	0xe8, 0x2e, 0x94, 0x0b, 0x2f, 0x0c, 0x46, 0xa8, 0x18, 0x4c, 0x14, 0x9a, 0x01, 0x13, 0xf1, 0x66,
+	0x33, 0xdd, 0x9d, 0x76, 0x47, 0xb6, 0x33, 0x9b, 0x99, 0x59, 0x08, 0x89, 0x0f, 0xe1, 0x63, 0xf8,
+	0x28, 0x5e, 0xfa, 0x08, 0xa6, 0x2f, 0xa2, 0xd9, 0xd9, 0x1f, 0x28, 0xd0, 0xe8, 0xdd, 0xcc, 0x39,
+	0xdf, 0x77, 0xce, 0xf7, 0x9d, 0x3d, 0x3b, 0xb0, 0x49, 0x12, 0xe6, 0x49, 0x1a, 0x32, 0xe5, 0x5d,
+	0x6c, 0x93, 0x38, 0x89, 0xc8, 0x76, 0x7e, 0x0d, 0xa9, 0xd2, 0x8c, 0x13, 0xcd, 0x04, 0x77, 0x13,
+	0x29, 0xb4, 0x40, 0x2d, 0x13, 0x77, 0x09, 0x95, 0xe4, 0x9c, 0xb9, 0x4c, 0xb8, 0x25, 0x61, 0xed,
+	0xf1, 0x48, 0x88, 0x51, 0x4c, 0x3d, 0x83, 0x1b, 0xa4, 0x43, 0x4f, 0x69, 0x99, 0x06, 0x3a, 0xe7,
+	0xad, 0xb5, 0x6f, 0x67, 0xc3, 0x54, 0xde, 0xa8, 0x7b, 0x37, 0x7f, 0x29, 0x49, 0x92, 0x50, 0xa9,
+	0x8a, 0xfc, 0x93, 0x22, 0x9f, 0x29, 0x1d, 0x32, 0x1a, 0x87, 0xfe, 0x80, 0x46, 0xe4, 0x82, 0x09,
+	0x59, 0x00, 0x5e, 0x32, 0xa5, 0x99, 0x70, 0x99, 0x30, 0x10, 0x4e, 0xf5, 0xa5, 0x90, 0xe7, 0x8c,
+	0x8f, 0x4a, 0x47, 0x3b, 0xde, 0x0d, 0x33, 0xbe, 0x4c, 0x63, 0x9a, 0x13, 0x9d, 0x6f, 0xb0, 0x8a,
+	0x33, 0x4f, 0x6f, 0xaf, 0xd3, 0xa8, 0x05, 0x76, 0x24, 0x94, 0x6e, 0x59, 0x1d, 0x6b, 0xa3, 0xbe,
+	0x6f, 0x4f, 0xf6, 0xac, 0x79, 0x6c, 0x22, 0xe8, 0x08, 0x9a, 0x5a, 0x92, 0xe1, 0x90, 0x05, 0x7e,
+	0x22, 0x62, 0x16, 0x5c, 0xb5, 0xe6, 0x3b, 0xd6, 0xc6, 0x72, 0x77, 0xdd, 0x9d, 0x35, 0x18, 0xf7,
+	0x34, 0xc7, 0xf7, 0x0d, 0x1c, 0x37, 0xf4, 0xcd, 0xab, 0xf3, 0xc3, 0x82, 0x47, 0x3d, 0xc1, 0x39,
+	0x0d, 0xb2, 0xc6, 0x7d, 0x21, 0xe2, 0x13, 0xaa, 0x35, 0xe3, 0x23, 0x85, 0x8e, 0xa1, 0xa6, 0x83,
+	0xc4, 0x68, 0x58, 0xee, 0xee, 0xba, 0xb9, 0xbf, 0x6b, 0x63, 0x65, 0x83, 0x1d, 0xf7, 0x7e, 0xbe,
+	0x7b, 0xda, 0xeb, 0x97, 0x67, 0x9c, 0x55, 0x42, 0xbb, 0xb0, 0x60, 0x44, 0xfe, 0x5b, 0xb2, 0x19,
+	0x48, 0x45, 0xce, 0x59, 0xce, 0x77, 0x0b, 0xec, 0xbd, 0x54, 0x47, 0xa8, 0x07, 0x8b, 0x8a, 0x06,
+	0x92, 0xea, 0x42, 0xdb, 0xe6, 0xec, 0x42, 0x27, 0x06, 0x87, 0xe9, 0x90, 0x4a, 0xca, 0x03, 0x7a,
+	0x38, 0x87, 0x0b, 0x2a, 0x7a, 0x05, 0x0b, 0x49, 0x4c, 0x18, 0x2f, 0xc4, 0x3c, 0x9d, 0x5d, 0xa3,
+	0x9f, 0xc1, 0xb2, 0xc6, 0x87, 0x73, 0x38, 0xe7, 0xec, 0x2f, 0x82, 0x4d, 0x52, 0x1d, 0x39, 0x0a,
+	0x56, 0x6e, 0x75, 0x40, 0x08, 0x6c, 0x4e, 0xc6, 0x34, 0xff, 0x74, 0xd8, 0x9c, 0xd1, 0x33, 0x68,
+	0x26, 0x44, 0xa9, 0x4b, 0x21, 0x43, 0xdf, 0x2c, 0x8f, 0x69, 0x5a, 0xc7, 0x8d, 0x32, 0xfa, 0x2e,
+	0x0b, 0x66, 0xb0, 0x54, 0x51, 0x99, 0x51, 0x0a, 0x58, 0x2d, 0x87, 0x95, 0x51, 0x03, 0x73, 0xde,
+	0x43, 0xbd, 0x92, 0x84, 0x3a, 0xb0, 0x54, 0x16, 0x99, 0xda, 0x96, 0x2a, 0x8a, 0xd6, 0x60, 0xa9,
+	0xe4, 0x17, 0x6d, 0xab, 0xbb, 0xf3, 0xc7, 0x82, 0xc6, 0xd4, 0xac, 0xd1, 0x1b, 0xb0, 0xc7, 0x22,
+	0xcc, 0xe5, 0x37, 0xbb, 0xcf, 0xff, 0xf3, 0x13, 0xb9, 0x1f, 0x45, 0x48, 0xb1, 0x61, 0xa2, 0x6e,
+	0x3e, 0x9b, 0x62, 0xae, 0xed, 0xd9, 0x15, 0x32, 0xfd, 0xd8, 0x60, 0x91, 0x07, 0x0f, 0x43, 0xa6,
+	0x02, 0x71, 0x41, 0xe5, 0x95, 0x4f, 0x79, 0x98, 0x08, 0xc6, 0xb5, 0x6a, 0xd5, 0x3a, 0xb5, 0x8d,
+	0x3a, 0x46, 0x55, 0xea, 0xa0, 0xcc, 0xa0, 0x75, 0x58, 0x09, 0x24, 0x0d, 0x29, 0xd7, 0x8c, 0xc4,
+	0xbe, 0xf1, 0x66, 0x1b, 0x6f, 0xcd, 0xeb, 0xf0, 0x51, 0xe6, 0xb0, 0x0d, 0x76, 0xa6, 0x0d, 0xd5,
+	0x61, 0xa1, 0x8f, 0x8f, 0x3f, 0x9f, 0xad, 0xce, 0xa1, 0x65, 0x78, 0xd0, 0xfb, 0xf0, 0xe9, 0xe4,
+	0xf4, 0x00, 0xaf, 0x5a, 0xce, 0x57, 0x68, 0x4c, 0xfd, 0x1f, 0xe8, 0x0c, 0x56, 0x82, 0x6a, 0x9f,
+	0xfd, 0x44, 0x88, 0xb8, 0xd8, 0xb2, 0xad, 0xd9, 0x4e, 0xee, 0xff, 0x01, 0x70, 0x33, 0x98, 0x8a,
+	0xef, 0xbf, 0xfe, 0x39, 0x69, 0x5b, 0xbf, 0x26, 0x6d, 0xeb, 0xf7, 0xa4, 0x6d, 0x7d, 0xd9, 0x1a,
+	0x31, 0x1d, 0xa5, 0x03, 0x37, 0x10, 0x63, 0x2f, 0xaf, 0xf9, 0x62, 0x4c, 0x55, 0x54, 0x9c, 0xbd,
+	0xbb, 0x4f, 0xe2, 0x60, 0xd1, 0x3c, 0x18, 0x3b, 0x7f, 0x07, 0x00, 0x29, 0xb4, 0x1a, 0xc0, 0x2f,
+	0x05, 0x00, 0x00,
 }
 
 func (m *RedisDestination) Marshal() (dAtA []byte, err error) {
@@ -851,6 +864,13 @@ func (m *RedisSettings) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.CredentialName) > 0 {
+		i -= len(m.CredentialName)
+		copy(dAtA[i:], m.CredentialName)
+		i = encodeVarintRedisdestination(dAtA, i, uint64(len(m.CredentialName)))
+		i--
+		dAtA[i] = 0x22
+	}
 	if len(m.DiscoveryEndpoints) > 0 {
 		for iNdEx := len(m.DiscoveryEndpoints) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.DiscoveryEndpoints[iNdEx])
@@ -1072,6 +1092,10 @@ func (m *RedisSettings) Size() (n int) {
 			n += 1 + l + sovRedisdestination(uint64(l))
 		}
 	}
+	l = len(m.CredentialName)
+	if l > 0 {
+		n += 1 + l + sovRedisdestination(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1856,6 +1880,38 @@ func (m *RedisSettings) Unmarshal(dAtA []byte) error {
 			}
 			m.DiscoveryEndpoints = append(m.DiscoveryEndpoints, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CredentialName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRedisdestination
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRedisdestination
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRedisdestination
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CredentialName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRedisdestination(dAtA[iNdEx:])
@@ -27,8 +27,11 @@ import (
 	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
 	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+	"github.com/aeraki-mesh/aeraki/plugin/dubbo"
+	"github.com/aeraki-mesh/aeraki/plugin/grpcweb"
 	"github.com/aeraki-mesh/aeraki/plugin/kafka"
 	"github.com/aeraki-mesh/aeraki/plugin/metaprotocol"
+	"github.com/aeraki-mesh/aeraki/plugin/raw"
 	"github.com/aeraki-mesh/aeraki/plugin/thrift"
 	"github.com/aeraki-mesh/aeraki/plugin/zookeeper"
 
@@ -62,6 +65,35 @@ func main() {
 	flag.StringVar(&args.LogLevel, "log-level", defaultLogLevel, "Component log level")
 	flag.BoolVar(&args.EnableEnvoyFilterNSScope, "enable-envoy-filter-namespace-scope", false,
 		"Generate Envoy Filters in the service namespace")
+	flag.BoolVar(&args.EnableMetaProtocolInlineRoutes, "enable-metaprotocol-inline-routes", false,
+		"Generate the MetaProtocol routes inline in the Envoy Filters instead of serving them via RDS")
+	flag.StringVar(&args.MetaProtocolFailureMode, "metaprotocol-failure-mode", string(metaprotocol.FailOpen),
+		"Behavior of the MetaProtocol global rate limit filters when the rate limit service is unavailable, "+
+			"fail-open or fail-closed")
+	flag.StringVar(&args.MetaProtocolDefaultIdleTimeouts, "metaprotocol-default-idle-timeouts", "",
+		"Default idle timeouts of the downstream connections of the MetaProtocol application protocols, after which "+
+			"the idle connections are closed, such as dubbo=10m,thrift=5m")
+	flag.BoolVar(&args.EnableStrictListenerMatch, "enable-strict-listener-match", false,
+		"Match the listeners by both name and port in the generated Envoy Filters")
+	flag.BoolVar(&args.DisableInboundEnvoyFilters, "disable-inbound-envoy-filters", false,
+		"Only generate the outbound Envoy Filters, the inbound traffic is left to the backends")
+	flag.StringVar(&args.ServiceEntrySelector, "service-entry-selector", "",
+		"Label selector of the ServiceEntries to generate the configuration for, such as aeraki.io/managed=true")
+	flag.StringVar(&args.StatsNamespace, "stats-namespace", "",
+		"Namespace prepended to the stat prefixes of the generated protocol filters, such as a tenant name")
+	flag.StringVar(&args.StatsMeshID, "stats-mesh-id", "",
+		"Mesh ID prepended to the stat prefixes of the generated protocol filters, to tell the federated meshes apart")
+	flag.StringVar(&args.StatPrefixHostSanitization, "stat-prefix-host-sanitization", "",
+		"How the hosts of the stat prefixes of the generated protocol filters are shortened, hash or truncate:<length>")
+	flag.StringVar(&args.EnvoyFilterOrder, "envoy-filter-order", string(envoyfilter.OutboundFirst),
+		"Order of the outbound and inbound Envoy Filters of a service port, outbound-first or inbound-first")
+	flag.StringVar(&args.EnvoyFilterLabels, "envoy-filter-labels", "",
+		"Labels added to all the generated Envoy Filters, such as istio.io/rev=canary for the Istio revision tags")
+	flag.StringVar(&args.ListenerNaming, "listener-naming", string(envoyfilter.AddressPortNaming),
+		"Naming scheme of the outbound listeners of the Istio version in use, address-port or wildcard-port")
+	flag.BoolVar(&args.EnableListenerAdditionalAddresses, "enable-listener-additional-addresses", false,
+		"Match a single outbound listener with additional addresses for all the VIPs of a service port, as Istio "+
+			"builds with dual stack enabled")
 	flag.StringVar(&args.KubeDomainSuffix, "domain", defaultKubernetesDomain, "Kubernetes DNS domain suffix")
 	flag.StringVar(&args.HTTPSAddr, "httpsAddr", ":15017", "validation service HTTPS address")
 
@@ -74,6 +106,27 @@ func main() {
 	args.RootNamespace = env.RegisterStringVar("AERAKI_NAMESPACE", args.RootNamespace, "").Get()
 	args.EnableEnvoyFilterNSScope = env.RegisterBoolVar("AERAKI_ENABLE_ENVOY_FILTER_NS_SCOPE",
 		args.EnableEnvoyFilterNSScope, "").Get()
+	args.EnableMetaProtocolInlineRoutes = env.RegisterBoolVar("AERAKI_ENABLE_METAPROTOCOL_INLINE_ROUTES",
+		args.EnableMetaProtocolInlineRoutes, "").Get()
+	args.MetaProtocolFailureMode = env.RegisterStringVar("AERAKI_METAPROTOCOL_FAILURE_MODE",
+		args.MetaProtocolFailureMode, "").Get()
+	args.MetaProtocolDefaultIdleTimeouts = env.RegisterStringVar("AERAKI_METAPROTOCOL_DEFAULT_IDLE_TIMEOUTS",
+		args.MetaProtocolDefaultIdleTimeouts, "").Get()
+	args.EnableStrictListenerMatch = env.RegisterBoolVar("AERAKI_ENABLE_STRICT_LISTENER_MATCH",
+		args.EnableStrictListenerMatch, "").Get()
+	args.DisableInboundEnvoyFilters = env.RegisterBoolVar("AERAKI_DISABLE_INBOUND_ENVOY_FILTERS",
+		args.DisableInboundEnvoyFilters, "").Get()
+	args.ServiceEntrySelector = env.RegisterStringVar("AERAKI_SERVICE_ENTRY_SELECTOR",
+		args.ServiceEntrySelector, "").Get()
+	args.StatsNamespace = env.RegisterStringVar("AERAKI_STATS_NAMESPACE", args.StatsNamespace, "").Get()
+	args.StatsMeshID = env.RegisterStringVar("AERAKI_STATS_MESH_ID", args.StatsMeshID, "").Get()
+	args.StatPrefixHostSanitization = env.RegisterStringVar("AERAKI_STAT_PREFIX_HOST_SANITIZATION",
+		args.StatPrefixHostSanitization, "").Get()
+	args.EnvoyFilterOrder = env.RegisterStringVar("AERAKI_ENVOY_FILTER_ORDER", args.EnvoyFilterOrder, "").Get()
+	args.ListenerNaming = env.RegisterStringVar("AERAKI_LISTENER_NAMING", args.ListenerNaming, "").Get()
+	args.EnableListenerAdditionalAddresses = env.RegisterBoolVar("AERAKI_ENABLE_LISTENER_ADDITIONAL_ADDRESSES",
+		args.EnableListenerAdditionalAddresses, "").Get()
+	args.EnvoyFilterLabels = env.RegisterStringVar("AERAKI_ENVOY_FILTER_LABELS", args.EnvoyFilterLabels, "").Get()
 	args.IstiodAddr = env.RegisterStringVar("AERAKI_ISTIOD_ADDR", args.IstiodAddr, "").Get()
 	args.AerakiXdsAddr = env.RegisterStringVar("AERAKI_XDS_ADDR", constants.DefaultAerakiXdsAddr, "").Get()
 	args.AerakiXdsPort = env.RegisterStringVar("AERAKI_XDS_PORT", constants.DefaultAerakiXdsPort, "").Get()
@@ -104,6 +157,9 @@ func initGenerators() map[protocol.Instance]envoyfilter.Generator {
 		protocol.Thrift:       thrift.NewGenerator(),
 		protocol.Kafka:        kafka.NewGenerator(),
 		protocol.Zookeeper:    zookeeper.NewGenerator(),
+		protocol.Raw:          raw.NewGenerator(),
+		protocol.GRPCWeb:      grpcweb.NewGenerator(),
+		protocol.Triple:       dubbo.NewTripleGenerator(),
 		protocol.MetaProtocol: metaprotocol.NewGenerator(),
 	}
 }
@@ -37,7 +37,35 @@ type AerakiArgs struct {
 	LogLevel                 string
 	KubeDomainSuffix         string
 	EnableEnvoyFilterNSScope bool
-	Protocols                map[protocol.Instance]envoyfilter.Generator
+	// Emit the MetaProtocol routes inline in the EnvoyFilters instead of serving them via RDS
+	EnableMetaProtocolInlineRoutes bool
+	// The behavior of the MetaProtocol global rate limit filters when the rate limit service is unavailable
+	MetaProtocolFailureMode string
+	// The default idle timeouts of the downstream connections of the MetaProtocol application protocols, such as
+	// dubbo=10m,thrift=5m
+	MetaProtocolDefaultIdleTimeouts string
+	// The order of the outbound and inbound EnvoyFilters of a service port, outbound-first or inbound-first
+	EnvoyFilterOrder string
+	// The labels added to all the generated EnvoyFilters, such as istio.io/rev=canary
+	EnvoyFilterLabels string
+	// Match the listeners by both name and port in the generated EnvoyFilters
+	EnableStrictListenerMatch bool
+	// Only generate the outbound EnvoyFilters, the inbound traffic is left to the backends
+	DisableInboundEnvoyFilters bool
+	// The label selector of the ServiceEntries Aeraki generates the configuration for, all of them if it's empty
+	ServiceEntrySelector string
+	// The namespace the stats of the generated protocol filters are emitted under, such as a tenant name
+	StatsNamespace string
+	// The ID of the mesh the stats of the generated protocol filters are emitted under, for the federated meshes
+	StatsMeshID string
+	// How the hosts of the stat prefixes of the generated protocol filters are shortened, hash or truncate:<length>
+	StatPrefixHostSanitization string
+	// The naming scheme of the outbound listeners of the Istio version in use, address-port or wildcard-port
+	ListenerNaming string
+	// Match a single outbound listener for all the VIPs of a service port, which has the other VIPs as its additional
+	// addresses
+	EnableListenerAdditionalAddresses bool
+	Protocols                         map[protocol.Instance]envoyfilter.Generator
 }
 
 // NewAerakiArgs constructs AerakiArgs with default value.
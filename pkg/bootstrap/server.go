@@ -47,9 +47,11 @@ import (
 	"github.com/aeraki-mesh/aeraki/pkg/controller/kube"
 	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/leaderelection"
+	metaprotocolmodel "github.com/aeraki-mesh/aeraki/pkg/model/metaprotocol"
 	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
 	"github.com/aeraki-mesh/aeraki/pkg/xds"
 	"github.com/aeraki-mesh/aeraki/plugin/dubbo"
+	"github.com/aeraki-mesh/aeraki/plugin/metaprotocol"
 	"github.com/aeraki-mesh/aeraki/plugin/redis"
 )
 
@@ -101,6 +103,39 @@ func NewServer(args *AerakiArgs) (*Server, error) {
 		IstiodAddr: args.IstiodAddr,
 		NameSpace:  args.RootNamespace,
 	})
+	envoyfilter.SetStrictListenerMatch(args.EnableStrictListenerMatch)
+	envoyfilter.SetListenerAdditionalAddresses(args.EnableListenerAdditionalAddresses)
+	envoyfilter.SetInboundDisabled(args.DisableInboundEnvoyFilters)
+	if err := envoyfilter.SetServiceEntrySelector(args.ServiceEntrySelector); err != nil {
+		return nil, err
+	}
+	if err := envoyfilter.SetStatsNamespace(args.StatsNamespace); err != nil {
+		return nil, err
+	}
+	if err := envoyfilter.SetStatsMeshID(args.StatsMeshID); err != nil {
+		return nil, err
+	}
+	if err := envoyfilter.SetStatPrefixHostSanitization(args.StatPrefixHostSanitization); err != nil {
+		return nil, err
+	}
+	if err := envoyfilter.SetEnvoyFilterOrder(args.EnvoyFilterOrder); err != nil {
+		return nil, err
+	}
+	if err := envoyfilter.SetEnvoyFilterLabels(args.EnvoyFilterLabels); err != nil {
+		return nil, err
+	}
+	if err := envoyfilter.SetListenerNaming(args.ListenerNaming); err != nil {
+		return nil, err
+	}
+	metaprotocol.SetInlineRoutes(args.EnableMetaProtocolInlineRoutes)
+	if err := metaprotocol.SetFailureMode(args.MetaProtocolFailureMode); err != nil {
+		return nil, err
+	}
+	defaultIdleTimeouts, err := metaprotocolmodel.ParseDefaultTimeouts(args.MetaProtocolDefaultIdleTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	metaprotocolmodel.SetDefaultIdleTimeouts(defaultIdleTimeouts)
 	// envoyFilterController watches changes on config and create/update corresponding EnvoyFilters
 	envoyFilterController := envoyfilter.NewController(client, configController.Store, args.Protocols,
 		args.EnableEnvoyFilterNSScope, args.RootNamespace)
@@ -235,6 +270,12 @@ func createSingletonControllers(args *AerakiArgs, kubeConfig *rest.Config) (mana
 	return mgr, nil
 }
 
+// RegisterApplyHandler registers a handler to be notified each time the generated EnvoyFilters and DestinationRules
+// of a batch of config changes have been applied, it should be called before the server is started
+func (s *Server) RegisterApplyHandler(handler envoyfilter.ApplyHandler) {
+	s.envoyFilterController.RegisterApplyHandler(handler)
+}
+
 // Start starts all components of the Aeraki service. Serving can be canceled at any time by closing the provided stop
 // channel.
 // This method won't block
@@ -21,4 +21,152 @@ const (
 	DefaultAerakiXdsPort = ":15010"
 	// DefaultAerakiXdsAddr is the default value for Aeraki xds address
 	DefaultAerakiXdsAddr = "aeraki.istio-system"
+	// ExactConnectionBalanceAnnotation is the ServiceEntry annotation which enables the exact connection balance on
+	// the outbound listeners of a service, so the connections are evenly spread across the Envoy worker threads
+	ExactConnectionBalanceAnnotation = "exactConnectionBalance"
+	// DownstreamIdleTimeoutAnnotation is the ServiceEntry annotation which sets the idle timeout of the downstream
+	// connections of the MetaProtocol proxy, or of the TcpProxy kept by the protocol filters inserted before it, the
+	// value is a duration string such as "30s". It's ignored by the other proxies replacing the TcpProxy.
+	DownstreamIdleTimeoutAnnotation = "downstreamIdleTimeout"
+	// UpstreamIdleTimeoutAnnotation is the ServiceEntry annotation which sets the idle timeout of the connections to
+	// the upstream clusters of a service, the value is a duration string such as "30s"
+	UpstreamIdleTimeoutAnnotation = "upstreamIdleTimeout"
+	// UpstreamConnectTimeoutAnnotation is the ServiceEntry annotation which sets how long the protocol proxy waits for
+	// the connections to the upstream clusters of a service to be established, the value is a positive duration string
+	// such as "500ms". It overrides the connect timeout of the connection pool of the DestinationRule.
+	UpstreamConnectTimeoutAnnotation = "upstreamConnectTimeout"
+	// UpstreamTLSModeAnnotation is the ServiceEntry annotation which sets the TLS mode of the connections to the
+	// upstream of a service in a DestinationRule managed by Aeraki, the value is either ISTIO_MUTUAL or DISABLE
+	UpstreamTLSModeAnnotation = "upstreamTLSMode"
+	// UpstreamTLSSNIAnnotation is the ServiceEntry annotation which overrides the SNI of the TLS connections to the
+	// upstream of a service when UpstreamTLSModeAnnotation is ISTIO_MUTUAL, the SNI defaults to the service host
+	UpstreamTLSSNIAnnotation = "upstreamTLSSNI"
+	// RetryBudgetPercentAnnotation is the ServiceEntry annotation which sets the retry budget of the MetaProtocol
+	// clusters, as the percentage of the active requests allowed to be retries
+	RetryBudgetPercentAnnotation = "retryBudgetPercent"
+	// PodNameAnnotation is the ServiceEntry annotation which restricts the inbound EnvoyFilters of a service to a
+	// single pod, the pod name is translated into a workload selector on PodNameLabel
+	PodNameAnnotation = "podName"
+	// PodNameLabel is the well-known label carrying the pod name. Kubernetes only sets it on the pods of a
+	// StatefulSet, the other pods need to be labeled manually to be selected by PodNameAnnotation.
+	PodNameLabel = "statefulset.kubernetes.io/pod-name"
+	// TCPWeightedClustersAnnotation is the ServiceEntry annotation which splits the connections of the TcpProxy
+	// between the subsets of a service, the value is a list of subset:weight such as "v1:70,v2:30". It only applies to
+	// the protocols whose filter is inserted before the TcpProxy, such as Kafka and Zookeeper.
+	TCPWeightedClustersAnnotation = "tcpWeightedClusters"
+	// DestinationCIDRsAnnotation is the ServiceEntry annotation which restricts the inbound filter chains of a service
+	// to the destination addresses within a list of CIDRs, such as "10.0.0.0/8,192.168.0.0/16"
+	DestinationCIDRsAnnotation = "destinationCIDRs"
+	// ProtocolPassthroughAnnotation is the ServiceEntry annotation which adds the original_dst listener filter to the
+	// outbound listeners of a service, so the connections redirected to them keep their original destination when they
+	// are passed through to the upstream, the value is a boolean
+	ProtocolPassthroughAnnotation = "protocolPassthrough"
+	// MaxConnectionsAnnotation is the ServiceEntry annotation which caps the concurrent connections of the protocol
+	// listeners of a service with the connection_limit filter, the value is a positive integer
+	MaxConnectionsAnnotation = "maxConnections"
+	// HeadlessEndpointsAnnotation is the ServiceEntry annotation which generates the outbound listener patches of a
+	// service without VIP for each of its endpoint IPs, the value is a boolean. The patches are regenerated whenever the
+	// endpoints change, so it should only be enabled for the services with a small and stable set of endpoints.
+	HeadlessEndpointsAnnotation = "headlessEndpoints"
+	// TLSPassthroughAnnotation is the ServiceEntry annotation which matches the outbound filter chains of a service by
+	// the TLS requested server names, which are the hosts of the service, the value is a boolean
+	TLSPassthroughAnnotation = "tlsPassthrough"
+	// SourceServiceEntryAnnotation is the annotation of the generated EnvoyFilters which points back to the
+	// ServiceEntry they're generated for, the value is namespace/name
+	SourceServiceEntryAnnotation = "aeraki.io/source-service-entry"
+	// ProxyMetadataMatchAnnotation is the ServiceEntry annotation which restricts the generated EnvoyFilters of a service
+	// to the proxies with the given node metadata, the value is a comma separated list of key=value pairs such as
+	// CLUSTER_ID=cluster-1
+	ProxyMetadataMatchAnnotation = "proxyMetadataMatch"
+	// TCPKeepaliveAnnotation is the ServiceEntry annotation which enables the TCP keepalive of the upstream connections
+	// of a service, the value is a JSON object such as {"probes": 3, "time": "10m", "interval": "75s"}, the system
+	// defaults are used for the settings left out
+	TCPKeepaliveAnnotation = "tcpKeepalive"
+	// ListenerBindAnnotation is the ServiceEntry annotation which sets how the outbound listeners of a service bind to
+	// their addresses, the value is a JSON object such as {"bindToPort": false, "additionalAddresses": ["10.0.0.2"]}
+	ListenerBindAnnotation = "listenerBind"
+	// OutboundFilterChainNameAnnotation is the ServiceEntry annotation which restricts the outbound filter chain patches
+	// of a service to the filter chain with the given name, for the listeners with multiple named filter chains
+	OutboundFilterChainNameAnnotation = "outboundFilterChainName"
+	// InboundFilterChainNameAnnotation is the ServiceEntry annotation which restricts the inbound filter chain patches
+	// of a service to the filter chain with the given name, for the listeners with multiple named filter chains
+	InboundFilterChainNameAnnotation = "inboundFilterChainName"
+	// RawFilterNameAnnotation is the ServiceEntry annotation which holds the name of the network filter inserted for
+	// the ports of a service whose protocol is raw, such as envoy.filters.network.experimental
+	RawFilterNameAnnotation = "rawFilterName"
+	// RawFilterTypedConfigAnnotation is the ServiceEntry annotation which holds the typed_config of the network filter
+	// inserted for the ports of a service whose protocol is raw, the value is a JSON object with the type URL of the
+	// config in its @type field
+	RawFilterTypedConfigAnnotation = "rawFilterTypedConfig"
+	// RDSDebounceAnnotation is the ServiceEntry annotation which overrides how long the MetaProtocol RDS server waits
+	// for further changes of a service before pushing its routes, the value is a duration such as 100ms, defaults to 1s
+	RDSDebounceAnnotation = "rdsDebounce"
+	// DrainOnClusterChangeAnnotation is the ServiceEntry annotation which drains the upstream connections of a service
+	// when the hosts of its clusters change, the value is a boolean, defaults to false
+	DrainOnClusterChangeAnnotation = "drainOnClusterChange"
+	// AccessLogFilterAnnotation is the ServiceEntry annotation which restricts the access log of a MetaProtocol service
+	// to the failed requests, the value is either errors for the requests with any response flag, or a comma separated
+	// list of the Envoy response flags such as UF,URX
+	AccessLogFilterAnnotation = "accessLogFilter"
+	// OutboundHostsAnnotation is the ServiceEntry annotation which scopes the outbound patches of a service to the
+	// sidecar egress traffic of the given hosts, the value is a comma separated list of the hosts of the service
+	OutboundHostsAnnotation = "outboundHosts"
+	// UpstreamProtocolAnnotation is the ServiceEntry annotation which sets the protocol options of the upstream
+	// connections of a service, the value is either http2 or tcp
+	UpstreamProtocolAnnotation = "upstreamProtocol"
+	// GatewayPatchContextAnnotation is the Gateway annotation which sets the listener direction the patches of the
+	// EnvoyFilters generated for the gateway match, the value is one of GATEWAY, SIDECAR_OUTBOUND, SIDECAR_INBOUND and
+	// ANY, defaults to GATEWAY
+	GatewayPatchContextAnnotation = "gatewayPatchContext"
+	// WasmFilterAnnotation is the ServiceEntry annotation which inserts a WASM network filter next to the protocol
+	// filter of a service, the value is a JSON object such as {"name": "audit", "filename": "/etc/wasm/audit.wasm",
+	// "position": "before"}, the code is either a file on the proxy or fetched from a url with its cluster and sha256
+	WasmFilterAnnotation = "wasmFilter"
+	// DropPercentageAnnotation is the ServiceEntry annotation which drops a percentage of the connections accepted by
+	// the protocol listeners of a service for resilience testing, the value is an integer between 1 and 100
+	DropPercentageAnnotation = "dropPercentage"
+	// InboundTransportAnnotation is the ServiceEntry annotation which restricts the inbound filter chain patches of a
+	// service to the mTLS or the plaintext filter chains, the value is one of mtls, plaintext and auto, defaults to
+	// auto, which patches the filter chains of both transports and lets the proxy pick the one in use
+	InboundTransportAnnotation = "inboundTransport"
+	// AllowedPrincipalsAnnotation is the ServiceEntry annotation which only accepts the inbound connections from the
+	// callers with the given SPIFFE identities, the value is a comma separated list of principals such as
+	// cluster.local/ns/default/sa/client, a principal may start or end with a * wildcard
+	AllowedPrincipalsAnnotation = "allowedPrincipals"
+	// DeniedPrincipalsAnnotation is the ServiceEntry annotation which rejects the inbound connections from the callers
+	// with the given SPIFFE identities, the value has the same format as the allowedPrincipals annotation
+	DeniedPrincipalsAnnotation = "deniedPrincipals"
+	// ListenerFiltersTimeoutAnnotation is the ServiceEntry annotation which sets how long the listener filters of the
+	// outbound listeners of a service wait for the data of a new connection, such as the one sniffing the protocol,
+	// the value is a duration string such as "5s", "0s" disables the timeout
+	ListenerFiltersTimeoutAnnotation = "listenerFiltersTimeout"
+	// ContinueOnListenerFiltersTimeoutAnnotation is the ServiceEntry annotation which tells whether the connections of
+	// the outbound listeners of a service are passed to the filter chains instead of being closed when the listener
+	// filters time out, the value is a boolean
+	ContinueOnListenerFiltersTimeoutAnnotation = "continueOnListenerFiltersTimeout"
+	// ListenerDrainTypeAnnotation is the ServiceEntry annotation which sets when the outbound listeners of a service
+	// drain their connections, the value is "default" or "modify-only". Envoy applies a protocol filter to the
+	// connections accepted after it's rolled out, "modify-only" keeps the existing connections from being drained when
+	// the health check of the proxy fails, so they're only drained when the listener is modified or removed.
+	ListenerDrainTypeAnnotation = "listenerDrainType"
+	// LocalityWeightedLBAnnotation is the ServiceEntry annotation which enables the locality weighted load balancing
+	// of the upstream clusters of a service, so the requests are spread across the zones by the weights of their
+	// endpoints, the value is a boolean, defaults to false
+	LocalityWeightedLBAnnotation = "localityWeightedLb"
+	// EnvoyFilterExpiresAtAnnotation is the ServiceEntry annotation which removes the EnvoyFilters generated for a
+	// service once the given time has passed, for the temporary overrides such as the ones of a maintenance window,
+	// the value is an RFC 3339 timestamp such as "2023-01-01T02:00:00Z"
+	EnvoyFilterExpiresAtAnnotation = "envoyFilterExpiresAt"
+	// DownstreamBufferLimitAnnotation is the ServiceEntry annotation which bounds the buffers of the downstream
+	// connections of the outbound listeners of a service, Envoy stops reading from a connection when its buffer is
+	// full until the slow upstream catches up, the value is a positive number of bytes
+	DownstreamBufferLimitAnnotation = "downstreamBufferLimit"
+	// DebugLogAnnotation is the ServiceEntry annotation which logs every request of a MetaProtocol service to debug
+	// it, even if the access log is off in the mesh config or restricted by the accessLogFilter annotation, the value
+	// is a boolean, defaults to false
+	DebugLogAnnotation = "debugLog"
+	// ExternalNameAnnotation is the ServiceEntry annotation which marks a service without VIP as the counterpart of a
+	// Kubernetes Service of type ExternalName, the value is the external DNS name of the Service. The outbound patches
+	// of the service match its listener on the wildcard address, and no VIP is allocated for it.
+	ExternalNameAnnotation = "externalName"
 )
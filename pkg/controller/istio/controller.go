@@ -166,7 +166,7 @@ func (c *Controller) configInitialRequests() []*discovery.DiscoveryRequest {
 // RegisterEventHandler adds a handler to receive config update events for a configuration type
 func (c *Controller) RegisterEventHandler(handler func(*istioconfig.Config, *istioconfig.Config, istiomodel.Event)) {
 	handlerWrapper := func(prev istioconfig.Config, curr istioconfig.Config, event istiomodel.Event) {
-		if event == istiomodel.EventUpdate && reflect.DeepEqual(prev.Spec, curr.Spec) {
+		if event == istiomodel.EventUpdate && !configChanged(&prev, &curr) {
 			return
 		}
 		// We care about these resources:
@@ -204,6 +204,12 @@ func (c *Controller) RegisterEventHandler(handler func(*istioconfig.Config, *ist
 	}
 }
 
+// configChanged checks whether an update changes a config, the annotations are compared along with the spec because
+// Aeraki reads some settings of a service from its annotations, such as the EnvoyFilter TTL and the access log
+func configChanged(prev, curr *istioconfig.Config) bool {
+	return !reflect.DeepEqual(prev.Spec, curr.Spec) || !reflect.DeepEqual(prev.Annotations, curr.Annotations)
+}
+
 func (c *Controller) shouldHandleGatewayChange(prev, curr *istioconfig.Config) bool {
 	return c.shouldHandleGateway(curr) || (!c.isNilConfig(prev) && c.shouldHandleGateway(prev))
 }
@@ -0,0 +1,76 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+)
+
+func TestConfigChanged(t *testing.T) {
+	config := func(port uint32, annotations map[string]string) *istioconfig.Config {
+		return &istioconfig.Config{
+			Meta: istioconfig.Meta{
+				Name:        "thrift-sample-server",
+				Namespace:   "meta-thrift",
+				Annotations: annotations,
+			},
+			Spec: &networking.ServiceEntry{
+				Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+				Ports: []*networking.Port{{Number: port, Name: "tcp-thrift"}},
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		prev *istioconfig.Config
+		curr *istioconfig.Config
+		want bool
+	}{
+		{
+			name: "unchanged",
+			prev: config(9090, map[string]string{"envoyFilterExpiresAt": "2023-01-01T01:00:00Z"}),
+			curr: config(9090, map[string]string{"envoyFilterExpiresAt": "2023-01-01T01:00:00Z"}),
+			want: false,
+		},
+		{
+			name: "spec changed",
+			prev: config(9090, nil),
+			curr: config(9091, nil),
+			want: true,
+		},
+		{
+			name: "annotation added",
+			prev: config(9090, nil),
+			curr: config(9090, map[string]string{"envoyFilterExpiresAt": "2023-01-01T01:00:00Z"}),
+			want: true,
+		},
+		{
+			name: "annotation changed",
+			prev: config(9090, map[string]string{"envoyFilterExpiresAt": "2023-01-01T01:00:00Z"}),
+			curr: config(9090, map[string]string{"envoyFilterExpiresAt": "2023-01-01T02:00:00Z"}),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := configChanged(tt.prev, tt.curr); got != tt.want {
+				t.Errorf("configChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
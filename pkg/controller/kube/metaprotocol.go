@@ -81,6 +81,7 @@ func (r *MetaProtocolController) Reconcile(ctx context.Context, request reconcil
 	}
 	metaProtocolLog.Debugf("register application protocol : %s, codec: %s", protocol.Spec.Protocol, protocol.Spec.Codec)
 	metaprotocolmodel.SetApplicationProtocolCodec(protocol.Spec.Protocol, protocol.Spec.Codec)
+	metaprotocolmodel.SetApplicationProtocolAttributes(protocol.Spec.Protocol, protocol.Spec.Attributes)
 
 	if r.triggerPush != nil {
 		err := r.triggerPush()
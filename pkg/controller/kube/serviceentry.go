@@ -123,6 +123,10 @@ func (c *serviceEntryController) autoAllocateIP(key controllerclient.ObjectKey,
 	if s.Spec.Resolution == istionapi.ServiceEntry_NONE {
 		return
 	}
+	// the clients of an ExternalName service resolve its host to the external name, so a VIP would never be used
+	if _, ok := s.Annotations[constants.ExternalNameAnnotation]; ok && len(s.Spec.Addresses) == 0 {
+		return
+	}
 
 	// Check whether the VIP conflicts with existing SEs if this service entry already has one
 	if len(s.Spec.Addresses) > 0 {
@@ -17,7 +17,12 @@ package kube
 import (
 	"testing"
 
+	istionapi "istio.io/api/networking/v1alpha3"
+	networking "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
 )
 
 func TestController_nextAvailableIP(t *testing.T) {
@@ -64,3 +69,24 @@ func TestController_nextAvailableIP(t *testing.T) {
 		t.Errorf("nextAvailableIP() = %v, want %v", got, "240.240.254.100")
 	}
 }
+
+func TestController_autoAllocateIPExternalName(t *testing.T) {
+	c := &serviceEntryController{
+		serviceIPs: make(map[string]client.ObjectKey),
+	}
+	se := &networking.ServiceEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "test",
+			Name:        "external",
+			Annotations: map[string]string{constants.ExternalNameAnnotation: "db.example.com"},
+		},
+		Spec: istionapi.ServiceEntry{
+			Hosts:      []string{"db.test.svc.cluster.local"},
+			Resolution: istionapi.ServiceEntry_DNS,
+		},
+	}
+	c.autoAllocateIP(client.ObjectKey{Namespace: "test", Name: "external"}, se)
+	if len(se.Spec.Addresses) != 0 || len(c.serviceIPs) != 0 {
+		t.Errorf("autoAllocateIP() allocated %v for an ExternalName service", se.Spec.Addresses)
+	}
+}
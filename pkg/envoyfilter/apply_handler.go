@@ -0,0 +1,54 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import "fmt"
+
+// ApplyHandler is notified each time the controller has applied a batch of generated configuration, which is the
+// result of the config changes debounced together. The error is nil if all the EnvoyFilters and DestinationRules of
+// the batch have been applied to the API server, so the protocol configuration has converged until the next change.
+// A failed batch is retried, and the handler is notified of every attempt.
+type ApplyHandler func(err error)
+
+// RegisterApplyHandler registers a handler to be notified of the applied batches. The handlers are called in the
+// order they're registered, from the goroutine of the controller, so they should return quickly.
+func (c *Controller) RegisterApplyHandler(handler ApplyHandler) {
+	c.applyHandlersLock.Lock()
+	defer c.applyHandlersLock.Unlock()
+	c.applyHandlers = append(c.applyHandlers, handler)
+}
+
+// applyBatch applies a batch of generated configuration and notifies the handlers of the result
+func (c *Controller) applyBatch(apply func() error) error {
+	err := apply()
+	c.applyHandlersLock.RLock()
+	handlers := append([]ApplyHandler(nil), c.applyHandlers...)
+	c.applyHandlersLock.RUnlock()
+	for _, handler := range handlers {
+		handler(err)
+	}
+	return err
+}
+
+// pushConfig2APIServer pushes the generated EnvoyFilters and DestinationRules to the API server
+func (c *Controller) pushConfig2APIServer() error {
+	if err := c.pushEnvoyFilters2APIServer(); err != nil {
+		return fmt.Errorf("failed to create envoyFilters: %v", err)
+	}
+	if err := c.pushDestinationRules2APIServer(); err != nil {
+		return fmt.Errorf("failed to create destinationRules: %v", err)
+	}
+	return nil
+}
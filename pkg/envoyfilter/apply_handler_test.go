@@ -0,0 +1,80 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestController_applyBatch(t *testing.T) {
+	errConflict := errors.New("the object has been modified")
+	tests := []struct {
+		name    string
+		results []error
+	}{
+		{
+			name:    "applied",
+			results: []error{nil},
+		},
+		{
+			name:    "failed",
+			results: []error{errConflict},
+		},
+		{
+			name:    "applied after a retry",
+			results: []error{errConflict, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(nil)
+			var first, second []error
+			c.RegisterApplyHandler(func(err error) {
+				first = append(first, err)
+			})
+			c.RegisterApplyHandler(func(err error) {
+				second = append(second, err)
+			})
+			for _, result := range tt.results {
+				applied := false
+				err := c.applyBatch(func() error {
+					applied = true
+					return result
+				})
+				if !applied {
+					t.Fatalf("applyBatch() didn't apply the batch")
+				}
+				if err != result {
+					t.Errorf("applyBatch() error = %v, want %v", err, result)
+				}
+			}
+			if !reflect.DeepEqual(first, tt.results) {
+				t.Errorf("first handler got %v, want %v", first, tt.results)
+			}
+			if !reflect.DeepEqual(second, tt.results) {
+				t.Errorf("second handler got %v, want %v", second, tt.results)
+			}
+		})
+	}
+}
+
+func TestController_applyBatchWithoutHandlers(t *testing.T) {
+	c := newTestController(nil)
+	if err := c.applyBatch(func() error { return nil }); err != nil {
+		t.Errorf("applyBatch() unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// downstreamBufferLimit returns the buffer limit of the downstream connections of the outbound listeners of a
+// service set by its annotation
+func downstreamBufferLimit(service *model.ServiceEntryWrapper) (uint32, bool, error) {
+	value, ok := service.Annotations[constants.DownstreamBufferLimitAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || limit == 0 {
+		return 0, false, fmt.Errorf("invalid %s annotation: %s, it should be a positive number of bytes",
+			constants.DownstreamBufferLimitAnnotation, value)
+	}
+	return uint32(limit), true, nil
+}
+
+// downstreamBufferLimitPatch generates a patch which sets the per connection buffer limit of an outbound listener.
+// When the buffer of a connection is full, Envoy applies back pressure by disabling the reads on it instead of
+// buffering more data.
+func downstreamBufferLimitPatch(listenerName string, port uint32,
+	limit uint32) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_LISTENER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, nil),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"per_connection_buffer_limit_bytes": {Kind: &types.Value_NumberValue{NumberValue: float64(limit)}},
+				},
+			},
+		},
+	}
+}
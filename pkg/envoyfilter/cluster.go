@@ -0,0 +1,75 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"sort"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// ExpectedOutboundClusterName returns the name of the outbound cluster Istio creates for a service port and subset,
+// the generated filters must reference exactly this name, otherwise the proxy refers to a cluster that doesn't exist.
+func ExpectedOutboundClusterName(service *networking.ServiceEntry, port *networking.Port, subset string) string {
+	return model.BuildClusterName(model.TrafficDirectionOutbound, subset, service.Hosts[0], int(port.Number))
+}
+
+// orphanClusters returns the outbound clusters referenced by the routes of a service which Istio won't create, because
+// the route subsets haven't been defined in the DestinationRule of the service (yet).
+func orphanClusters(context *model.EnvoyFilterContext) []string {
+	service := context.ServiceEntry.Spec
+	port := service.Ports[0]
+
+	subsets := make(map[string]bool)
+	if context.VirtualService != nil {
+		for _, http := range context.VirtualService.Spec.Http {
+			for _, route := range http.Route {
+				if route.Destination != nil && route.Destination.Subset != "" {
+					subsets[route.Destination.Subset] = true
+				}
+			}
+		}
+	}
+	if context.MetaRouter != nil {
+		for _, route := range context.MetaRouter.Spec.Routes {
+			for _, dest := range route.Route {
+				// only the subsets of this service are checked, the other hosts have their own DestinationRules
+				if dest.Destination != nil && dest.Destination.Subset != "" &&
+					dest.Destination.Host == service.Hosts[0] {
+					subsets[dest.Destination.Subset] = true
+				}
+			}
+		}
+		if fallback := context.MetaRouter.Spec.FallbackCluster; fallback != nil && fallback.Subset != "" &&
+			fallback.Host == service.Hosts[0] {
+			subsets[fallback.Subset] = true
+		}
+	}
+
+	if context.DestinationRule != nil && context.DestinationRule.Spec != nil {
+		for _, subset := range context.DestinationRule.Spec.Subsets {
+			delete(subsets, subset.Name)
+		}
+	}
+
+	clusters := make([]string, 0, len(subsets))
+	for subset := range subsets {
+		clusters = append(clusters, ExpectedOutboundClusterName(service, port, subset))
+	}
+	sort.Strings(clusters)
+	return clusters
+}
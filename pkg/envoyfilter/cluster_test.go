@@ -0,0 +1,294 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	metaprotocol "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestExpectedOutboundClusterName(t *testing.T) {
+	service := testService("thrift", "thrift.example.com", "tcp-thrift").Spec
+	tests := []struct {
+		name   string
+		subset string
+		want   string
+	}{
+		{
+			name: "without subset",
+			want: "outbound|9090||thrift.example.com",
+		},
+		{
+			name:   "with subset",
+			subset: "v1",
+			want:   "outbound|9090|v1|thrift.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpectedOutboundClusterName(service, service.Ports[0], tt.subset); got != tt.want {
+				t.Errorf("ExpectedOutboundClusterName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrphanClusters(t *testing.T) {
+	const host = "meta.example.com"
+	virtualService := &model.VirtualServiceWrapper{
+		Spec: &networking.VirtualService{
+			Http: []*networking.HTTPRoute{
+				{
+					Route: []*networking.HTTPRouteDestination{
+						{Destination: &networking.Destination{Host: host, Subset: "v1"}},
+						{Destination: &networking.Destination{Host: host, Subset: "v2"}},
+					},
+				},
+			},
+		},
+	}
+	metaRouter := &metaprotocol.MetaRouter{
+		Spec: v1alpha1.MetaRouter{
+			Routes: []*v1alpha1.MetaRoute{
+				{
+					Route: []*v1alpha1.MetaRouteDestination{
+						{Destination: &v1alpha1.Destination{Host: host, Subset: "v3"}},
+						{Destination: &v1alpha1.Destination{Host: "other.example.com", Subset: "v4"}},
+					},
+				},
+			},
+		},
+	}
+	destinationRule := &model.DestinationRuleWrapper{
+		Spec: &networking.DestinationRule{
+			Host:    host,
+			Subsets: []*networking.Subset{{Name: "v1"}, {Name: "v3"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		context *model.EnvoyFilterContext
+		want    []string
+	}{
+		{
+			name:    "no routes",
+			context: &model.EnvoyFilterContext{},
+		},
+		{
+			name: "virtual service without destination rule",
+			context: &model.EnvoyFilterContext{
+				VirtualService: virtualService,
+			},
+			want: []string{"outbound|9090|v1|meta.example.com", "outbound|9090|v2|meta.example.com"},
+		},
+		{
+			name: "virtual service with destination rule",
+			context: &model.EnvoyFilterContext{
+				VirtualService:  virtualService,
+				DestinationRule: destinationRule,
+			},
+			want: []string{"outbound|9090|v2|meta.example.com"},
+		},
+		{
+			name: "meta router with destination rule",
+			context: &model.EnvoyFilterContext{
+				MetaRouter:      metaRouter,
+				DestinationRule: destinationRule,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.context.ServiceEntry = testService("meta", host, "tcp-metaprotocol-dubbo")
+			got := orphanClusters(tt.context)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("orphanClusters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterClusterPatch(t *testing.T) {
+	type value = map[string]interface{}
+	tcpOptions := func(fields value) value {
+		fields["@type"] = "type.googleapis.com/" + tcpProtocolOptions
+		return value{"typed_extension_protocol_options": value{tcpProtocolOptions: fields}}
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		// want is the value of the patch merged into the clusters of the service, no patch is expected if it's nil
+		want        value
+		wantWarning bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:        "connect timeout",
+			annotations: map[string]string{constants.UpstreamConnectTimeoutAnnotation: "500ms"},
+			want:        value{"connect_timeout": "0.5s"},
+		},
+		{
+			name:        "zero connect timeout",
+			annotations: map[string]string{constants.UpstreamConnectTimeoutAnnotation: "0s"},
+			wantWarning: true,
+		},
+		{
+			name:        "negative connect timeout",
+			annotations: map[string]string{constants.UpstreamConnectTimeoutAnnotation: "-1s"},
+			wantWarning: true,
+		},
+		{
+			name:        "invalid connect timeout",
+			annotations: map[string]string{constants.UpstreamConnectTimeoutAnnotation: "5"},
+			wantWarning: true,
+		},
+		{
+			name:        "drain on cluster change",
+			annotations: map[string]string{constants.DrainOnClusterChangeAnnotation: "true"},
+			want:        value{"close_connections_on_host_set_change": true},
+		},
+		{
+			name:        "no drain on cluster change",
+			annotations: map[string]string{constants.DrainOnClusterChangeAnnotation: "false"},
+		},
+		{
+			name:        "invalid drain on cluster change",
+			annotations: map[string]string{constants.DrainOnClusterChangeAnnotation: "always"},
+			wantWarning: true,
+		},
+		{
+			name:        "locality weighted lb",
+			annotations: map[string]string{constants.LocalityWeightedLBAnnotation: "true"},
+			want:        value{"common_lb_config": value{"locality_weighted_lb_config": value{}}},
+		},
+		{
+			name:        "no locality weighted lb",
+			annotations: map[string]string{constants.LocalityWeightedLBAnnotation: "false"},
+		},
+		{
+			name:        "invalid locality weighted lb",
+			annotations: map[string]string{constants.LocalityWeightedLBAnnotation: "zone"},
+			wantWarning: true,
+		},
+		{
+			name:        "tcp keepalive",
+			annotations: map[string]string{constants.TCPKeepaliveAnnotation: `{"probes": 3, "time": "10m", "interval": "75s"}`},
+			want: value{"upstream_connection_options": value{"tcp_keepalive": value{
+				"keepalive_probes":   float64(3),
+				"keepalive_time":     float64(600),
+				"keepalive_interval": float64(75),
+			}}},
+		},
+		{
+			name:        "tcp keepalive system defaults",
+			annotations: map[string]string{constants.TCPKeepaliveAnnotation: `{}`},
+			want:        value{"upstream_connection_options": value{"tcp_keepalive": value{}}},
+		},
+		{
+			name:        "tcp keepalive fractional seconds",
+			annotations: map[string]string{constants.TCPKeepaliveAnnotation: `{"interval": "1500ms"}`},
+			wantWarning: true,
+		},
+		{
+			name:        "invalid tcp keepalive",
+			annotations: map[string]string{constants.TCPKeepaliveAnnotation: `probes=3`},
+			wantWarning: true,
+		},
+		{
+			name:        "upstream idle timeout",
+			annotations: map[string]string{constants.UpstreamIdleTimeoutAnnotation: "1m30s"},
+			want:        tcpOptions(value{"idle_timeout": "90s"}),
+		},
+		{
+			name:        "downstream idle timeout only",
+			annotations: map[string]string{constants.DownstreamIdleTimeoutAnnotation: "30s"},
+			wantWarning: true,
+		},
+		{
+			name:        "invalid upstream idle timeout",
+			annotations: map[string]string{constants.UpstreamIdleTimeoutAnnotation: "-1s"},
+			wantWarning: true,
+		},
+		{
+			name:        "upstream HTTP/2",
+			annotations: map[string]string{constants.UpstreamProtocolAnnotation: "http2"},
+			want: value{"typed_extension_protocol_options": value{httpProtocolOptions: value{
+				"@type":                "type.googleapis.com/" + httpProtocolOptions,
+				"explicit_http_config": value{"http2_protocol_options": value{}},
+			}}},
+		},
+		{
+			name:        "upstream TCP",
+			annotations: map[string]string{constants.UpstreamProtocolAnnotation: "tcp"},
+			want:        tcpOptions(value{}),
+		},
+		{
+			name: "upstream TCP with idle timeout",
+			annotations: map[string]string{
+				constants.UpstreamProtocolAnnotation:    "tcp",
+				constants.UpstreamIdleTimeoutAnnotation: "1m",
+			},
+			want: tcpOptions(value{"idle_timeout": "60s"}),
+		},
+		{
+			name:        "invalid upstream protocol",
+			annotations: map[string]string{constants.UpstreamProtocolAnnotation: "http3"},
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			// the clusters are shared by the VIPs, so a single patch is expected
+			service.Spec.Addresses = []string{"10.0.0.1", "10.0.0.2"}
+			service.Annotations = tt.annotations
+			result := generateThrift(service)
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, wantWarning %v", result.Warnings, tt.wantWarning)
+			}
+
+			clusterPatches := patchesOf(result, networking.EnvoyFilter_CLUSTER)
+			if tt.want == nil {
+				if len(clusterPatches) != 0 {
+					t.Errorf("unexpected cluster patches: %v", clusterPatches)
+				}
+				return
+			}
+			if len(clusterPatches) != 1 {
+				t.Fatalf("got %d cluster patches, want 1", len(clusterPatches))
+			}
+			patch := clusterPatches[0]
+			if patch.Patch.Operation != networking.EnvoyFilter_Patch_MERGE ||
+				patch.Match.GetCluster().Service != "thrift.example.com" ||
+				patch.Match.GetCluster().PortNumber != 9090 {
+				t.Errorf("cluster patch = %v, want a merge into the clusters of the service", patch)
+			}
+			if got := patchValue(t, patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cluster patch value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
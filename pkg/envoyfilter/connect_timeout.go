@@ -0,0 +1,67 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// upstreamConnectTimeout returns the connect timeout of the upstream clusters of a service set by the annotation of
+// the service
+func upstreamConnectTimeout(service *model.ServiceEntryWrapper) (time.Duration, bool, error) {
+	value, ok := service.Annotations[constants.UpstreamConnectTimeoutAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	// Envoy requires the connect timeout to be greater than zero
+	if err != nil || timeout <= 0 {
+		return 0, false, fmt.Errorf("invalid %s annotation: %s, it should be a positive duration",
+			constants.UpstreamConnectTimeoutAnnotation, value)
+	}
+	return timeout, true, nil
+}
+
+// upstreamConnectTimeoutClusterPatch sets the connect timeout of all the subset clusters of a service port
+func upstreamConnectTimeoutClusterPatch(host string, port uint32,
+	timeout time.Duration) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_CLUSTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &networking.EnvoyFilter_ClusterMatch{
+					PortNumber: port,
+					Service:    host,
+				},
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"connect_timeout": {Kind: &types.Value_StringValue{StringValue: DurationJSON(timeout)}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	connectionlimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/connection_limit/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	connectionLimitFilter = "envoy.filters.network.connection_limit"
+	connectionLimitType   = "type.googleapis.com/envoy.extensions.filters.network.connection_limit.v3.ConnectionLimit"
+)
+
+// maxConnections returns the connection cap of the protocol listeners of a service set by its annotation
+func maxConnections(service *model.ServiceEntryWrapper) (uint64, bool, error) {
+	value, ok := service.Annotations[constants.MaxConnectionsAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || limit == 0 {
+		return 0, false, fmt.Errorf("invalid %s annotation: %s, it should be a positive integer",
+			constants.MaxConnectionsAnnotation, value)
+	}
+	return limit, true, nil
+}
+
+// connectionLimitPatch inserts the connection_limit filter before the protocol filter of a listener. The patch has to
+// follow the one which puts the protocol filter into the filter chain, since the patches are applied in order.
+func connectionLimitPatch(context networking.EnvoyFilter_PatchContext, listenerName string, port,
+	destinationPort uint32, filterName, statPrefix string,
+	limit uint64) (*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	value, err := generateValue(&connectionlimit.ConnectionLimit{
+		StatPrefix:     StatPrefix(statPrefix),
+		MaxConnections: &wrappers.UInt64Value{Value: limit},
+	}, connectionLimitFilter, connectionLimitType)
+	if err != nil {
+		return nil, err
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: context,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+					DestinationPort: destinationPort,
+					Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+						Name: filterName,
+					},
+				}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     value,
+		},
+	}, nil
+}
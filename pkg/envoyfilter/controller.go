@@ -17,13 +17,16 @@ package envoyfilter
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	"istio.io/istio/pkg/config"
 
 	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/go-multierror"
 	"github.com/zhaohuabing/debounce"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
@@ -67,6 +70,13 @@ type Controller struct {
 	// Sending on this channel results in a push.
 	pushChannel chan istiomodel.Event
 	meshConfig  mesh.Holder
+
+	applyHandlersLock sync.RWMutex
+	applyHandlers     []ApplyHandler
+
+	// expiries holds the expiry times of the services with an EnvoyFilter expiry for which a push has been scheduled
+	expiriesLock sync.Mutex
+	expiries     map[string]time.Time
 }
 
 // NewController creates a new controller instance based on the provided arguments.
@@ -79,6 +89,7 @@ func NewController(istioClientset *istioclient.Clientset, store istiomodel.Confi
 		namespaceScoped: namespaceScoped,
 		namespace:       namespace,
 		pushChannel:     make(chan istiomodel.Event, 100),
+		expiries:        make(map[string]time.Time),
 	}
 	return controller
 }
@@ -99,9 +110,8 @@ func (c *Controller) mainLoop(stop <-chan struct{}) {
 	const maxRetries = 3
 	retries := 0
 	callback := func() {
-		err := c.pushEnvoyFilters2APIServer()
-		if err != nil {
-			controllerLog.Errorf("failed to create envoyFilters: %v", err)
+		if err := c.applyBatch(c.pushConfig2APIServer); err != nil {
+			controllerLog.Errorf("%v", err)
 			// Retry if failed to create envoyFilters
 			if retries >= maxRetries {
 				retries = 0
@@ -126,7 +136,7 @@ func (c *Controller) mainLoop(stop <-chan struct{}) {
 }
 
 func (c *Controller) pushEnvoyFilters2APIServer() error {
-	generatedEnvoyFilters, err := c.generateEnvoyFilters()
+	generatedEnvoyFilters, metaRouterStatuses, err := c.generateEnvoyFilters()
 	if err != nil {
 		return fmt.Errorf("failed to generate EnvoyFilter: %v", err)
 	}
@@ -152,7 +162,9 @@ func (c *Controller) pushEnvoyFilters2APIServer() error {
 		oldEnvoyFilter := &existingEnvoyFilters.Items[i]
 		mapKey := envoyFilterMapKey(oldEnvoyFilter.Name, oldEnvoyFilter.Namespace)
 		if newEnvoyFilter, ok := generatedEnvoyFilters[mapKey]; ok {
-			if !proto.Equal(newEnvoyFilter.Envoyfilter, &oldEnvoyFilter.Spec) {
+			if !proto.Equal(newEnvoyFilter.Envoyfilter, &oldEnvoyFilter.Spec) ||
+				metadataChanged(envoyFilterLabels(newEnvoyFilter), oldEnvoyFilter.Labels) ||
+				metadataChanged(newEnvoyFilter.Annotations, oldEnvoyFilter.Annotations) {
 				controllerLog.Infof("updating EnvoyFilter: namespace: %s name: %s %v", newEnvoyFilter.Namespace,
 					newEnvoyFilter.Name, model.Struct2JSON(*newEnvoyFilter.Envoyfilter))
 				_, err = c.istioClientset.NetworkingV1alpha3().EnvoyFilters(newEnvoyFilter.Namespace).Update(context.TODO(),
@@ -175,83 +187,208 @@ func (c *Controller) pushEnvoyFilters2APIServer() error {
 				nil),
 			v1.CreateOptions{FieldManager: constants.AerakiFieldManager})
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	// the MetaRouter statuses are only recorded after the EnvoyFilters they point to have been pushed
+	c.updateMetaRouterStatuses(metaRouterStatuses)
+	return nil
 }
 
 func (c *Controller) toEnvoyFilterCRD(newEf *model.EnvoyFilterWrapper,
 	oldEf *v1alpha3.EnvoyFilter) *v1alpha3.EnvoyFilter {
 	envoyFilter := &v1alpha3.EnvoyFilter{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      newEf.Name,
-			Namespace: newEf.Namespace,
-			Labels: map[string]string{
-				"manager": constants.AerakiFieldManager,
-			},
+			Name:        newEf.Name,
+			Namespace:   newEf.Namespace,
+			Labels:      envoyFilterLabels(newEf),
+			Annotations: newEf.Annotations,
 		},
 		Spec: *newEf.Envoyfilter,
 	}
 	if oldEf != nil {
 		envoyFilter.ResourceVersion = oldEf.ResourceVersion
+		// keep the annotations added by others, the ones set by Aeraki take precedence
+		if len(oldEf.Annotations) > 0 {
+			annotations := make(map[string]string, len(oldEf.Annotations)+len(newEf.Annotations))
+			for key, value := range oldEf.Annotations {
+				annotations[key] = value
+			}
+			for key, value := range newEf.Annotations {
+				annotations[key] = value
+			}
+			envoyFilter.Annotations = annotations
+		}
 	}
 	return envoyFilter
 }
 
-func (c *Controller) generateEnvoyFilters() (map[string]*model.EnvoyFilterWrapper, error) {
+// generateEnvoyFilters generates the EnvoyFilters of all the services, along with the statuses of the MetaRouters of
+// the services whose EnvoyFilters have been generated successfully. It fails if the resources related to a service
+// can't be found, so the push is retried instead of deleting the EnvoyFilters of that service.
+func (c *Controller) generateEnvoyFilters() (map[string]*model.EnvoyFilterWrapper, []*metaRouterStatus, error) {
 	envoyFilters := make(map[string]*model.EnvoyFilterWrapper)
+	var metaRouterStatuses []*metaRouterStatus
 	serviceEntries, err := c.configStore.List(collections.IstioNetworkingV1Alpha3Serviceentries.Resource().
 		GroupVersionKind(), "")
 	if err != nil {
-		return envoyFilters, fmt.Errorf("failed to listconfigs: %v", err)
+		return envoyFilters, metaRouterStatuses, fmt.Errorf("failed to listconfigs: %v", err)
 	}
 
 	for i := range serviceEntries {
 		service, ok := serviceEntries[i].Spec.(*networking.ServiceEntry)
 		if !ok { // should never happen
-			return envoyFilters, fmt.Errorf("failed in getting a service entry: %s: %v", serviceEntries[i].Labels, err)
+			return envoyFilters, metaRouterStatuses, fmt.Errorf("failed in getting a service entry: %s: %v",
+				serviceEntries[i].Labels, err)
 		}
-
-		if len(service.Hosts) == 0 {
-			controllerLog.Errorf("host should not be empty: %s", serviceEntries[i].Name)
-			// We can't retry in this scenario
-			return envoyFilters, nil
+		wrapper := &model.ServiceEntryWrapper{
+			Meta: serviceEntries[i].Meta,
+			Spec: service,
+		}
+		generator, ctx, err := c.prepare(wrapper)
+		if err != nil {
+			return envoyFilters, metaRouterStatuses, fmt.Errorf("service %s/%s: %v", wrapper.Namespace,
+				wrapper.Name, err)
+		}
+		if generator == nil {
+			continue
 		}
+		warnings, err := c.generate(generator, ctx, envoyFilters)
+		for _, warning := range warnings {
+			controllerLog.Warnf("service: %s/%s: %s", wrapper.Namespace, wrapper.Name, warning)
+		}
+		if err != nil {
+			controllerLog.Errorf("failed to generate envoy filter: service: %s, error: %v", wrapper.Name, err)
+		} else if ctx.MetaRouter != nil {
+			metaRouterStatuses = append(metaRouterStatuses, &metaRouterStatus{
+				metaRouter: ctx.MetaRouter,
+				names:      MetaProtocolEnvoyFilterNames(ctx.ServiceEntry),
+			})
+		}
+	}
+
+	// generate envoyFilters for gateway with tcp-metaprotocol server
+	c.generateGatewayEnvoyFilters(envoyFilters)
 
-		if len(service.Hosts) > 1 {
-			controllerLog.Warnf("multiple hosts found for service: %s, only the first one will be processed",
-				serviceEntries[i].Name)
+	return envoyFilters, metaRouterStatuses, nil
+}
+
+// GenerateAll generates the EnvoyFilters for a batch of services, each service is dispatched to the generator of its
+// protocol. Services without an Aeraki supported protocol are skipped. A failure on one service doesn't abort the
+// batch, the errors of all the failed services are aggregated into the returned error. The warnings of the services
+// are prefixed with the service namespace and name in the returned result.
+func (c *Controller) GenerateAll(services []*model.ServiceEntryWrapper) (*model.GenerationResult, error) {
+	envoyFilters := make(map[string]*model.EnvoyFilterWrapper)
+	result := &model.GenerationResult{}
+	var errs *multierror.Error
+	for _, service := range services {
+		generator, ctx, err := c.prepare(service)
+		var warnings []string
+		if err == nil && generator != nil {
+			warnings, err = c.generate(generator, ctx, envoyFilters)
+		}
+		for _, warning := range warnings {
+			result.AddWarning("service %s/%s: %s", service.Namespace, service.Name, warning)
+		}
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("service %s/%s: %v", service.Namespace, service.Name, err))
 		}
+	}
 
-		for _, port := range service.Ports {
-			instance := protocol.GetLayer7ProtocolFromPortName(port.Name)
-			if generator, ok := c.generators[instance]; ok {
-				controllerLog.Infof("found generator for port: %s", port.Name)
+	result.EnvoyFilters = make([]*model.EnvoyFilterWrapper, 0, len(envoyFilters))
+	for _, wrapper := range envoyFilters {
+		result.EnvoyFilters = append(result.EnvoyFilters, wrapper)
+	}
+	sort.Slice(result.EnvoyFilters, func(i, j int) bool {
+		return envoyFilterMapKey(result.EnvoyFilters[i].Name, result.EnvoyFilters[i].Namespace) <
+			envoyFilterMapKey(result.EnvoyFilters[j].Name, result.EnvoyFilters[j].Namespace)
+	})
+	return result, errs.ErrorOrNil()
+}
 
-				ctx, err := c.envoyFilterContext(service, &serviceEntries[i])
-				if err != nil {
-					return envoyFilters, err
-				}
-				if ctx == nil {
-					return envoyFilters, err
-				}
-				envoyFilterWrappers, err := generator.Generate(ctx)
-				if err == nil {
-					for _, wrapper := range envoyFilterWrappers {
-						envoyFilters = c.createEnvoyFiltersOnExportNSs(ctx, wrapper, envoyFilters)
-					}
-				} else {
-					controllerLog.Errorf("failed to generate envoy filter: service: %s, port: %s, error: %v",
-						serviceEntries[i].Name,
-						port.Name, err)
-				}
-				break
-			}
+// prepare finds the generator of a service and the resources related to it. A nil generator is returned for the
+// services which aren't selected, don't have an Aeraki supported protocol, or whose EnvoyFilters have expired.
+func (c *Controller) prepare(service *model.ServiceEntryWrapper) (Generator, *model.EnvoyFilterContext, error) {
+	if service == nil || service.Spec == nil || !isServiceEntrySelected(service.Labels) {
+		return nil, nil, nil
+	}
+	if len(service.Spec.Hosts) == 0 {
+		return nil, nil, fmt.Errorf("host should not be empty")
+	}
+	if len(service.Spec.Hosts) > 1 {
+		controllerLog.Warnf("multiple hosts found for service: %s/%s, only the first one will be processed",
+			service.Namespace, service.Name)
+	}
+	generator := c.findGenerator(service.Spec)
+	if generator == nil {
+		controllerLog.Debugf("no generator found for service: %s/%s", service.Namespace, service.Name)
+		return nil, nil, nil
+	}
+	if c.envoyFiltersExpired(service) {
+		return nil, nil, nil
+	}
+	ctx, err := c.envoyFilterContext(service)
+	if err != nil {
+		return nil, nil, err
+	}
+	return generator, ctx, nil
+}
+
+// findGenerator returns the generator of the first service port which carries an Aeraki supported protocol
+func (c *Controller) findGenerator(service *networking.ServiceEntry) Generator {
+	for _, port := range service.Ports {
+		instance := protocol.GetLayer7ProtocolFromPortName(port.Name)
+		if generator, ok := c.generators[instance]; ok {
+			controllerLog.Infof("found generator for port: %s", port.Name)
+			return generator
 		}
 	}
+	return nil
+}
 
-	// generate envoyFilters for gateway with tcp-metaprotocol server
-	c.generateGatewayEnvoyFilters(envoyFilters)
+// generate calls the generator and adds the generated EnvoyFilters to the namespaces they're exported to, the warnings
+// of the generation are returned
+func (c *Controller) generate(generator Generator, ctx *model.EnvoyFilterContext,
+	envoyFilters map[string]*model.EnvoyFilterWrapper) ([]string, error) {
+	var warnings []string
+	// Istio only creates the subset clusters defined in the DestinationRule, a route referencing an undefined subset
+	// leaves the proxy with an orphan cluster reference until the DestinationRule catches up
+	for _, cluster := range orphanClusters(ctx) {
+		warnings = append(warnings, fmt.Sprintf("cluster %s doesn't exist, please check the subsets in the "+
+			"DestinationRule", cluster))
+	}
+	warning, err := c.upstreamTLSConflictWarning(ctx.ServiceEntry)
+	if err != nil {
+		return warnings, err
+	}
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+	result, err := generator.Generate(ctx)
+	if err != nil {
+		return warnings, err
+	}
+	for _, wrapper := range result.EnvoyFilters {
+		if err := postProcessEnvoyFilter(wrapper, ctx.ServiceEntry); err != nil {
+			return warnings, err
+		}
+	}
+	for _, wrapper := range result.EnvoyFilters {
+		c.createEnvoyFiltersOnExportNSs(ctx, wrapper, envoyFilters)
+	}
+	return append(warnings, result.Warnings...), nil
+}
 
-	return envoyFilters, nil
+// postProcessEnvoyFilter applies the settings shared by all the generated EnvoyFilters of a service, such as the
+// labels and the annotations of the EnvoyFilter and the proxy matches set by the service annotations, then validates
+// the type URLs of the patches
+func postProcessEnvoyFilter(wrapper *model.EnvoyFilterWrapper, service *model.ServiceEntryWrapper) error {
+	annotateSourceServiceEntry(wrapper, service)
+	labelEnvoyFilter(wrapper)
+	matchProxyMetadata(wrapper, service)
+	matchFilterChainName(wrapper, service)
+	matchInboundTransport(wrapper, service)
+	return validateTypeURLs(wrapper)
 }
 
 func (c *Controller) generateGatewayEnvoyFilters(envoyFilters map[string]*model.EnvoyFilterWrapper) {
@@ -290,14 +427,22 @@ func (c *Controller) generateGatewayEnvoyFilters(envoyFilters map[string]*model.
 					continue
 				}
 				for _, ctx := range ctxs {
-					envoyFilterWrappers, err := generator.Generate(ctx)
+					result, err := generator.Generate(ctx)
 					if err != nil {
 						controllerLog.Errorf("failed to generate router envoy filter: router: %s, port: %s, error: %v",
 							gateways[i].Name,
 							server.Name, err)
 						continue
 					}
-					for _, wrapper := range envoyFilterWrappers {
+					for _, warning := range result.Warnings {
+						controllerLog.Warnf("router: %s, port: %s: %s", gateways[i].Name, server.Name, warning)
+					}
+					for _, wrapper := range result.EnvoyFilters {
+						if err := postProcessEnvoyFilter(wrapper, ctx.ServiceEntry); err != nil {
+							controllerLog.Errorf("invalid router envoy filter: router: %s, port: %s, error: %v",
+								gateways[i].Name, server.Name, err)
+							continue
+						}
 						envoyFilters[envoyFilterMapKey(wrapper.Name, wrapper.Namespace)] = wrapper
 					}
 				}
@@ -328,6 +473,8 @@ func (c *Controller) createEnvoyFiltersOnExportNSs(ctx *model.EnvoyFilterContext
 			wrapperClone := &model.EnvoyFilterWrapper{
 				Name:        wrapper.Name,
 				Namespace:   exportNS,
+				Labels:      wrapper.Labels,
+				Annotations: wrapper.Annotations,
 				Envoyfilter: wrapper.Envoyfilter,
 			}
 			envoyFilters[envoyFilterMapKey(wrapperClone.Name, wrapperClone.Namespace)] = wrapperClone
@@ -337,24 +484,25 @@ func (c *Controller) createEnvoyFiltersOnExportNSs(ctx *model.EnvoyFilterContext
 }
 
 // envoyFilterContext wraps all the resources needed to create the EnvoyFilter
-func (c *Controller) envoyFilterContext(service *networking.ServiceEntry,
-	serviceEntry *config.Config) (*model.EnvoyFilterContext, error) {
-	relatedVs, err := c.findRelatedVirtualService(service)
+func (c *Controller) envoyFilterContext(service *model.ServiceEntryWrapper) (*model.EnvoyFilterContext, error) {
+	relatedVs, err := c.findRelatedVirtualService(service.Spec)
 	if err != nil {
-		return nil, fmt.Errorf("failed in finding the related virtual service : %s: %v", service.Hosts[0], err)
+		return nil, fmt.Errorf("failed in finding the related virtual service : %s: %v", service.Spec.Hosts[0], err)
 	}
-	relatedMr, err := c.findRelatedMetaRouter(service)
+	relatedMr, err := c.findRelatedMetaRouter(service.Spec)
 	if err != nil {
-		return nil, fmt.Errorf("failed in finding the related meta router : %s: %v", service.Hosts[0], err)
+		return nil, fmt.Errorf("failed in finding the related meta router : %s: %v", service.Spec.Hosts[0], err)
+	}
+	relatedDr, err := c.findRelatedDestinationRule(service)
+	if err != nil {
+		return nil, fmt.Errorf("failed in finding the related destination rule : %s: %v", service.Spec.Hosts[0], err)
 	}
 	return &model.EnvoyFilterContext{
-		MeshConfig: c.meshConfig,
-		ServiceEntry: &model.ServiceEntryWrapper{
-			Meta: serviceEntry.Meta,
-			Spec: service,
-		},
-		VirtualService: relatedVs,
-		MetaRouter:     relatedMr,
+		MeshConfig:      c.meshConfig,
+		ServiceEntry:    service,
+		VirtualService:  relatedVs,
+		MetaRouter:      relatedMr,
+		DestinationRule: relatedDr,
 	}, nil
 }
 
@@ -426,6 +574,28 @@ func (c *Controller) defaultEnvoyFilterNS(serviceNS string) string {
 	return c.namespace
 }
 
+// annotateSourceServiceEntry adds the annotation pointing back to the ServiceEntry an EnvoyFilter is generated for
+func annotateSourceServiceEntry(wrapper *model.EnvoyFilterWrapper, service *model.ServiceEntryWrapper) {
+	if service == nil {
+		return
+	}
+	if wrapper.Annotations == nil {
+		wrapper.Annotations = make(map[string]string)
+	}
+	wrapper.Annotations[constants.SourceServiceEntryAnnotation] = service.Namespace + "/" + service.Name
+}
+
+// metadataChanged checks whether the labels or annotations set by Aeraki differ from the ones of an existing
+// EnvoyFilter, the ones added by others are left alone
+func metadataChanged(metadata, existing map[string]string) bool {
+	for key, value := range metadata {
+		if existing[key] != value {
+			return true
+		}
+	}
+	return false
+}
+
 func envoyFilterMapKey(name, ns string) string {
 	return ns + "-" + name
 }
@@ -474,6 +644,29 @@ func (c *Controller) findRelatedMetaRouter(service *networking.ServiceEntry) (*m
 	return nil, nil
 }
 
+func (c *Controller) findRelatedDestinationRule(service *model.ServiceEntryWrapper) (*model.DestinationRuleWrapper,
+	error) {
+	drs, err := c.configStore.List(
+		collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind(), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %v", err)
+	}
+
+	for i := range drs {
+		dr, ok := drs[i].Spec.(*networking.DestinationRule)
+		if !ok { // should never happen
+			return nil, fmt.Errorf("failed in getting a destination rule: %s: %v", drs[i].Name, err)
+		}
+		if model.IsFQDNEquals(dr.Host, drs[i].Namespace, service.Spec.Hosts[0], service.Namespace) {
+			return &model.DestinationRuleWrapper{
+				Meta: drs[i].Meta,
+				Spec: dr,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
 // ConfigUpdated sends a config change event to the pushChannel to trigger the generation of envoyfilters
 func (c *Controller) ConfigUpdated(event istiomodel.Event) {
 	c.pushChannel <- event
@@ -0,0 +1,438 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aerakischeme "github.com/aeraki-mesh/aeraki/client-go/pkg/clientset/versioned/scheme"
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+type stubGenerator struct {
+	err     error
+	warning string
+}
+
+func (g *stubGenerator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	result := &model.GenerationResult{
+		EnvoyFilters: []*model.EnvoyFilterWrapper{
+			{
+				Name:        "aeraki-" + context.ServiceEntry.Spec.Hosts[0],
+				Envoyfilter: &networking.EnvoyFilter{},
+			},
+		},
+	}
+	if g.warning != "" {
+		result.AddWarning(g.warning)
+	}
+	return result, nil
+}
+
+func newTestController(generators map[protocol.Instance]Generator) *Controller {
+	c := NewController(nil, memory.Make(collections.Pilot), generators, false, "istio-system")
+	c.MetaRouterControllerClient = fake.NewClientBuilder().WithScheme(aerakischeme.Scheme).Build()
+	meshConfig := mesh.DefaultMeshConfig()
+	c.InitMeshConfig(mesh.NewFixedWatcher(&meshConfig))
+	return c
+}
+
+func testService(name, host, portName string) *model.ServiceEntryWrapper {
+	return &model.ServiceEntryWrapper{
+		Meta: istioconfig.Meta{
+			Name:      name,
+			Namespace: "meta",
+		},
+		Spec: &networking.ServiceEntry{
+			Hosts: []string{host},
+			Ports: []*networking.Port{
+				{
+					Number: 9090,
+					Name:   portName,
+				},
+			},
+		},
+	}
+}
+
+func TestController_GenerateAll(t *testing.T) {
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{},
+		protocol.Kafka:  &stubGenerator{},
+		protocol.Dubbo:  &stubGenerator{err: fmt.Errorf("invalid interface")},
+	})
+
+	services := []*model.ServiceEntryWrapper{
+		testService("thrift", "thrift.example.com", "tcp-thrift"),
+		testService("dubbo", "dubbo.example.com", "tcp-dubbo"),
+		testService("kafka", "kafka.example.com", "tcp-kafka"),
+		testService("http", "http.example.com", "http"),
+		{
+			Meta: istioconfig.Meta{Name: "no-host", Namespace: "meta"},
+			Spec: &networking.ServiceEntry{},
+		},
+	}
+
+	result, err := c.GenerateAll(services)
+	if err == nil {
+		t.Fatalf("GenerateAll() expected an error for the failed services")
+	}
+	for _, want := range []string{"meta/dubbo: invalid interface", "meta/no-host: host should not be empty"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("GenerateAll() error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	var got []string
+	for _, wrapper := range result.EnvoyFilters {
+		got = append(got, wrapper.Namespace+"/"+wrapper.Name)
+	}
+	want := []string{"istio-system/aeraki-kafka.example.com", "istio-system/aeraki-thrift.example.com"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("GenerateAll() = %v, want %v", got, want)
+	}
+}
+
+func TestController_GenerateAllNoError(t *testing.T) {
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{},
+	})
+
+	result, err := c.GenerateAll([]*model.ServiceEntryWrapper{
+		testService("thrift", "thrift.example.com", "tcp-thrift"),
+	})
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	if len(result.EnvoyFilters) != 1 {
+		t.Errorf("GenerateAll() got %d EnvoyFilters, want 1", len(result.EnvoyFilters))
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("GenerateAll() unexpected warnings: %v", result.Warnings)
+	}
+}
+
+func TestController_GenerateAllSourceServiceEntry(t *testing.T) {
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{},
+		protocol.Kafka:  &stubGenerator{},
+	})
+	kafka := testService("kafka", "kafka.example.com", "tcp-kafka")
+	kafka.Namespace = "meta-kafka"
+
+	result, err := c.GenerateAll([]*model.ServiceEntryWrapper{
+		testService("thrift", "thrift.example.com", "tcp-thrift"),
+		kafka,
+	})
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"aeraki-kafka.example.com":  "meta-kafka/kafka",
+		"aeraki-thrift.example.com": "meta/thrift",
+	}
+	if len(result.EnvoyFilters) != len(want) {
+		t.Fatalf("GenerateAll() got %d EnvoyFilters, want %d", len(result.EnvoyFilters), len(want))
+	}
+	for _, wrapper := range result.EnvoyFilters {
+		if got := wrapper.Annotations[constants.SourceServiceEntryAnnotation]; got != want[wrapper.Name] {
+			t.Errorf("EnvoyFilter %s source = %q, want %q", wrapper.Name, got, want[wrapper.Name])
+		}
+	}
+}
+
+func TestController_toEnvoyFilterCRDAnnotations(t *testing.T) {
+	c := newTestController(nil)
+	wrapper := &model.EnvoyFilterWrapper{
+		Name:        "aeraki-thrift.example.com",
+		Namespace:   "istio-system",
+		Annotations: map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift"},
+		Envoyfilter: &networking.EnvoyFilter{},
+	}
+	tests := []struct {
+		name        string
+		existing    map[string]string
+		want        map[string]string
+		wantChanged bool
+	}{
+		{
+			name:        "new EnvoyFilter",
+			want:        map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift"},
+			wantChanged: true,
+		},
+		{
+			name:     "unchanged",
+			existing: map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift"},
+			want:     map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift"},
+		},
+		{
+			name:     "annotated by others",
+			existing: map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift", "owner": "team-a"},
+			want:     map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift", "owner": "team-a"},
+		},
+		{
+			name:        "source changed",
+			existing:    map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift-v1"},
+			want:        map[string]string{constants.SourceServiceEntryAnnotation: "meta/thrift"},
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metadataChanged(wrapper.Annotations, tt.existing); got != tt.wantChanged {
+				t.Errorf("metadataChanged() = %v, want %v", got, tt.wantChanged)
+			}
+			var existing *v1alpha3.EnvoyFilter
+			if tt.existing != nil {
+				existing = &v1alpha3.EnvoyFilter{ObjectMeta: v1.ObjectMeta{Annotations: tt.existing}}
+			}
+			if got := c.toEnvoyFilterCRD(wrapper, existing).Annotations; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toEnvoyFilterCRD() annotations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_GenerateAllWarnings(t *testing.T) {
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{warning: "the service has no workload selector"},
+	})
+
+	result, err := c.GenerateAll([]*model.ServiceEntryWrapper{
+		testService("thrift", "thrift.example.com", "tcp-thrift"),
+	})
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	want := "service meta/thrift: the service has no workload selector"
+	if len(result.Warnings) != 1 || result.Warnings[0] != want {
+		t.Errorf("GenerateAll() warnings = %v, want [%s]", result.Warnings, want)
+	}
+}
+
+func TestController_GenerateAllServiceEntrySelector(t *testing.T) {
+	defer func() {
+		_ = SetServiceEntrySelector("")
+	}()
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{},
+	})
+	managed := testService("managed", "managed.example.com", "tcp-thrift")
+	managed.Labels = map[string]string{"aeraki.io/managed": "true"}
+	optedOut := testService("opted-out", "opted-out.example.com", "tcp-thrift")
+	optedOut.Labels = map[string]string{"aeraki.io/managed": "false"}
+	unlabeled := testService("unlabeled", "unlabeled.example.com", "tcp-thrift")
+	services := []*model.ServiceEntryWrapper{managed, optedOut, unlabeled}
+
+	tests := []struct {
+		name     string
+		selector string
+		want     []string
+	}{
+		{
+			name: "all services",
+			want: []string{"istio-system/aeraki-managed.example.com", "istio-system/aeraki-opted-out.example.com",
+				"istio-system/aeraki-unlabeled.example.com"},
+		},
+		{
+			name:     "opt in",
+			selector: "aeraki.io/managed=true",
+			want:     []string{"istio-system/aeraki-managed.example.com"},
+		},
+		{
+			name:     "opt out",
+			selector: "aeraki.io/managed!=false",
+			want:     []string{"istio-system/aeraki-managed.example.com", "istio-system/aeraki-unlabeled.example.com"},
+		},
+		{
+			name:     "no match",
+			selector: "aeraki.io/managed=yes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetServiceEntrySelector(tt.selector); err != nil {
+				t.Fatalf("SetServiceEntrySelector() unexpected error: %v", err)
+			}
+			result, err := c.GenerateAll(services)
+			if err != nil {
+				t.Fatalf("GenerateAll() unexpected error: %v", err)
+			}
+			var got []string
+			for _, wrapper := range result.EnvoyFilters {
+				got = append(got, wrapper.Namespace+"/"+wrapper.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GenerateAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetServiceEntrySelectorInvalid(t *testing.T) {
+	defer func() {
+		_ = SetServiceEntrySelector("")
+	}()
+	if err := SetServiceEntrySelector("aeraki.io/managed=true"); err != nil {
+		t.Fatalf("SetServiceEntrySelector() unexpected error: %v", err)
+	}
+	if err := SetServiceEntrySelector("aeraki.io/managed in (true"); err == nil {
+		t.Errorf("SetServiceEntrySelector() expected an error for an invalid selector")
+	}
+	if !isServiceEntrySelected(map[string]string{"aeraki.io/managed": "true"}) ||
+		isServiceEntrySelected(nil) {
+		t.Errorf("an invalid selector should not replace the current one")
+	}
+}
+
+func TestPostProcessEnvoyFilter(t *testing.T) {
+	tests := []struct {
+		name                 string
+		annotations          map[string]string
+		wantOutboundChain    string
+		wantInboundChain     string
+		wantInboundTransport string
+		wantMetadata         map[string]string
+		wantWarning          bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:              "outbound filter chain name",
+			annotations:       map[string]string{constants.OutboundFilterChainNameAnnotation: "thrift-outbound"},
+			wantOutboundChain: "thrift-outbound",
+		},
+		{
+			name:             "inbound filter chain name",
+			annotations:      map[string]string{constants.InboundFilterChainNameAnnotation: "thrift-inbound"},
+			wantInboundChain: "thrift-inbound",
+		},
+		{
+			name: "both filter chain names",
+			annotations: map[string]string{
+				constants.OutboundFilterChainNameAnnotation: "thrift-outbound",
+				constants.InboundFilterChainNameAnnotation:  "thrift-inbound",
+			},
+			wantOutboundChain: "thrift-outbound",
+			wantInboundChain:  "thrift-inbound",
+		},
+		{
+			name:        "auto inbound transport",
+			annotations: map[string]string{constants.InboundTransportAnnotation: "auto"},
+		},
+		{
+			name:                 "mtls inbound transport",
+			annotations:          map[string]string{constants.InboundTransportAnnotation: "mtls"},
+			wantInboundTransport: "tls",
+		},
+		{
+			name:                 "plaintext inbound transport",
+			annotations:          map[string]string{constants.InboundTransportAnnotation: "plaintext"},
+			wantInboundTransport: "raw_buffer",
+		},
+		{
+			name:        "invalid inbound transport",
+			annotations: map[string]string{constants.InboundTransportAnnotation: "tls"},
+			wantWarning: true,
+		},
+		{
+			name: "proxy metadata",
+			// the proxy metadata is also matched by the cluster patches
+			annotations: map[string]string{
+				constants.ProxyMetadataMatchAnnotation:  "CLUSTER_ID=cluster-1",
+				constants.UpstreamIdleTimeoutAnnotation: "1m",
+			},
+			wantMetadata: map[string]string{"CLUSTER_ID": "cluster-1"},
+		},
+		{
+			name: "multiple proxy metadata keys",
+			annotations: map[string]string{
+				constants.ProxyMetadataMatchAnnotation: "CLUSTER_ID=cluster-1, NETWORK=network-1",
+			},
+			wantMetadata: map[string]string{"CLUSTER_ID": "cluster-1", "NETWORK": "network-1"},
+		},
+		{
+			name:        "invalid proxy metadata",
+			annotations: map[string]string{constants.ProxyMetadataMatchAnnotation: "cluster-1"},
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = []string{"10.0.0.1"}
+			service.Spec.WorkloadSelector = &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift"}}
+			service.Annotations = tt.annotations
+			result := generateThrift(service)
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+
+			var outbound, inbound int
+			for _, wrapper := range result.EnvoyFilters {
+				if err := postProcessEnvoyFilter(wrapper, service); err != nil {
+					t.Fatalf("postProcessEnvoyFilter() unexpected error: %v", err)
+				}
+				for _, patch := range wrapper.Envoyfilter.ConfigPatches {
+					if got := patch.Match.GetProxy().GetMetadata(); !reflect.DeepEqual(got, tt.wantMetadata) {
+						t.Errorf("proxy metadata of the %v patch = %v, want %v", patch.Match.Context, got,
+							tt.wantMetadata)
+					}
+					filterChain := patch.Match.GetListener().GetFilterChain()
+					if filterChain == nil {
+						continue
+					}
+					// the outbound patches are never restricted to a transport protocol
+					wantName, wantTransport := tt.wantOutboundChain, ""
+					if patch.Match.Context == networking.EnvoyFilter_SIDECAR_INBOUND {
+						wantName, wantTransport = tt.wantInboundChain, tt.wantInboundTransport
+						inbound++
+					} else {
+						outbound++
+					}
+					if filterChain.Name != wantName {
+						t.Errorf("filter chain name of the %v patch = %q, want %q", patch.Match.Context,
+							filterChain.Name, wantName)
+					}
+					if filterChain.TransportProtocol != wantTransport {
+						t.Errorf("transport protocol of the %v patch = %q, want %q", patch.Match.Context,
+							filterChain.TransportProtocol, wantTransport)
+					}
+				}
+			}
+			if outbound == 0 || inbound == 0 {
+				t.Errorf("got %d outbound and %d inbound filter chain patches, want both", outbound, inbound)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// upstreamTLSMode returns the TLS mode of the upstream connections set by the annotation of the service, only
+// ISTIO_MUTUAL and DISABLE are supported. An invalid mode is ignored.
+func upstreamTLSMode(service *model.ServiceEntryWrapper) (networking.ClientTLSSettings_TLSmode, bool) {
+	value, ok := service.Annotations[constants.UpstreamTLSModeAnnotation]
+	if !ok {
+		return networking.ClientTLSSettings_DISABLE, false
+	}
+	switch value {
+	case networking.ClientTLSSettings_ISTIO_MUTUAL.String():
+		return networking.ClientTLSSettings_ISTIO_MUTUAL, true
+	case networking.ClientTLSSettings_DISABLE.String():
+		return networking.ClientTLSSettings_DISABLE, true
+	}
+	generatorLog.Errorf("invalid %s annotation of service %s: %s, only ISTIO_MUTUAL and DISABLE are supported",
+		constants.UpstreamTLSModeAnnotation, service.Name, value)
+	return networking.ClientTLSSettings_DISABLE, false
+}
+
+// buildTLSDestinationRule builds the DestinationRule managed by Aeraki carrying the upstream TLS settings of a
+// service. Nil is returned if the service doesn't specify an upstream TLS mode.
+func buildTLSDestinationRule(service *model.ServiceEntryWrapper) *model.DestinationRuleWrapper {
+	mode, ok := upstreamTLSMode(service)
+	if !ok {
+		return nil
+	}
+	return &model.DestinationRuleWrapper{
+		Meta: istioconfig.Meta{
+			Name:      fmt.Sprintf("aeraki-%s", service.Name),
+			Namespace: service.Namespace,
+			Labels: map[string]string{
+				"manager": constants.AerakiFieldManager,
+			},
+		},
+		Spec: &networking.DestinationRule{
+			Host: service.Spec.Hosts[0],
+			TrafficPolicy: &networking.TrafficPolicy{
+				// the other fields of the TLS settings are meaningless for ISTIO_MUTUAL and DISABLE
+				Tls: &networking.ClientTLSSettings{Mode: mode, Sni: upstreamTLSSNI(service, mode)},
+			},
+		},
+	}
+}
+
+// upstreamTLSSNI returns the SNI of the TLS connections to the upstream of a service, which is set by the annotation of
+// the service and defaults to the service host. There is no SNI if TLS is disabled.
+func upstreamTLSSNI(service *model.ServiceEntryWrapper, mode networking.ClientTLSSettings_TLSmode) string {
+	if mode == networking.ClientTLSSettings_DISABLE {
+		return ""
+	}
+	if sni := service.Annotations[constants.UpstreamTLSSNIAnnotation]; sni != "" {
+		return sni
+	}
+	return service.Spec.Hosts[0]
+}
+
+// generateDestinationRules generates the DestinationRules for the services which specify an upstream TLS mode
+func (c *Controller) generateDestinationRules() (map[string]*model.DestinationRuleWrapper, error) {
+	destinationRules := make(map[string]*model.DestinationRuleWrapper)
+	serviceEntries, err := c.configStore.List(collections.IstioNetworkingV1Alpha3Serviceentries.Resource().
+		GroupVersionKind(), "")
+	if err != nil {
+		return destinationRules, fmt.Errorf("failed to listconfigs: %v", err)
+	}
+
+	for i := range serviceEntries {
+		service, ok := serviceEntries[i].Spec.(*networking.ServiceEntry)
+		if !ok || len(service.Hosts) == 0 || !isServiceEntrySelected(serviceEntries[i].Labels) {
+			continue
+		}
+		wrapper := &model.ServiceEntryWrapper{
+			Meta: serviceEntries[i].Meta,
+			Spec: service,
+		}
+		if _, ok := upstreamTLSMode(wrapper); !ok {
+			continue
+		}
+		userDR, err := c.findUserDestinationRule(wrapper)
+		if err != nil {
+			return destinationRules, err
+		}
+		// Aeraki never modifies a DestinationRule it doesn't manage, and a second DestinationRule for the same host
+		// would conflict with the user-defined one. The conflict is reported as a warning of the EnvoyFilter
+		// generation of the service.
+		if userDR != nil {
+			continue
+		}
+		dr := buildTLSDestinationRule(wrapper)
+		destinationRules[envoyFilterMapKey(dr.Name, dr.Namespace)] = dr
+	}
+	return destinationRules, nil
+}
+
+// upstreamTLSConflictWarning returns a warning if the upstream TLS mode of a service can't be applied because the
+// service already has a DestinationRule which isn't managed by Aeraki
+func (c *Controller) upstreamTLSConflictWarning(service *model.ServiceEntryWrapper) (string, error) {
+	if _, ok := upstreamTLSMode(service); !ok {
+		return "", nil
+	}
+	userDR, err := c.findUserDestinationRule(service)
+	if err != nil || userDR == nil {
+		return "", err
+	}
+	return fmt.Sprintf("the %s annotation conflicts with DestinationRule %s/%s, the upstream TLS mode should be set "+
+		"in the DestinationRule", constants.UpstreamTLSModeAnnotation, userDR.Namespace, userDR.Name), nil
+}
+
+// isManagedByAeraki checks whether a DestinationRule is labeled with manager=aeraki
+func isManagedByAeraki(labels map[string]string) bool {
+	return labels["manager"] == constants.AerakiFieldManager
+}
+
+// findUserDestinationRule finds the DestinationRule of a service which isn't managed by Aeraki
+func (c *Controller) findUserDestinationRule(service *model.ServiceEntryWrapper) (*model.DestinationRuleWrapper,
+	error) {
+	drs, err := c.configStore.List(
+		collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind(), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %v", err)
+	}
+	for i := range drs {
+		dr, ok := drs[i].Spec.(*networking.DestinationRule)
+		if !ok || isManagedByAeraki(drs[i].Labels) {
+			continue
+		}
+		if model.IsFQDNEquals(dr.Host, drs[i].Namespace, service.Spec.Hosts[0], service.Namespace) {
+			return &model.DestinationRuleWrapper{
+				Meta: drs[i].Meta,
+				Spec: dr,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// pushDestinationRules2APIServer creates or updates the DestinationRules carrying the upstream TLS modes, and deletes
+// the DestinationRules created by Aeraki which are not needed any more. Only the DestinationRules labeled with
+// manager=aeraki are touched.
+func (c *Controller) pushDestinationRules2APIServer() error {
+	generatedDestinationRules, err := c.generateDestinationRules()
+	if err != nil {
+		return fmt.Errorf("failed to generate DestinationRule: %v", err)
+	}
+
+	existingDestinationRules, err := c.istioClientset.NetworkingV1alpha3().DestinationRules("").List(context.TODO(),
+		v1.ListOptions{
+			LabelSelector: "manager=" + constants.AerakiFieldManager,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list DestinationRules: %v", err)
+	}
+	for i := range existingDestinationRules.Items {
+		old := &existingDestinationRules.Items[i]
+		if _, ok := generatedDestinationRules[envoyFilterMapKey(old.Name, old.Namespace)]; !ok {
+			controllerLog.Infof("deleting DestinationRule: namespace: %s name: %s", old.Namespace, old.Name)
+			err = c.istioClientset.NetworkingV1alpha3().DestinationRules(old.Namespace).Delete(context.TODO(),
+				old.Name, v1.DeleteOptions{})
+		}
+	}
+
+	for _, dr := range generatedDestinationRules {
+		client := c.istioClientset.NetworkingV1alpha3().DestinationRules(dr.Namespace)
+		old, getErr := client.Get(context.TODO(), dr.Name, v1.GetOptions{})
+		switch {
+		case errors.IsNotFound(getErr):
+			controllerLog.Infof("creating DestinationRule: namespace: %s name: %s %v", dr.Namespace, dr.Name,
+				model.Struct2JSON(dr.Spec))
+			_, err = client.Create(context.TODO(), &v1alpha3.DestinationRule{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      dr.Name,
+					Namespace: dr.Namespace,
+					Labels:    dr.Labels,
+				},
+				Spec: *dr.Spec,
+			}, v1.CreateOptions{FieldManager: constants.AerakiFieldManager})
+		case getErr != nil:
+			err = getErr
+		case !isManagedByAeraki(old.Labels):
+			// a DestinationRule with the same name created by the user is never overwritten
+			controllerLog.Warnf("DestinationRule %s/%s isn't managed by Aeraki, skip updating it", dr.Namespace,
+				dr.Name)
+		case !proto.Equal(dr.Spec, &old.Spec):
+			controllerLog.Infof("updating DestinationRule: namespace: %s name: %s %v", dr.Namespace, dr.Name,
+				model.Struct2JSON(dr.Spec))
+			old.Spec = *dr.Spec
+			_, err = client.Update(context.TODO(), old, v1.UpdateOptions{FieldManager: constants.AerakiFieldManager})
+		}
+	}
+	return err
+}
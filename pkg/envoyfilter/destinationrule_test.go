@@ -0,0 +1,175 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+func TestBuildTLSDestinationRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		sni      string
+		wantName string
+		wantMode networking.ClientTLSSettings_TLSmode
+		wantSNI  string
+		wantNil  bool
+	}{
+		{
+			name:     "ISTIO_MUTUAL",
+			mode:     "ISTIO_MUTUAL",
+			wantName: "aeraki-thrift",
+			wantMode: networking.ClientTLSSettings_ISTIO_MUTUAL,
+			wantSNI:  "thrift.example.com",
+		},
+		{
+			name:     "ISTIO_MUTUAL with SNI override",
+			mode:     "ISTIO_MUTUAL",
+			sni:      "thrift.internal.example.com",
+			wantName: "aeraki-thrift",
+			wantMode: networking.ClientTLSSettings_ISTIO_MUTUAL,
+			wantSNI:  "thrift.internal.example.com",
+		},
+		{
+			name:     "SNI ignored when TLS is disabled",
+			mode:     "DISABLE",
+			sni:      "thrift.internal.example.com",
+			wantName: "aeraki-thrift",
+			wantMode: networking.ClientTLSSettings_DISABLE,
+		},
+		{
+			name:     "DISABLE",
+			mode:     "DISABLE",
+			wantName: "aeraki-thrift",
+			wantMode: networking.ClientTLSSettings_DISABLE,
+		},
+		{
+			name:    "not set",
+			wantNil: true,
+		},
+		{
+			name:    "unsupported mode",
+			mode:    "SIMPLE",
+			wantNil: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			if tt.mode != "" {
+				service.Annotations = map[string]string{constants.UpstreamTLSModeAnnotation: tt.mode}
+			}
+			if tt.sni != "" {
+				service.Annotations[constants.UpstreamTLSSNIAnnotation] = tt.sni
+			}
+
+			got := buildTLSDestinationRule(service)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("buildTLSDestinationRule() = %v, want nil", got)
+				}
+				return
+			}
+			if got.Name != tt.wantName || got.Namespace != "meta" {
+				t.Errorf("name = %s/%s, want meta/%s", got.Namespace, got.Name, tt.wantName)
+			}
+			if got.Spec.Host != "thrift.example.com" {
+				t.Errorf("host = %v, want thrift.example.com", got.Spec.Host)
+			}
+			if mode := got.Spec.TrafficPolicy.GetTls().GetMode(); mode != tt.wantMode {
+				t.Errorf("tls mode = %v, want %v", mode, tt.wantMode)
+			}
+			if sni := got.Spec.TrafficPolicy.GetTls().GetSni(); sni != tt.wantSNI {
+				t.Errorf("tls sni = %v, want %v", sni, tt.wantSNI)
+			}
+			if got.Labels["manager"] != constants.AerakiFieldManager {
+				t.Errorf("labels = %v, want the Aeraki manager label", got.Labels)
+			}
+		})
+	}
+}
+
+func TestGenerateDestinationRules(t *testing.T) {
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{},
+		protocol.Dubbo:  &stubGenerator{},
+	})
+	services := []*model.ServiceEntryWrapper{
+		testService("thrift", "thrift.example.com", "tcp-thrift"),
+		testService("dubbo", "dubbo.example.com", "tcp-dubbo"),
+	}
+	for _, service := range services {
+		service.Annotations = map[string]string{constants.UpstreamTLSModeAnnotation: "ISTIO_MUTUAL"}
+		if _, err := c.configStore.Create(istioconfig.Config{
+			Meta: istioconfig.Meta{
+				GroupVersionKind: collections.IstioNetworkingV1Alpha3Serviceentries.Resource().GroupVersionKind(),
+				Name:             service.Name,
+				Namespace:        service.Namespace,
+				Annotations:      service.Annotations,
+			},
+			Spec: service.Spec,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the user-defined DestinationRule of the dubbo service conflicts with the annotation
+	if _, err := c.configStore.Create(istioconfig.Config{
+		Meta: istioconfig.Meta{
+			GroupVersionKind: collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind(),
+			Name:             "dubbo",
+			Namespace:        "meta",
+		},
+		Spec: &networking.DestinationRule{
+			Host: "dubbo.example.com",
+			TrafficPolicy: &networking.TrafficPolicy{
+				Tls: &networking.ClientTLSSettings{Mode: networking.ClientTLSSettings_DISABLE},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	destinationRules, err := c.generateDestinationRules()
+	if err != nil {
+		t.Fatalf("generateDestinationRules() error = %v", err)
+	}
+	if len(destinationRules) != 1 {
+		t.Fatalf("generateDestinationRules() got %d DestinationRules, want 1", len(destinationRules))
+	}
+	if _, ok := destinationRules[envoyFilterMapKey("aeraki-thrift", "meta")]; !ok {
+		t.Errorf("generateDestinationRules() = %v, want the DestinationRule of the thrift service", destinationRules)
+	}
+
+	// the conflict is reported as a warning of the dubbo service
+	result, err := c.GenerateAll(services)
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	want := "service meta/dubbo: the upstreamTLSMode annotation conflicts with DestinationRule meta/dubbo, the " +
+		"upstream TLS mode should be set in the DestinationRule"
+	if !reflect.DeepEqual(result.Warnings, []string{want}) {
+		t.Errorf("GenerateAll() warnings = %v, want %v", result.Warnings, []string{want})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// drainOnClusterChange returns whether the upstream connections of a service are drained when the hosts of its
+// clusters change, so the long-lived protocol connections get rebalanced to the new hosts
+func drainOnClusterChange(service *model.ServiceEntryWrapper) (bool, error) {
+	value, ok := service.Annotations[constants.DrainOnClusterChangeAnnotation]
+	if !ok {
+		return false, nil
+	}
+	drain, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s, it should be a boolean",
+			constants.DrainOnClusterChangeAnnotation, value)
+	}
+	return drain, nil
+}
+
+// drainClusterPatch closes the upstream connections of all the subset clusters of a service port when the hosts of
+// the clusters change
+func drainClusterPatch(host string, port uint32) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_CLUSTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &networking.EnvoyFilter_ClusterMatch{
+					PortNumber: port,
+					Service:    host,
+				},
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"close_connections_on_host_set_change": {Kind: &types.Value_BoolValue{BoolValue: true}},
+				},
+			},
+		},
+	}
+}
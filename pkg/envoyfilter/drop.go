@@ -0,0 +1,110 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	rbacfilter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	rbacFilter = "envoy.filters.network.rbac"
+	rbacType   = "type.googleapis.com/envoy.extensions.filters.network.rbac.v3.RBAC"
+	dropPolicy = "drop-connections"
+)
+
+// dropPercentage returns the percentage of the connections dropped by the protocol listeners of a service set by its
+// annotation
+func dropPercentage(service *model.ServiceEntryWrapper) (uint64, bool, error) {
+	value, ok := service.Annotations[constants.DropPercentageAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	percentage, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || percentage == 0 || percentage > 100 {
+		return 0, false, fmt.Errorf("invalid %s annotation: %s, it should be an integer between 1 and 100",
+			constants.DropPercentageAnnotation, value)
+	}
+	return percentage, true, nil
+}
+
+// dropCondition builds the CEL expression `connection.id % 100u < percentage`. Envoy has no network filter which
+// injects faults, so the connections are denied by the RBAC filter, and the ids of the downstream connections are
+// sequential, which makes the given percentage of them match the expression.
+func dropCondition(percentage uint64) *expr.Expr {
+	call := func(function string, args ...*expr.Expr) *expr.Expr {
+		return &expr.Expr{ExprKind: &expr.Expr_CallExpr{CallExpr: &expr.Expr_Call{Function: function, Args: args}}}
+	}
+	constant := func(value uint64) *expr.Expr {
+		return &expr.Expr{ExprKind: &expr.Expr_ConstExpr{ConstExpr: &expr.Constant{
+			ConstantKind: &expr.Constant_Uint64Value{Uint64Value: value},
+		}}}
+	}
+	connectionID := &expr.Expr{ExprKind: &expr.Expr_SelectExpr{SelectExpr: &expr.Expr_Select{
+		Operand: &expr.Expr{ExprKind: &expr.Expr_IdentExpr{IdentExpr: &expr.Expr_Ident{Name: "connection"}}},
+		Field:   "id",
+	}}}
+	return call("_<_", call("_%_", connectionID, constant(100)), constant(percentage))
+}
+
+// dropPatch inserts a RBAC filter which denies the given percentage of the connections before the protocol filter of
+// a listener. The patch has to follow the one which puts the protocol filter into the filter chain, since the patches
+// are applied in order.
+func dropPatch(context networking.EnvoyFilter_PatchContext, listenerName string, port,
+	destinationPort uint32, filterName, statPrefix string,
+	percentage uint64) (*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	value, err := generateValue(&rbacfilter.RBAC{
+		StatPrefix: StatPrefix(statPrefix),
+		Rules: &rbac.RBAC{
+			Action: rbac.RBAC_DENY,
+			Policies: map[string]*rbac.Policy{
+				dropPolicy: {
+					Permissions: []*rbac.Permission{{Rule: &rbac.Permission_Any{Any: true}}},
+					Principals:  []*rbac.Principal{{Identifier: &rbac.Principal_Any{Any: true}}},
+					Condition:   dropCondition(percentage),
+				},
+			},
+		},
+	}, rbacFilter, rbacType)
+	if err != nil {
+		return nil, err
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: context,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+					DestinationPort: destinationPort,
+					Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+						Name: filterName,
+					},
+				}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     value,
+		},
+	}, nil
+}
@@ -0,0 +1,85 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"time"
+
+	istiomodel "istio.io/istio/pilot/pkg/model"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// now returns the current time, it's replaced in the tests to simulate the expiry of the EnvoyFilters
+var now = time.Now
+
+// envoyFilterExpiry returns when the EnvoyFilters generated for a service expire, which is set by its annotation. An
+// absolute time is used instead of a TTL counted from the creation of the ServiceEntry, so setting the annotation on
+// an existing ServiceEntry doesn't remove its EnvoyFilters right away.
+func envoyFilterExpiry(service *model.ServiceEntryWrapper) (time.Time, bool, error) {
+	value, ok := service.Annotations[constants.EnvoyFilterExpiresAtAnnotation]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	expiry, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s annotation: %s, it should be an RFC 3339 timestamp",
+			constants.EnvoyFilterExpiresAtAnnotation, value)
+	}
+	return expiry, true, nil
+}
+
+// envoyFiltersExpired checks whether the EnvoyFilters of a service have expired, the expired ones are not generated
+// so they're deleted by the next push. A push is scheduled at the expiry of the ones which haven't expired yet. An
+// invalid expiry is ignored.
+func (c *Controller) envoyFiltersExpired(service *model.ServiceEntryWrapper) bool {
+	expiry, ok, err := envoyFilterExpiry(service)
+	if err != nil {
+		controllerLog.Warnf("service: %s/%s: %v", service.Namespace, service.Name, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	remaining := expiry.Sub(now())
+	if remaining <= 0 {
+		controllerLog.Infof("the EnvoyFilters of service %s/%s expired at %v", service.Namespace, service.Name,
+			expiry)
+		return true
+	}
+	c.scheduleExpiry(service.Namespace+"/"+service.Name, expiry, remaining)
+	return false
+}
+
+// scheduleExpiry triggers a push when the EnvoyFilters of a service expire, a push is scheduled only once for each
+// expiry of a service
+func (c *Controller) scheduleExpiry(key string, expiry time.Time, remaining time.Duration) {
+	c.expiriesLock.Lock()
+	defer c.expiriesLock.Unlock()
+	if scheduled, ok := c.expiries[key]; ok && scheduled.Equal(expiry) {
+		return
+	}
+	c.expiries[key] = expiry
+	time.AfterFunc(remaining, func() {
+		c.expiriesLock.Lock()
+		if c.expiries[key].Equal(expiry) {
+			delete(c.expiries, key)
+		}
+		c.expiriesLock.Unlock()
+		c.ConfigUpdated(istiomodel.EventUpdate)
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+func TestController_GenerateAllEnvoyFilterExpiry(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		created     time.Time
+		expiresAt   string
+		wantFilters int
+		wantExpiry  bool
+	}{
+		{
+			name:        "not set",
+			created:     start,
+			wantFilters: 1,
+		},
+		{
+			name:        "not expired",
+			created:     start,
+			expiresAt:   "2023-01-01T02:00:00Z",
+			wantFilters: 1,
+			wantExpiry:  true,
+		},
+		{
+			name:      "expired",
+			created:   start,
+			expiresAt: "2023-01-01T01:00:00Z",
+		},
+		{
+			// the expiry doesn't depend on the age of the ServiceEntry, so the annotation can be set on an old one
+			name:        "old service entry",
+			created:     start.Add(-365 * 24 * time.Hour),
+			expiresAt:   "2023-01-01T02:00:00Z",
+			wantFilters: 1,
+			wantExpiry:  true,
+		},
+		{
+			name:        "invalid",
+			created:     start,
+			expiresAt:   "2h",
+			wantFilters: 1,
+		},
+	}
+	defer func() { now = time.Now }()
+	now = func() time.Time { return start.Add(time.Hour) }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(map[protocol.Instance]Generator{protocol.Thrift: &stubGenerator{}})
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.CreationTimestamp = tt.created
+			if tt.expiresAt != "" {
+				service.Annotations = map[string]string{constants.EnvoyFilterExpiresAtAnnotation: tt.expiresAt}
+			}
+
+			result, err := c.GenerateAll([]*model.ServiceEntryWrapper{service})
+			if err != nil {
+				t.Fatalf("GenerateAll() unexpected error: %v", err)
+			}
+			if len(result.EnvoyFilters) != tt.wantFilters {
+				t.Errorf("GenerateAll() got %d EnvoyFilters, want %d", len(result.EnvoyFilters), tt.wantFilters)
+			}
+			if _, ok := c.expiries["meta/thrift"]; ok != tt.wantExpiry {
+				t.Errorf("expiry scheduled: %v, want %v", ok, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestController_GenerateAllEnvoyFilterExpiryPush(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry := start.Add(30 * time.Minute)
+	defer func() { now = time.Now }()
+	c := newTestController(map[protocol.Instance]Generator{protocol.Thrift: &stubGenerator{}})
+	service := testService("thrift", "thrift.example.com", "tcp-thrift")
+	service.CreationTimestamp = start.Add(-24 * time.Hour)
+	service.Annotations = map[string]string{constants.EnvoyFilterExpiresAtAnnotation: expiry.Format(time.RFC3339)}
+
+	now = func() time.Time { return start.Add(10 * time.Minute) }
+	result, err := c.GenerateAll([]*model.ServiceEntryWrapper{service})
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	if len(result.EnvoyFilters) != 1 {
+		t.Fatalf("GenerateAll() got %d EnvoyFilters before the expiry, want 1", len(result.EnvoyFilters))
+	}
+	if got := c.expiries["meta/thrift"]; !got.Equal(expiry) {
+		t.Errorf("scheduled expiry = %v, want %v", got, expiry)
+	}
+
+	// the EnvoyFilters are no longer generated once expired, so the push deletes the existing ones
+	now = func() time.Time { return expiry }
+	result, err = c.GenerateAll([]*model.ServiceEntryWrapper{service})
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	if len(result.EnvoyFilters) != 0 {
+		t.Errorf("GenerateAll() got %d EnvoyFilters after the expiry, want 0", len(result.EnvoyFilters))
+	}
+}
+
+func TestController_scheduleExpiry(t *testing.T) {
+	c := newTestController(nil)
+	expiry := time.Now().Add(10 * time.Millisecond)
+	c.scheduleExpiry("meta/thrift", expiry, 10*time.Millisecond)
+	// scheduling the same expiry again doesn't trigger another push
+	c.scheduleExpiry("meta/thrift", expiry, 10*time.Millisecond)
+
+	select {
+	case <-c.pushChannel:
+	case <-time.After(time.Second):
+		t.Fatalf("no push triggered by the expiry")
+	}
+	select {
+	case e := <-c.pushChannel:
+		t.Errorf("unexpected push: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.expiriesLock.Lock()
+	defer c.expiriesLock.Unlock()
+	if _, ok := c.expiries["meta/thrift"]; ok {
+		t.Errorf("the expiry is kept after the push")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// externalName returns the DNS name a service is an alias of, which is set by the annotation of a ServiceEntry built
+// for a Kubernetes Service of type ExternalName. The clients resolve the host of such a service to the external name
+// instead of a VIP, so Istio builds its outbound listener on the wildcard address rather than on a VIP.
+func externalName(service *model.ServiceEntryWrapper) (string, bool, error) {
+	value, ok := service.Annotations[constants.ExternalNameAnnotation]
+	if !ok {
+		return "", false, nil
+	}
+	name := strings.TrimSuffix(value, ".")
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", false, fmt.Errorf("invalid %s annotation: %s, it should be a DNS name: %s",
+			constants.ExternalNameAnnotation, value, strings.Join(errs, ", "))
+	}
+	return name, true, nil
+}
+
+// externalNameWarnings reports the external name annotation of a service which is invalid or ignored
+func externalNameWarnings(service *model.ServiceEntryWrapper, result *model.GenerationResult) {
+	_, ok, err := externalName(service)
+	if err != nil {
+		result.AddWarning("%v", err)
+		return
+	}
+	if ok && len(service.Spec.Addresses) > 0 {
+		result.AddWarning("%s annotation is ignored because the service has VIPs", constants.ExternalNameAnnotation)
+	}
+}
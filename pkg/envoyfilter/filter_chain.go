@@ -0,0 +1,89 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// destinationCIDRs parses the destination CIDRs of a service set by its annotation
+func destinationCIDRs(service *model.ServiceEntryWrapper) ([]*core.CidrRange, bool, error) {
+	value, ok := service.Annotations[constants.DestinationCIDRsAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	var ranges []*core.CidrRange
+	for _, cidr := range strings.Split(strings.ReplaceAll(value, " ", ""), ",") {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s annotation: %s, %s is not a CIDR",
+				constants.DestinationCIDRsAnnotation, value, cidr)
+		}
+		prefixLen, _ := ipNet.Mask.Size()
+		ranges = append(ranges, &core.CidrRange{
+			AddressPrefix: ipNet.IP.String(),
+			PrefixLen:     &wrappers.UInt32Value{Value: uint32(prefixLen)},
+		})
+	}
+	return ranges, true, nil
+}
+
+// destinationCIDRsFilterChainPatch generates a patch which adds the destination CIDRs of a service to the match of its
+// inbound filter chains. The EnvoyFilter match can't select the filter chains by destination CIDRs, so they're set in
+// the Envoy filter chain match instead, the connections to the other destinations fall through to the passthrough
+// filter chains and aren't handled by the protocol filter. Nil is returned if the service doesn't specify any CIDR.
+func destinationCIDRsFilterChainPatch(service *model.ServiceEntryWrapper,
+	port *networking.Port) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	ranges, ok, _ := destinationCIDRs(service)
+	if !ok {
+		return nil
+	}
+	value, err := StructValue(&listener.FilterChain{
+		FilterChainMatch: &listener.FilterChainMatch{
+			PrefixRanges: ranges,
+		},
+	})
+	if err != nil {
+		// This should not happen
+		generatorLog.Errorf("Failed to generate the destination CIDRs of the filter chain: %v", err)
+		return nil
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_FILTER_CHAIN,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_INBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch("virtualInbound", virtualInboundListenerPort,
+					&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						DestinationPort: InboundPort(service, port),
+					}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value:     value,
+		},
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// matchFilterChainName adds the filter chain names set by the annotations of a service to the filter chain matches of
+// an EnvoyFilter generated for it. The inbound patches are told apart by their SIDECAR_INBOUND context, all the other
+// patches are outbound. The listener patches which don't match a filter chain are left alone.
+func matchFilterChainName(wrapper *model.EnvoyFilterWrapper, service *model.ServiceEntryWrapper) {
+	if service == nil {
+		return
+	}
+	outbound := service.Annotations[constants.OutboundFilterChainNameAnnotation]
+	inbound := service.Annotations[constants.InboundFilterChainNameAnnotation]
+	if outbound == "" && inbound == "" {
+		return
+	}
+	for _, patch := range wrapper.Envoyfilter.ConfigPatches {
+		filterChain := patch.GetMatch().GetListener().GetFilterChain()
+		if filterChain == nil {
+			continue
+		}
+		name := outbound
+		if patch.Match.Context == networking.EnvoyFilter_SIDECAR_INBOUND {
+			name = inbound
+		}
+		if name != "" {
+			filterChain.Name = name
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+)
+
+func TestDestinationCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:  "ipv4 and ipv6",
+			cidrs: "10.0.0.0/8, 192.168.1.0/24,fd00::/8",
+			want:  []string{"10.0.0.0/8", "192.168.1.0/24", "fd00::/8"},
+		},
+		{
+			name:  "host bits",
+			cidrs: "10.1.2.3/16",
+			want:  []string{"10.1.0.0/16"},
+		},
+		{
+			name:    "address without prefix length",
+			cidrs:   "10.0.0.1",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			cidrs:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			if tt.want != nil || tt.wantErr {
+				service.Annotations = map[string]string{constants.DestinationCIDRsAnnotation: tt.cidrs}
+			}
+			ranges, ok, err := destinationCIDRs(service)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("destinationCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, cidr := range ranges {
+				got = append(got, fmt.Sprintf("%s/%d", cidr.AddressPrefix, cidr.PrefixLen.Value))
+			}
+			if ok != (tt.want != nil) || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("destinationCIDRs() = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterDestinationCIDRs(t *testing.T) {
+	service := testService("thrift", "thrift.example.com", "tcp-thrift")
+	service.Spec.Addresses = []string{"10.0.0.1"}
+	service.Spec.WorkloadSelector = &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift"}}
+	service.Annotations = map[string]string{constants.DestinationCIDRsAnnotation: "172.16.0.0/12"}
+
+	result := generateThrift(service)
+	if len(result.EnvoyFilters) != 2 {
+		t.Fatalf("GenerateReplaceNetworkFilter() got %d EnvoyFilters, want 2", len(result.EnvoyFilters))
+	}
+	if got := len(result.EnvoyFilters[0].Envoyfilter.ConfigPatches); got != 1 {
+		t.Errorf("outbound EnvoyFilter got %d patches, want 1", got)
+	}
+	inboundPatches := result.EnvoyFilters[1].Envoyfilter.ConfigPatches
+	if len(inboundPatches) != 2 {
+		t.Fatalf("inbound EnvoyFilter got %d patches, want the proxy and the filter chain patches",
+			len(inboundPatches))
+	}
+	patch := inboundPatches[1]
+	if patch.ApplyTo != networking.EnvoyFilter_FILTER_CHAIN ||
+		patch.Patch.Operation != networking.EnvoyFilter_Patch_MERGE ||
+		patch.Match.Context != networking.EnvoyFilter_SIDECAR_INBOUND {
+		t.Errorf("patch = %v, want a merge into the inbound filter chain", patch)
+	}
+	if port := patch.Match.GetListener().FilterChain.DestinationPort; port != 9090 {
+		t.Errorf("destination port = %v, want 9090", port)
+	}
+	prefixRanges := patch.Patch.Value.Fields["filterChainMatch"].GetStructValue().Fields["prefixRanges"].
+		GetListValue().Values
+	if len(prefixRanges) != 1 {
+		t.Fatalf("prefix ranges = %v, want 172.16.0.0/12", prefixRanges)
+	}
+	prefixRange := prefixRanges[0].GetStructValue().Fields
+	if prefixRange["addressPrefix"].GetStringValue() != "172.16.0.0" || prefixRange["prefixLen"].GetNumberValue() != 12 {
+		t.Errorf("prefix range = %v, want 172.16.0.0/12", prefixRange)
+	}
+}
+
+func TestGenerateReplaceNetworkFilterFilterChainMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		hosts         []string
+		annotations   map[string]string
+		wantContext   networking.EnvoyFilter_PatchContext
+		wantSNIs      []string
+		wantTransport string
+		// wantClusterHosts are the hosts of the cluster patches added by the upstream idle timeout, if it's set
+		wantClusterHosts []string
+		wantWarning      bool
+	}{
+		{
+			name:     "tls passthrough not set",
+			hosts:    []string{"thrift.example.com"},
+			wantSNIs: []string{""},
+		},
+		{
+			name:          "tls passthrough of a single host",
+			hosts:         []string{"thrift.example.com"},
+			annotations:   map[string]string{constants.TLSPassthroughAnnotation: "true"},
+			wantSNIs:      []string{"thrift.example.com"},
+			wantTransport: "tls",
+		},
+		{
+			name:          "tls passthrough of multiple hosts",
+			hosts:         []string{"thrift.example.com", "thrift.example.org"},
+			annotations:   map[string]string{constants.TLSPassthroughAnnotation: "true"},
+			wantSNIs:      []string{"thrift.example.com", "thrift.example.org"},
+			wantTransport: "tls",
+		},
+		{
+			name:        "tls passthrough disabled",
+			hosts:       []string{"thrift.example.com"},
+			annotations: map[string]string{constants.TLSPassthroughAnnotation: "false"},
+			wantSNIs:    []string{""},
+		},
+		{
+			name:        "invalid tls passthrough",
+			hosts:       []string{"thrift.example.com"},
+			annotations: map[string]string{constants.TLSPassthroughAnnotation: "tls"},
+			wantSNIs:    []string{""},
+			wantWarning: true,
+		},
+		{
+			name:  "outbound hosts not scoped",
+			hosts: []string{"a.example.com", "b.example.com"},
+			annotations: map[string]string{
+				constants.TLSPassthroughAnnotation:      "true",
+				constants.UpstreamIdleTimeoutAnnotation: "1m",
+			},
+			wantSNIs:         []string{"a.example.com", "b.example.com"},
+			wantTransport:    "tls",
+			wantClusterHosts: []string{"a.example.com"},
+		},
+		{
+			name:  "outbound hosts scoped to a single host",
+			hosts: []string{"a.example.com", "b.example.com"},
+			annotations: map[string]string{
+				constants.TLSPassthroughAnnotation:      "true",
+				constants.UpstreamIdleTimeoutAnnotation: "1m",
+				constants.OutboundHostsAnnotation:       "b.example.com",
+			},
+			wantContext:      networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			wantSNIs:         []string{"b.example.com"},
+			wantTransport:    "tls",
+			wantClusterHosts: []string{"b.example.com"},
+		},
+		{
+			name:  "outbound hosts scoped to all the hosts",
+			hosts: []string{"a.example.com", "b.example.com"},
+			annotations: map[string]string{
+				constants.TLSPassthroughAnnotation:      "true",
+				constants.UpstreamIdleTimeoutAnnotation: "1m",
+				constants.OutboundHostsAnnotation:       "a.example.com, b.example.com",
+			},
+			wantContext:      networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			wantSNIs:         []string{"a.example.com", "b.example.com"},
+			wantTransport:    "tls",
+			wantClusterHosts: []string{"a.example.com", "b.example.com"},
+		},
+		{
+			name:  "outbound host of another service",
+			hosts: []string{"a.example.com", "b.example.com"},
+			annotations: map[string]string{
+				constants.TLSPassthroughAnnotation:      "true",
+				constants.UpstreamIdleTimeoutAnnotation: "1m",
+				constants.OutboundHostsAnnotation:       "c.example.com",
+			},
+			wantSNIs:         []string{"a.example.com", "b.example.com"},
+			wantTransport:    "tls",
+			wantClusterHosts: []string{"a.example.com"},
+			wantWarning:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", tt.hosts[0], "tcp-thrift")
+			service.Spec.Hosts = tt.hosts
+			service.Spec.Addresses = []string{"10.0.0.1"}
+			service.Annotations = tt.annotations
+			result := generateThrift(service)
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+
+			var snis []string
+			for _, patch := range patchesOf(result, networking.EnvoyFilter_NETWORK_FILTER) {
+				filterChain := patch.Match.GetListener().FilterChain
+				if patch.Patch.Operation != networking.EnvoyFilter_Patch_REPLACE ||
+					filterChain.Filter.Name != wellknown.TCPProxy {
+					t.Errorf("patch = %v, want the tcp proxy replaced", patch)
+				}
+				if patch.Match.Context != tt.wantContext {
+					t.Errorf("context = %v, want %v", patch.Match.Context, tt.wantContext)
+				}
+				if filterChain.TransportProtocol != tt.wantTransport {
+					t.Errorf("transport protocol = %v, want %v", filterChain.TransportProtocol, tt.wantTransport)
+				}
+				snis = append(snis, filterChain.Sni)
+			}
+			if !reflect.DeepEqual(snis, tt.wantSNIs) {
+				t.Errorf("requested server names = %v, want %v", snis, tt.wantSNIs)
+			}
+			var clusterHosts []string
+			for _, patch := range patchesOf(result, networking.EnvoyFilter_CLUSTER) {
+				clusterHosts = append(clusterHosts, patch.Match.GetCluster().Service)
+			}
+			if !reflect.DeepEqual(clusterHosts, tt.wantClusterHosts) {
+				t.Errorf("cluster patch hosts = %v, want %v", clusterHosts, tt.wantClusterHosts)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// GatewayPatchContext returns the context the patches of the EnvoyFilters generated for a gateway match. The patches
+// match the gateway listeners by default, the annotation of the gateway can set it to another listener direction,
+// such as SIDECAR_OUTBOUND for a gateway which runs as a sidecar.
+func GatewayPatchContext(gateway *model.GatewayWrapper) (networking.EnvoyFilter_PatchContext, error) {
+	value, ok := gateway.Annotations[constants.GatewayPatchContextAnnotation]
+	if !ok {
+		return networking.EnvoyFilter_GATEWAY, nil
+	}
+	patchContext, ok := networking.EnvoyFilter_PatchContext_value[strings.ToUpper(value)]
+	if !ok {
+		return networking.EnvoyFilter_GATEWAY, fmt.Errorf("invalid %s annotation: %s, it should be one of GATEWAY, "+
+			"SIDECAR_OUTBOUND, SIDECAR_INBOUND or ANY", constants.GatewayPatchContextAnnotation, value)
+	}
+	return networking.EnvoyFilter_PatchContext(patchContext), nil
+}
+
+// MatchPatchContext sets the context of all the config patches of an EnvoyFilter
+func MatchPatchContext(wrapper *model.EnvoyFilterWrapper, patchContext networking.EnvoyFilter_PatchContext) {
+	for _, patch := range wrapper.Envoyfilter.ConfigPatches {
+		if patch.Match == nil {
+			patch.Match = &networking.EnvoyFilter_EnvoyConfigObjectMatch{}
+		}
+		patch.Match.Context = patchContext
+	}
+}
@@ -20,5 +20,7 @@ import (
 
 // Generator generates protocol specified envoyfilters
 type Generator interface {
-	Generate(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error)
+	// Generate generates the EnvoyFilters for a service or a gateway. The problems which don't fail the generation are
+	// reported as the warnings of the result.
+	Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error)
 }
@@ -0,0 +1,117 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// maxHeadlessEndpoints is the number of endpoints above which a warning is reported for a headless service, since the
+// size of its outbound EnvoyFilter grows with the endpoints, and every proxy receives all of them
+const maxHeadlessEndpoints = 100
+
+// headlessEndpoints checks whether the per-endpoint outbound listener patches are enabled for a service by its
+// annotation
+func headlessEndpoints(service *model.ServiceEntryWrapper) (bool, error) {
+	value, ok := service.Annotations[constants.HeadlessEndpointsAnnotation]
+	if !ok {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s, it should be a boolean",
+			constants.HeadlessEndpointsAnnotation, value)
+	}
+	return enabled, nil
+}
+
+// outboundListenerAddresses returns the addresses of the outbound listeners of a service. Istio builds an outbound
+// listener on each VIP of a service, and on each endpoint IP of a headless service, which has no VIP. The endpoint IPs
+// are only used if the per-endpoint patches are enabled for the service, the second return value tells whether they
+// are used. The listener of a service with an external name is matched by the host of the service instead, as it's
+// built on the wildcard address.
+func outboundListenerAddresses(service *model.ServiceEntryWrapper) ([]string, bool) {
+	if len(service.Spec.Addresses) > 0 {
+		return service.Spec.Addresses, false
+	}
+	if _, ok, _ := externalName(service); ok {
+		return []string{wildcardAddress}, false
+	}
+	if enabled, _ := headlessEndpoints(service); !enabled {
+		return nil, false
+	}
+	addresses := endpointIPs(service)
+	return addresses, len(addresses) > 0
+}
+
+// endpointIPs returns the distinct IPs of the endpoints of a service, the endpoints addressed by a host name or a unix
+// domain socket have no listener of their own, so they're skipped
+func endpointIPs(service *model.ServiceEntryWrapper) []string {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, endpoint := range service.Spec.Endpoints {
+		if net.ParseIP(endpoint.Address) == nil || seen[endpoint.Address] {
+			continue
+		}
+		seen[endpoint.Address] = true
+		addresses = append(addresses, endpoint.Address)
+	}
+	return addresses
+}
+
+// serviceOutboundEnvoyFilterName returns the name of the outbound EnvoyFilter of a service port. The name of a headless
+// service doesn't contain its endpoint IPs, so the EnvoyFilter is updated in place instead of being replaced when the
+// endpoints change.
+func serviceOutboundEnvoyFilterName(service *model.ServiceEntryWrapper, port int) string {
+	addresses, headless := outboundListenerAddresses(service)
+	if headless {
+		return fmt.Sprintf("aeraki-outbound-%s-headless-%d", setName(service.Spec.Hosts), port)
+	}
+	if _, ok, _ := externalName(service); ok && len(service.Spec.Addresses) == 0 {
+		return fmt.Sprintf("aeraki-outbound-%s-external-%d", setName(service.Spec.Hosts), port)
+	}
+	return outboundEnvoyFilterName(service.Spec.Hosts, addresses, port)
+}
+
+// headlessEndpointsWarnings reports the headless annotation of a service which is ignored or which may not scale
+func headlessEndpointsWarnings(service *model.ServiceEntryWrapper, result *model.GenerationResult) {
+	enabled, err := headlessEndpoints(service)
+	if err != nil {
+		result.AddWarning("%v", err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	if len(service.Spec.Addresses) > 0 {
+		result.AddWarning("%s annotation is ignored because the service has VIPs",
+			constants.HeadlessEndpointsAnnotation)
+		return
+	}
+	addresses := endpointIPs(service)
+	if skipped := len(service.Spec.Endpoints) - len(addresses); skipped > 0 {
+		result.AddWarning("%d endpoints without a distinct IP address are skipped in the outbound EnvoyFilter",
+			skipped)
+	}
+	if len(addresses) > maxHeadlessEndpoints {
+		result.AddWarning("the outbound EnvoyFilter patches %d endpoint listeners, more than %d endpoints may slow "+
+			"down the configuration distribution", len(addresses), maxHeadlessEndpoints)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// GenerateInsertBeforeHTTPFilter generates an EnvoyFilter that inserts a protocol specified HTTP filter before the
+// router filter of the HTTP connection manager, it's used by the protocols tunneled over HTTP, such as gRPC based
+// protocols.
+//
+// Istio creates a shared outbound listener for all the HTTP services listening on the same port, so the outbound
+// EnvoyFilter is named after the filter and the port rather than the service: the services sharing a port generate
+// the same EnvoyFilter, and the filter is inserted only once into the listener. The outbound EnvoyFilter is never
+// combined with the per-service inbound one for the same reason.
+func GenerateInsertBeforeHTTPFilter(service *model.ServiceEntryWrapper, port *networking.Port,
+	outboundFilter proto.Message, inboundFilter proto.Message, filterName string,
+	filterType string) *model.GenerationResult {
+	result := &model.GenerationResult{}
+
+	var outboundEnvoyFilters, inboundEnvoyFilters []*model.EnvoyFilterWrapper
+	if outboundFilter != nil {
+		outboundEnvoyFilters = generateOutboundHTTPFilterEnvoyFilters(port, outboundFilter, filterName, filterType)
+	}
+
+	workloadSelector := inboundEnvoyFilterWorkloadSelector(service)
+
+	// a workload selector should be set in an inbound envoy filter, so we won't override the inbound config of other
+	// services at the same port
+	if inboundFilter != nil && !inboundDisabled.Load() {
+		if hasInboundWorkloadSelector(workloadSelector) {
+			inboundEnvoyFilters = generateInboundHTTPFilterEnvoyFilters(service, port, inboundFilter, filterName,
+				filterType, workloadSelector)
+		} else {
+			addMissingWorkloadSelectorWarning(result, port)
+		}
+	}
+	result.EnvoyFilters = orderEnvoyFilters(outboundEnvoyFilters, inboundEnvoyFilters)
+	return result
+}
+
+func generateOutboundHTTPFilterEnvoyFilters(port *networking.Port, outboundFilter proto.Message, filterName string,
+	filterType string) []*model.EnvoyFilterWrapper {
+	var envoyFilters []*model.EnvoyFilterWrapper
+	outboundFilterStruct, err := generateValue(outboundFilter, filterName, filterType)
+	if err != nil {
+		// This should not happen
+		generatorLog.Errorf("Failed to generate outbound EnvoyFilter: %v", err)
+		return envoyFilters
+	}
+
+	// Istio creates a shared outbound listener for all the HTTP services listening on the same port
+	outboundListenerName := "0.0.0.0_" + strconv.Itoa(int(port.Number))
+	outboundFilterPatch := &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_HTTP_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(outboundListenerName, port.Number,
+					&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: wellknown.HTTPConnectionManager,
+							SubFilter: &networking.EnvoyFilter_ListenerMatch_SubFilterMatch{
+								Name: wellknown.Router,
+							},
+						},
+					}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     outboundFilterStruct,
+		},
+	}
+
+	envoyFilters = append(envoyFilters, &model.EnvoyFilterWrapper{
+		Name: outboundHTTPFilterEnvoyFilterName(filterName, int(port.Number)),
+		Envoyfilter: &networking.EnvoyFilter{
+			ConfigPatches: []*networking.EnvoyFilter_EnvoyConfigObjectPatch{outboundFilterPatch},
+		},
+	})
+	return envoyFilters
+}
+
+func generateInboundHTTPFilterEnvoyFilters(service *model.ServiceEntryWrapper, port *networking.Port,
+	inboundFilter proto.Message, filterName string, filterType string,
+	workloadSelector *networking.WorkloadSelector) []*model.EnvoyFilterWrapper {
+	var envoyFilters []*model.EnvoyFilterWrapper
+	inboundFilterStruct, err := generateValue(inboundFilter, filterName, filterType)
+	if err != nil {
+		// This should not happen
+		generatorLog.Errorf("Failed to generate inbound EnvoyFilter: %v", err)
+		return envoyFilters
+	}
+
+	inboundFilterPatch := &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_HTTP_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_INBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch("virtualInbound", virtualInboundListenerPort,
+					&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						DestinationPort: InboundPort(service, port),
+						Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: wellknown.HTTPConnectionManager,
+							SubFilter: &networking.EnvoyFilter_ListenerMatch_SubFilterMatch{
+								Name: wellknown.Router,
+							},
+						},
+					}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     inboundFilterStruct,
+		},
+	}
+
+	envoyFilters = append(envoyFilters, &model.EnvoyFilterWrapper{
+		Name: inboundHTTPFilterEnvoyFilterName(service.Spec.Hosts, int(port.Number)),
+		Envoyfilter: &networking.EnvoyFilter{
+			WorkloadSelector: workloadSelector,
+			ConfigPatches:    []*networking.EnvoyFilter_EnvoyConfigObjectPatch{inboundFilterPatch},
+		},
+	})
+	return envoyFilters
+}
+
+// outboundHTTPFilterEnvoyFilterName returns the name of the outbound EnvoyFilter shared by all the services at the
+// port, underscores in the filter name are not allowed in a Kubernetes resource name
+func outboundHTTPFilterEnvoyFilterName(filterName string, port int) string {
+	return fmt.Sprintf("aeraki-outbound-http-%s-%d", strings.ReplaceAll(filterName, "_", "-"), port)
+}
+
+func inboundHTTPFilterEnvoyFilterName(hosts []string, port int) string {
+	return fmt.Sprintf("aeraki-inbound-http-%s-%d", setName(hosts), port)
+}
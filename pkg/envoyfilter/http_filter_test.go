@@ -0,0 +1,154 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"testing"
+
+	grpcstats "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_stats/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerateInsertBeforeHTTPFilter(t *testing.T) {
+	const (
+		filterName = "envoy.filters.http.grpc_stats"
+		filterType = "type.googleapis.com/envoy.extensions.filters.http.grpc_stats.v3.FilterConfig"
+	)
+	service := &model.ServiceEntryWrapper{
+		Spec: &networking.ServiceEntry{
+			Hosts: []string{"grpc-sample-server.grpc.svc.cluster.local"},
+			Ports: []*networking.Port{
+				{
+					Number: 50051,
+					Name:   "grpc-sample",
+				},
+			},
+			WorkloadSelector: &networking.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "grpc-sample-server",
+				},
+			},
+		},
+	}
+	filter := &grpcstats.FilterConfig{EmitFilterState: true}
+
+	envoyFilters := GenerateInsertBeforeHTTPFilter(service, service.Spec.Ports[0], filter, filter, filterName,
+		filterType).EnvoyFilters
+	if len(envoyFilters) != 2 {
+		t.Fatalf("GenerateInsertBeforeHTTPFilter() got %d EnvoyFilters, want 2", len(envoyFilters))
+	}
+
+	tests := []struct {
+		name         string
+		envoyFilter  *model.EnvoyFilterWrapper
+		wantName     string
+		wantContext  networking.EnvoyFilter_PatchContext
+		wantListener string
+		wantPort     uint32
+	}{
+		{
+			name:         "outbound",
+			envoyFilter:  envoyFilters[0],
+			wantName:     "aeraki-outbound-http-envoy.filters.http.grpc-stats-50051",
+			wantContext:  networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			wantListener: "0.0.0.0_50051",
+		},
+		{
+			name:         "inbound",
+			envoyFilter:  envoyFilters[1],
+			wantName:     "aeraki-inbound-http-grpc-sample-server.grpc.svc.cluster.local-50051",
+			wantContext:  networking.EnvoyFilter_SIDECAR_INBOUND,
+			wantListener: "virtualInbound",
+			wantPort:     50051,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envoyFilter.Name != tt.wantName {
+				t.Errorf("name = %v, want %v", tt.envoyFilter.Name, tt.wantName)
+			}
+			patch := tt.envoyFilter.Envoyfilter.ConfigPatches[0]
+			if patch.ApplyTo != networking.EnvoyFilter_HTTP_FILTER {
+				t.Errorf("applyTo = %v, want %v", patch.ApplyTo, networking.EnvoyFilter_HTTP_FILTER)
+			}
+			if patch.Patch.Operation != networking.EnvoyFilter_Patch_INSERT_BEFORE {
+				t.Errorf("operation = %v, want %v", patch.Patch.Operation, networking.EnvoyFilter_Patch_INSERT_BEFORE)
+			}
+			if patch.Match.Context != tt.wantContext {
+				t.Errorf("context = %v, want %v", patch.Match.Context, tt.wantContext)
+			}
+
+			listener := patch.Match.GetListener()
+			if listener.Name != tt.wantListener {
+				t.Errorf("listener = %v, want %v", listener.Name, tt.wantListener)
+			}
+			if listener.FilterChain.DestinationPort != tt.wantPort {
+				t.Errorf("destination port = %v, want %v", listener.FilterChain.DestinationPort, tt.wantPort)
+			}
+			if listener.FilterChain.Filter.Name != wellknown.HTTPConnectionManager {
+				t.Errorf("filter = %v, want %v", listener.FilterChain.Filter.Name, wellknown.HTTPConnectionManager)
+			}
+			if listener.FilterChain.Filter.SubFilter.Name != wellknown.Router {
+				t.Errorf("sub filter = %v, want %v", listener.FilterChain.Filter.SubFilter.Name, wellknown.Router)
+			}
+
+			value := patch.Patch.Value
+			if got := value.Fields["name"].GetStringValue(); got != filterName {
+				t.Errorf("filter name = %v, want %v", got, filterName)
+			}
+			typedConfig := value.Fields["typed_config"].GetStructValue()
+			if got := typedConfig.Fields["type_url"].GetStringValue(); got != filterType {
+				t.Errorf("type_url = %v, want %v", got, filterType)
+			}
+		})
+	}
+
+	if envoyFilters[0].Envoyfilter.WorkloadSelector != nil {
+		t.Errorf("outbound EnvoyFilter should not have a workload selector")
+	}
+	if envoyFilters[1].Envoyfilter.WorkloadSelector.Labels["app"] != "grpc-sample-server" {
+		t.Errorf("inbound EnvoyFilter workload selector = %v", envoyFilters[1].Envoyfilter.WorkloadSelector)
+	}
+}
+
+func TestGenerateInsertBeforeHTTPFilterSharedPort(t *testing.T) {
+	filter := &grpcstats.FilterConfig{EmitFilterState: true}
+	port := &networking.Port{
+		Number: 50051,
+		Name:   "grpc-sample",
+	}
+	var names []string
+	for _, host := range []string{"grpc-foo.grpc.svc.cluster.local", "grpc-bar.grpc.svc.cluster.local"} {
+		service := &model.ServiceEntryWrapper{
+			Spec: &networking.ServiceEntry{
+				Hosts: []string{host},
+				Ports: []*networking.Port{port},
+			},
+		}
+		envoyFilters := GenerateInsertBeforeHTTPFilter(service, port, filter, nil, "envoy.filters.http.grpc_stats",
+			"type.googleapis.com/envoy.extensions.filters.http.grpc_stats.v3.FilterConfig").EnvoyFilters
+		if len(envoyFilters) != 1 {
+			t.Fatalf("GenerateInsertBeforeHTTPFilter() got %d EnvoyFilters, want 1", len(envoyFilters))
+		}
+		names = append(names, envoyFilters[0].Name)
+	}
+	// the services sharing the outbound listener should generate the same EnvoyFilter, so the filter is inserted once
+	if names[0] != names[1] {
+		t.Errorf("outbound EnvoyFilter names = %v, want the same name for the services at the same port", names)
+	}
+}
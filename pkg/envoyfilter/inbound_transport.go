@@ -0,0 +1,75 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	inboundTransportAuto      = "auto"
+	inboundTransportMTLS      = "mtls"
+	inboundTransportPlaintext = "plaintext"
+)
+
+// inboundTransportProtocols maps the values of the inbound transport annotation to the transport protocols of the
+// filter chains of the virtualInbound listener
+var inboundTransportProtocols = map[string]string{
+	inboundTransportAuto:      "",
+	inboundTransportMTLS:      "tls",
+	inboundTransportPlaintext: "raw_buffer",
+}
+
+// inboundTransportProtocol returns the transport protocol of the inbound filter chains patched for a service, an empty
+// string means the filter chains of all the transport protocols are patched
+func inboundTransportProtocol(service *model.ServiceEntryWrapper) (string, error) {
+	value, ok := service.Annotations[constants.InboundTransportAnnotation]
+	if !ok {
+		return "", nil
+	}
+	protocol, ok := inboundTransportProtocols[value]
+	if !ok {
+		return "", fmt.Errorf("invalid %s annotation: %s, it should be one of %s, %s and %s",
+			constants.InboundTransportAnnotation, value, inboundTransportMTLS, inboundTransportPlaintext,
+			inboundTransportAuto)
+	}
+	return protocol, nil
+}
+
+// matchInboundTransport adds the transport protocol set by the annotation of a service to the filter chain matches of
+// the inbound patches of an EnvoyFilter generated for it, so only the mTLS or the plaintext filter chains are patched
+// whatever mTLS mode the proxy is in.
+func matchInboundTransport(wrapper *model.EnvoyFilterWrapper, service *model.ServiceEntryWrapper) {
+	if service == nil {
+		return
+	}
+	protocol, err := inboundTransportProtocol(service)
+	if err != nil || protocol == "" {
+		return
+	}
+	for _, patch := range wrapper.Envoyfilter.ConfigPatches {
+		if patch.GetMatch().GetContext() != networking.EnvoyFilter_SIDECAR_INBOUND {
+			continue
+		}
+		if filterChain := patch.Match.GetListener().GetFilterChain(); filterChain != nil {
+			filterChain.TransportProtocol = protocol
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// extraLabels holds the labels added to all the generated EnvoyFilters, such as the revision tag the Istio control
+// plane selects its configuration by
+var extraLabels atomic.Pointer[map[string]string]
+
+// SetEnvoyFilterLabels sets the labels added to all the generated EnvoyFilters, as a comma separated list of
+// key=value pairs such as "istio.io/rev=canary". The manager label can't be overridden, Aeraki finds the EnvoyFilters
+// it manages by it.
+func SetEnvoyFilterLabels(value string) error {
+	if value == "" {
+		extraLabels.Store(nil)
+		return nil
+	}
+	parsed, err := labels.ConvertSelectorToLabelsMap(value)
+	if err != nil {
+		return fmt.Errorf("invalid EnvoyFilter labels %q: %v", value, err)
+	}
+	if _, ok := parsed["manager"]; ok {
+		return fmt.Errorf("invalid EnvoyFilter labels %q: the manager label is reserved", value)
+	}
+	set := map[string]string(parsed)
+	extraLabels.Store(&set)
+	return nil
+}
+
+// labelEnvoyFilter adds the labels set by SetEnvoyFilterLabels to a generated EnvoyFilter
+func labelEnvoyFilter(wrapper *model.EnvoyFilterWrapper) {
+	set := extraLabels.Load()
+	if set == nil {
+		return
+	}
+	if wrapper.Labels == nil {
+		wrapper.Labels = make(map[string]string, len(*set))
+	}
+	for key, value := range *set {
+		wrapper.Labels[key] = value
+	}
+}
+
+// envoyFilterLabels returns the labels of the EnvoyFilter CRD of a generated EnvoyFilter, the manager label always
+// points to Aeraki
+func envoyFilterLabels(wrapper *model.EnvoyFilterWrapper) map[string]string {
+	result := make(map[string]string, len(wrapper.Labels)+1)
+	for key, value := range wrapper.Labels {
+		result[key] = value
+	}
+	result["manager"] = constants.AerakiFieldManager
+	return result
+}
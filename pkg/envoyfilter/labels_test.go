@@ -0,0 +1,99 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+func TestSetEnvoyFilterLabels(t *testing.T) {
+	defer func() { _ = SetEnvoyFilterLabels("") }()
+	tests := []struct {
+		name    string
+		labels  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:   "revision tag",
+			labels: "istio.io/rev=canary",
+			want:   map[string]string{"istio.io/rev": "canary"},
+		},
+		{
+			name:   "multiple labels",
+			labels: "istio.io/rev=canary,team=payments",
+			want:   map[string]string{"istio.io/rev": "canary", "team": "payments"},
+		},
+		{
+			name:    "invalid value",
+			labels:  "istio.io/rev=canary!",
+			wantErr: true,
+		},
+		{
+			name:    "manager",
+			labels:  "manager=someone-else",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extraLabels.Store(nil)
+			if err := SetEnvoyFilterLabels(tt.labels); (err != nil) != tt.wantErr {
+				t.Fatalf("SetEnvoyFilterLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			wrapper := &model.EnvoyFilterWrapper{}
+			labelEnvoyFilter(wrapper)
+			if !reflect.DeepEqual(wrapper.Labels, tt.want) {
+				t.Errorf("labels = %v, want %v", wrapper.Labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_GenerateAllLabels(t *testing.T) {
+	defer func() { _ = SetEnvoyFilterLabels("") }()
+	if err := SetEnvoyFilterLabels("istio.io/rev=canary"); err != nil {
+		t.Fatalf("SetEnvoyFilterLabels() unexpected error: %v", err)
+	}
+	c := newTestController(map[protocol.Instance]Generator{
+		protocol.Thrift: &stubGenerator{},
+	})
+
+	result, err := c.GenerateAll([]*model.ServiceEntryWrapper{
+		testService("thrift", "thrift.example.com", "tcp-thrift"),
+	})
+	if err != nil {
+		t.Fatalf("GenerateAll() unexpected error: %v", err)
+	}
+	if len(result.EnvoyFilters) != 1 {
+		t.Fatalf("GenerateAll() got %d EnvoyFilters, want 1", len(result.EnvoyFilters))
+	}
+	wrapper := result.EnvoyFilters[0]
+	if want := map[string]string{"istio.io/rev": "canary"}; !reflect.DeepEqual(wrapper.Labels, want) {
+		t.Errorf("EnvoyFilter labels = %v, want %v", wrapper.Labels, want)
+	}
+	crd := c.toEnvoyFilterCRD(wrapper, nil)
+	want := map[string]string{"istio.io/rev": "canary", "manager": "Aeraki"}
+	if !reflect.DeepEqual(crd.Labels, want) {
+		t.Errorf("EnvoyFilter CRD labels = %v, want %v", crd.Labels, want)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// listenerBindConfig is the bind policy of the outbound listeners of a service
+type listenerBindConfig struct {
+	// BindToPort tells whether the listeners bind to their ports, the listeners which don't only receive the
+	// connections handed off by the virtualOutbound listener. Istio's setting is kept if it's not specified.
+	BindToPort *bool `json:"bindToPort"`
+	// AdditionalAddresses are the IPs the listeners listen on besides their own addresses, with the same ports
+	AdditionalAddresses []string `json:"additionalAddresses"`
+}
+
+// listenerBind returns the bind policy of the outbound listeners of a service set by the annotation of the service
+func listenerBind(service *model.ServiceEntryWrapper) (*listenerBindConfig, bool, error) {
+	value, ok := service.Annotations[constants.ListenerBindAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	config := &listenerBindConfig{}
+	if err := json.Unmarshal([]byte(value), config); err != nil {
+		return nil, false, fmt.Errorf("invalid %s annotation: %v", constants.ListenerBindAnnotation, err)
+	}
+	for _, address := range config.AdditionalAddresses {
+		if net.ParseIP(address) == nil {
+			return nil, false, fmt.Errorf("invalid %s annotation: %s, the additional addresses should be IPs",
+				constants.ListenerBindAnnotation, value)
+		}
+	}
+	if config.BindToPort == nil && len(config.AdditionalAddresses) == 0 {
+		return nil, false, nil
+	}
+	return config, true, nil
+}
+
+// listenerBindPatch generates a patch which sets the bind policy of an outbound listener. The additional addresses
+// aren't in the Listener API the control plane is built with, so the patch is built as a plain struct.
+func listenerBindPatch(listenerName string, port uint32,
+	config *listenerBindConfig) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	fields := make(map[string]*types.Value)
+	if config.BindToPort != nil {
+		fields["bind_to_port"] = &types.Value{Kind: &types.Value_BoolValue{BoolValue: *config.BindToPort}}
+	}
+	if len(config.AdditionalAddresses) > 0 {
+		addresses := make([]*types.Value, 0, len(config.AdditionalAddresses))
+		for _, address := range config.AdditionalAddresses {
+			addresses = append(addresses, structValue(map[string]*types.Value{
+				"address": structValue(map[string]*types.Value{
+					"socket_address": structValue(map[string]*types.Value{
+						"address":    {Kind: &types.Value_StringValue{StringValue: address}},
+						"port_value": {Kind: &types.Value_NumberValue{NumberValue: float64(port)}},
+					}),
+				}),
+			}))
+		}
+		fields["additional_addresses"] = &types.Value{
+			Kind: &types.Value_ListValue{ListValue: &types.ListValue{Values: addresses}},
+		}
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_LISTENER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, nil),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value:     &types.Struct{Fields: fields},
+		},
+	}
+}
+
+func structValue(fields map[string]*types.Value) *types.Value {
+	return &types.Value{Kind: &types.Value_StructValue{StructValue: &types.Struct{Fields: fields}}}
+}
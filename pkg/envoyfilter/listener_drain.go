@@ -0,0 +1,68 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// listenerDrainTypes maps the values of the listenerDrainType annotation to the drain types of the Envoy listener
+var listenerDrainTypes = map[string]string{
+	"default":     "DEFAULT",
+	"modify-only": "MODIFY_ONLY",
+}
+
+// listenerDrainType returns the drain type of the outbound listeners of a service set by the annotation of the
+// service
+func listenerDrainType(service *model.ServiceEntryWrapper) (string, bool, error) {
+	value, ok := service.Annotations[constants.ListenerDrainTypeAnnotation]
+	if !ok {
+		return "", false, nil
+	}
+	drainType, ok := listenerDrainTypes[value]
+	if !ok {
+		return "", false, fmt.Errorf("invalid %s annotation: %s, it should be default or modify-only",
+			constants.ListenerDrainTypeAnnotation, value)
+	}
+	return drainType, true, nil
+}
+
+// listenerDrainTypePatch generates a patch which sets the drain type of an outbound listener
+func listenerDrainTypePatch(listenerName string, port uint32,
+	drainType string) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_LISTENER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, nil),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"drain_type": {Kind: &types.Value_StringValue{StringValue: drainType}},
+				},
+			},
+		},
+	}
+}
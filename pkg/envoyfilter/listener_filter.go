@@ -0,0 +1,80 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	originaldst "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/original_dst/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/anypb"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// protocolPassthrough checks whether the protocol passthrough is enabled for a service by its annotation
+func protocolPassthrough(service *model.ServiceEntryWrapper) (bool, error) {
+	value, ok := service.Annotations[constants.ProtocolPassthroughAnnotation]
+	if !ok {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s, it should be a boolean",
+			constants.ProtocolPassthroughAnnotation, value)
+	}
+	return enabled, nil
+}
+
+// originalDstListenerPatch adds the original_dst listener filter to an outbound listener. The EnvoyFilter API of the
+// supported Istio versions can't patch the listener filters directly, so the filter is merged into the listener, the
+// merge appends it to the existing listener filters.
+func originalDstListenerPatch(listenerName string, port uint32) (*networking.EnvoyFilter_EnvoyConfigObjectPatch,
+	error) {
+	config, err := anypb.New(&originaldst.OriginalDst{})
+	if err != nil {
+		return nil, err
+	}
+	value, err := StructValue(&listener.Listener{
+		ListenerFilters: []*listener.ListenerFilter{
+			{
+				Name: wellknown.OriginalDestination,
+				ConfigType: &listener.ListenerFilter_TypedConfig{
+					TypedConfig: config,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_LISTENER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, nil),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value:     value,
+		},
+	}, nil
+}
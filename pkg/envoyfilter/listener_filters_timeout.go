@@ -0,0 +1,73 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// listenerFiltersTimeout returns the fields of the outbound listeners of a service which control the timeout of the
+// listener filters, set by the annotations of the service. No fields are returned if neither annotation is set.
+func listenerFiltersTimeout(service *model.ServiceEntryWrapper) (map[string]*types.Value, error) {
+	fields := make(map[string]*types.Value)
+	if value, ok := service.Annotations[constants.ListenerFiltersTimeoutAnnotation]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid %s annotation: %s, it should be a non-negative duration",
+				constants.ListenerFiltersTimeoutAnnotation, value)
+		}
+		fields["listener_filters_timeout"] = &types.Value{
+			Kind: &types.Value_StringValue{StringValue: DurationJSON(timeout)},
+		}
+	}
+	if value, ok := service.Annotations[constants.ContinueOnListenerFiltersTimeoutAnnotation]; ok {
+		continueOnTimeout, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %s, it should be a boolean",
+				constants.ContinueOnListenerFiltersTimeoutAnnotation, value)
+		}
+		fields["continue_on_listener_filters_timeout"] = &types.Value{
+			Kind: &types.Value_BoolValue{BoolValue: continueOnTimeout},
+		}
+	}
+	return fields, nil
+}
+
+// listenerFiltersTimeoutPatch generates a patch which sets the timeout of the listener filters of an outbound
+// listener
+func listenerFiltersTimeoutPatch(listenerName string, port uint32,
+	fields map[string]*types.Value) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_LISTENER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, nil),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value:     &types.Struct{Fields: fields},
+		},
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// ListenerNaming is the scheme of the names Istio gives to the outbound listeners of a service port, which differs
+// across the Istio versions
+type ListenerNaming string
+
+const (
+	// AddressPortNaming names an outbound listener by its bind address and port, such as 10.0.0.1_9090, as the Istio
+	// versions with a listener per VIP do
+	AddressPortNaming ListenerNaming = "address-port"
+	// WildcardPortNaming names an outbound listener by the wildcard address and its port, such as 0.0.0.0_9090, as
+	// the Istio versions sharing a single listener among all the services of a port do
+	WildcardPortNaming ListenerNaming = "wildcard-port"
+)
+
+// wildcardAddress is the bind address of the outbound listeners shared by all the services of a port
+const wildcardAddress = "0.0.0.0"
+
+// listenerNaming is the scheme of the outbound listener names, AddressPortNaming if it's not set
+var listenerNaming atomic.Value
+
+// listenerAdditionalAddresses tells whether Istio binds all the VIPs of a service port to a single outbound listener
+var listenerAdditionalAddresses atomic.Bool
+
+// SetListenerNaming sets the scheme of the names of the outbound listeners the generated EnvoyFilters match, it
+// should be the one of the Istio version in use, AddressPortNaming is used if the naming is empty
+func SetListenerNaming(naming string) error {
+	switch ListenerNaming(naming) {
+	case "":
+		listenerNaming.Store(AddressPortNaming)
+	case AddressPortNaming, WildcardPortNaming:
+		listenerNaming.Store(ListenerNaming(naming))
+	default:
+		return fmt.Errorf("invalid listener naming: %s, it should be %s or %s", naming, AddressPortNaming,
+			WildcardPortNaming)
+	}
+	return nil
+}
+
+// SetListenerAdditionalAddresses enables or disables matching the multi-address outbound listeners. When enabled, the
+// generated EnvoyFilters expect a single outbound listener for all the VIPs of a service port, which is named after
+// the first VIP and has the other ones as its additional addresses, as Istio builds with dual stack enabled.
+func SetListenerAdditionalAddresses(enabled bool) {
+	listenerAdditionalAddresses.Store(enabled)
+}
+
+// outboundListenerNames returns the distinct names of the outbound listeners Istio builds for the addresses of a
+// service port. The endpoint IPs of a headless service always have a listener of their own.
+func outboundListenerNames(addresses []string, headless bool, port uint32) []string {
+	if !headless && listenerAdditionalAddresses.Load() && len(addresses) > 0 {
+		addresses = addresses[:1]
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		// the addresses share a single listener if its name doesn't contain the address
+		name := outboundListenerName(address, port)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// outboundListenerName is the name of the outbound listener Istio builds for an address and a port of a service in
+// the configured naming scheme
+func outboundListenerName(address string, port uint32) string {
+	if naming, _ := listenerNaming.Load().(ListenerNaming); naming == WildcardPortNaming {
+		address = wildcardAddress
+	}
+	return address + "_" + strconv.Itoa(int(port))
+}
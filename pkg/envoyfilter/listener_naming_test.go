@@ -0,0 +1,434 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+)
+
+func TestSetListenerNaming(t *testing.T) {
+	defer func() { _ = SetListenerNaming("") }()
+	tests := []struct {
+		naming  string
+		want    ListenerNaming
+		wantErr bool
+	}{
+		{naming: "", want: AddressPortNaming},
+		{naming: "address-port", want: AddressPortNaming},
+		{naming: "wildcard-port", want: WildcardPortNaming},
+		{naming: "port", want: AddressPortNaming, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.naming, func(t *testing.T) {
+			listenerNaming.Store(AddressPortNaming)
+			if err := SetListenerNaming(tt.naming); (err != nil) != tt.wantErr {
+				t.Fatalf("SetListenerNaming() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := listenerNaming.Load(); got != tt.want {
+				t.Errorf("listener naming = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterListenerNaming(t *testing.T) {
+	defer func() { _ = SetListenerNaming("") }()
+	tests := []struct {
+		naming    ListenerNaming
+		wantNames []string
+	}{
+		{
+			naming:    AddressPortNaming,
+			wantNames: []string{"10.0.0.1_9090", "10.0.0.2_9090"},
+		},
+		{
+			naming:    WildcardPortNaming,
+			wantNames: []string{"0.0.0.0_9090"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.naming), func(t *testing.T) {
+			if err := SetListenerNaming(string(tt.naming)); err != nil {
+				t.Fatalf("SetListenerNaming() unexpected error: %v", err)
+			}
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = []string{"10.0.0.1", "10.0.0.2"}
+			var names []string
+			for _, patch := range patchesOf(generateThrift(service), networking.EnvoyFilter_NETWORK_FILTER) {
+				names = append(names, patch.Match.GetListener().Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("listener names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterListenerAdditionalAddresses(t *testing.T) {
+	defer SetListenerAdditionalAddresses(false)
+	tests := []struct {
+		name      string
+		enabled   bool
+		addresses []string
+		endpoints []string
+		wantNames []string
+	}{
+		{
+			name:      "a listener per address",
+			addresses: []string{"10.0.0.1", "fd00::1"},
+			wantNames: []string{"10.0.0.1_9090", "fd00::1_9090"},
+		},
+		{
+			name:      "multi-address listener",
+			enabled:   true,
+			addresses: []string{"10.0.0.1", "fd00::1"},
+			wantNames: []string{"10.0.0.1_9090"},
+		},
+		{
+			name:      "single address",
+			enabled:   true,
+			addresses: []string{"10.0.0.1"},
+			wantNames: []string{"10.0.0.1_9090"},
+		},
+		{
+			name:      "headless endpoints",
+			enabled:   true,
+			endpoints: []string{"10.1.0.1", "10.1.0.2"},
+			wantNames: []string{"10.1.0.1_9090", "10.1.0.2_9090"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetListenerAdditionalAddresses(tt.enabled)
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = tt.addresses
+			for _, address := range tt.endpoints {
+				service.Spec.Endpoints = append(service.Spec.Endpoints, &networking.WorkloadEntry{Address: address})
+				service.Annotations = map[string]string{constants.HeadlessEndpointsAnnotation: "true"}
+			}
+			var names []string
+			for _, patch := range patchesOf(generateThrift(service), networking.EnvoyFilter_NETWORK_FILTER) {
+				names = append(names, patch.Match.GetListener().Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("listener names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterOutboundListeners(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		addresses     []string
+		resolution    networking.ServiceEntry_Resolution
+		endpoints     []string
+		wantName      string
+		wantListeners []string
+		wantWarning   bool
+	}{
+		{
+			name:          "external name",
+			annotations:   map[string]string{constants.ExternalNameAnnotation: "dubbo.external.com"},
+			resolution:    networking.ServiceEntry_DNS,
+			endpoints:     []string{"dubbo.external.com"},
+			wantName:      "aeraki-outbound-dubbo.example.com-external-20880",
+			wantListeners: []string{"0.0.0.0_20880"},
+		},
+		{
+			name:          "fully qualified external name",
+			annotations:   map[string]string{constants.ExternalNameAnnotation: "dubbo.external.com."},
+			resolution:    networking.ServiceEntry_DNS,
+			endpoints:     []string{"dubbo.external.com"},
+			wantName:      "aeraki-outbound-dubbo.example.com-external-20880",
+			wantListeners: []string{"0.0.0.0_20880"},
+		},
+		{
+			name:       "external name not set",
+			resolution: networking.ServiceEntry_DNS,
+			endpoints:  []string{"dubbo.external.com"},
+		},
+		{
+			name:        "invalid external name",
+			annotations: map[string]string{constants.ExternalNameAnnotation: "dubbo_external"},
+			resolution:  networking.ServiceEntry_DNS,
+			endpoints:   []string{"dubbo.external.com"},
+			wantWarning: true,
+		},
+		{
+			name:          "external name of a service with VIP",
+			annotations:   map[string]string{constants.ExternalNameAnnotation: "dubbo.external.com"},
+			addresses:     []string{"10.0.0.1"},
+			resolution:    networking.ServiceEntry_DNS,
+			endpoints:     []string{"dubbo.external.com"},
+			wantName:      "aeraki-outbound-dubbo.example.com-10.0.0.1-20880",
+			wantListeners: []string{"10.0.0.1_20880"},
+			wantWarning:   true,
+		},
+		{
+			name:          "headless endpoints",
+			annotations:   map[string]string{constants.HeadlessEndpointsAnnotation: "true"},
+			endpoints:     []string{"10.244.0.11", "10.244.0.12", "10.244.0.13"},
+			wantName:      "aeraki-outbound-dubbo.example.com-headless-20880",
+			wantListeners: []string{"10.244.0.11_20880", "10.244.0.12_20880", "10.244.0.13_20880"},
+		},
+		{
+			name:          "duplicated and host name headless endpoints",
+			annotations:   map[string]string{constants.HeadlessEndpointsAnnotation: "true"},
+			endpoints:     []string{"10.244.0.11", "10.244.0.11", "dubbo-0.example.com"},
+			wantName:      "aeraki-outbound-dubbo.example.com-headless-20880",
+			wantListeners: []string{"10.244.0.11_20880"},
+			wantWarning:   true,
+		},
+		{
+			name:      "headless endpoints not enabled",
+			endpoints: []string{"10.244.0.11", "10.244.0.12", "10.244.0.13"},
+		},
+		{
+			name:        "invalid headless endpoints",
+			annotations: map[string]string{constants.HeadlessEndpointsAnnotation: "yes please"},
+			endpoints:   []string{"10.244.0.11", "10.244.0.12", "10.244.0.13"},
+			wantWarning: true,
+		},
+		{
+			name:          "headless endpoints of a service with VIP",
+			annotations:   map[string]string{constants.HeadlessEndpointsAnnotation: "true"},
+			addresses:     []string{"10.0.0.1"},
+			endpoints:     []string{"10.244.0.11", "10.244.0.12", "10.244.0.13"},
+			wantName:      "aeraki-outbound-dubbo.example.com-10.0.0.1-20880",
+			wantListeners: []string{"10.0.0.1_20880"},
+			wantWarning:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("dubbo", "dubbo.example.com", "tcp-dubbo")
+			service.Spec.Ports[0].Number = 20880
+			service.Spec.Addresses = tt.addresses
+			service.Spec.Resolution = tt.resolution
+			for _, address := range tt.endpoints {
+				service.Spec.Endpoints = append(service.Spec.Endpoints, &networking.WorkloadEntry{Address: address})
+			}
+			service.Annotations = tt.annotations
+			result := generateThrift(service)
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantName == "" {
+				if len(result.EnvoyFilters) != 0 {
+					t.Errorf("GenerateReplaceNetworkFilter() got %d EnvoyFilters, want none", len(result.EnvoyFilters))
+				}
+				return
+			}
+			if len(result.EnvoyFilters) != 1 {
+				t.Fatalf("GenerateReplaceNetworkFilter() got %d EnvoyFilters, want 1", len(result.EnvoyFilters))
+			}
+			if name := result.EnvoyFilters[0].Name; name != tt.wantName {
+				t.Errorf("EnvoyFilter name = %v, want %v", name, tt.wantName)
+			}
+			var listeners []string
+			for _, patch := range patchesOf(result, networking.EnvoyFilter_NETWORK_FILTER) {
+				listeners = append(listeners, patch.Match.GetListener().Name)
+			}
+			if !reflect.DeepEqual(listeners, tt.wantListeners) {
+				t.Errorf("patched listeners = %v, want %v", listeners, tt.wantListeners)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterListenerPatch(t *testing.T) {
+	type value = map[string]interface{}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		// want is the value of the patch merged into the outbound listener, no patch is expected if it's nil
+		want        value
+		wantWarning bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:        "exact connection balance",
+			annotations: map[string]string{constants.ExactConnectionBalanceAnnotation: "true"},
+			want:        value{"connection_balance_config": value{"exact_balance": value{}}},
+		},
+		{
+			name:        "no exact connection balance",
+			annotations: map[string]string{constants.ExactConnectionBalanceAnnotation: "false"},
+		},
+		{
+			name:        "invalid exact connection balance",
+			annotations: map[string]string{constants.ExactConnectionBalanceAnnotation: "exact"},
+			wantWarning: true,
+		},
+		{
+			name:        "downstream buffer limit",
+			annotations: map[string]string{constants.DownstreamBufferLimitAnnotation: "65536"},
+			want:        value{"per_connection_buffer_limit_bytes": float64(65536)},
+		},
+		{
+			name:        "zero downstream buffer limit",
+			annotations: map[string]string{constants.DownstreamBufferLimitAnnotation: "0"},
+			wantWarning: true,
+		},
+		{
+			name:        "too large downstream buffer limit",
+			annotations: map[string]string{constants.DownstreamBufferLimitAnnotation: "4294967296"},
+			wantWarning: true,
+		},
+		{
+			name:        "invalid downstream buffer limit",
+			annotations: map[string]string{constants.DownstreamBufferLimitAnnotation: "64Ki"},
+			wantWarning: true,
+		},
+		{
+			name:        "bind to port",
+			annotations: map[string]string{constants.ListenerBindAnnotation: `{"bindToPort": false}`},
+			want:        value{"bind_to_port": false},
+		},
+		{
+			name:        "additional addresses",
+			annotations: map[string]string{constants.ListenerBindAnnotation: `{"additionalAddresses": ["10.0.0.2"]}`},
+			want: value{"additional_addresses": []interface{}{value{"address": value{
+				"socket_address": value{"address": "10.0.0.2", "port_value": float64(9090)},
+			}}}},
+		},
+		{
+			name:        "empty listener bind",
+			annotations: map[string]string{constants.ListenerBindAnnotation: `{}`},
+		},
+		{
+			name:        "invalid additional address",
+			annotations: map[string]string{constants.ListenerBindAnnotation: `{"additionalAddresses": ["a.b"]}`},
+			wantWarning: true,
+		},
+		{
+			name:        "modify only drain type",
+			annotations: map[string]string{constants.ListenerDrainTypeAnnotation: "modify-only"},
+			want:        value{"drain_type": "MODIFY_ONLY"},
+		},
+		{
+			name:        "default drain type",
+			annotations: map[string]string{constants.ListenerDrainTypeAnnotation: "default"},
+			want:        value{"drain_type": "DEFAULT"},
+		},
+		{
+			name:        "invalid drain type",
+			annotations: map[string]string{constants.ListenerDrainTypeAnnotation: "never"},
+			wantWarning: true,
+		},
+		{
+			name:        "listener filters timeout",
+			annotations: map[string]string{constants.ListenerFiltersTimeoutAnnotation: "1500ms"},
+			want:        value{"listener_filters_timeout": "1.5s"},
+		},
+		{
+			name:        "listener filters timeout disabled",
+			annotations: map[string]string{constants.ListenerFiltersTimeoutAnnotation: "0s"},
+			want:        value{"listener_filters_timeout": "0s"},
+		},
+		{
+			name: "continue on listener filters timeout",
+			annotations: map[string]string{
+				constants.ListenerFiltersTimeoutAnnotation:           "5s",
+				constants.ContinueOnListenerFiltersTimeoutAnnotation: "true",
+			},
+			want: value{"listener_filters_timeout": "5s", "continue_on_listener_filters_timeout": true},
+		},
+		{
+			name:        "continue on the default listener filters timeout",
+			annotations: map[string]string{constants.ContinueOnListenerFiltersTimeoutAnnotation: "true"},
+			want:        value{"continue_on_listener_filters_timeout": true},
+		},
+		{
+			name:        "negative listener filters timeout",
+			annotations: map[string]string{constants.ListenerFiltersTimeoutAnnotation: "-1s"},
+			wantWarning: true,
+		},
+		{
+			name:        "invalid listener filters timeout",
+			annotations: map[string]string{constants.ListenerFiltersTimeoutAnnotation: "5"},
+			wantWarning: true,
+		},
+		{
+			name: "invalid continue on listener filters timeout",
+			annotations: map[string]string{
+				constants.ListenerFiltersTimeoutAnnotation:           "5s",
+				constants.ContinueOnListenerFiltersTimeoutAnnotation: "yes please",
+			},
+			wantWarning: true,
+		},
+		{
+			name:        "protocol passthrough",
+			annotations: map[string]string{constants.ProtocolPassthroughAnnotation: "true"},
+			want: value{"listenerFilters": []interface{}{value{
+				"name": wellknown.OriginalDestination,
+				"typedConfig": value{
+					"@type": "type.googleapis.com/envoy.extensions.filters.listener.original_dst.v3.OriginalDst",
+				},
+			}}},
+		},
+		{
+			name:        "no protocol passthrough",
+			annotations: map[string]string{constants.ProtocolPassthroughAnnotation: "false"},
+		},
+		{
+			name:        "invalid protocol passthrough",
+			annotations: map[string]string{constants.ProtocolPassthroughAnnotation: "original"},
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = []string{"10.0.0.1"}
+			service.Annotations = tt.annotations
+			result := generateThrift(service)
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, wantWarning %v", result.Warnings, tt.wantWarning)
+			}
+
+			listenerPatches := patchesOf(result, networking.EnvoyFilter_LISTENER)
+			if tt.want == nil {
+				if len(listenerPatches) != 0 {
+					t.Errorf("unexpected listener patches: %v", listenerPatches)
+				}
+				return
+			}
+			if len(listenerPatches) != 1 {
+				t.Fatalf("got %d listener patches, want 1", len(listenerPatches))
+			}
+			patch := listenerPatches[0]
+			if name := patch.Match.GetListener().Name; name != "10.0.0.1_9090" {
+				t.Errorf("listener = %v, want the outbound listener 10.0.0.1_9090", name)
+			}
+			if patch.Patch.Operation != networking.EnvoyFilter_Patch_MERGE {
+				t.Errorf("operation = %v, want MERGE", patch.Patch.Operation)
+			}
+			if got := patchValue(t, patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listener patch value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
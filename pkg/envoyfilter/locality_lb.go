@@ -0,0 +1,68 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// localityWeightedLB returns whether the locality weighted load balancing is enabled for the upstream clusters of a
+// service
+func localityWeightedLB(service *model.ServiceEntryWrapper) (bool, error) {
+	value, ok := service.Annotations[constants.LocalityWeightedLBAnnotation]
+	if !ok {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s, it should be a boolean",
+			constants.LocalityWeightedLBAnnotation, value)
+	}
+	return enabled, nil
+}
+
+// localityWeightedLBClusterPatch enables the locality weighted load balancing of all the subset clusters of a service
+// port. Envoy picks a locality by the load balancing weights of the localities in EDS first, then an endpoint in it.
+func localityWeightedLBClusterPatch(host string, port uint32) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_CLUSTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &networking.EnvoyFilter_ClusterMatch{
+					PortNumber: port,
+					Service:    host,
+				},
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"common_lb_config": structValue(map[string]*types.Value{
+						"locality_weighted_lb_config": structValue(map[string]*types.Value{}),
+					}),
+				},
+			},
+		},
+	}
+}
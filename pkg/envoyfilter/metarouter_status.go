@@ -0,0 +1,95 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+	istiometa "istio.io/api/meta/v1alpha1"
+
+	metaprotocol "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+// EnvoyFiltersGeneratedCondition is the type of the MetaRouter status condition which records the names of the
+// EnvoyFilters generated for the service of the MetaRouter, the names are comma separated in the condition message
+const EnvoyFiltersGeneratedCondition = "EnvoyFiltersGenerated"
+
+// MetaProtocolEnvoyFilterNames returns the sorted names of the EnvoyFilters generated for the MetaProtocol ports of a
+// service, which are reported in the status of the MetaRouter of the service. The names are built by the same helpers
+// as the generated EnvoyFilters, under the same conditions, so they can be derived without generating the EnvoyFilters.
+func MetaProtocolEnvoyFilterNames(service *model.ServiceEntryWrapper) []string {
+	var names []string
+	for _, port := range service.Spec.Ports {
+		if !protocol.GetLayer7ProtocolFromPortName(port.Name).IsMetaProtocol() {
+			continue
+		}
+		addresses, _ := outboundListenerAddresses(service)
+		hasOutbound := len(addresses) > 0
+		hasInbound := !inboundDisabled.Load() &&
+			hasInboundWorkloadSelector(inboundEnvoyFilterWorkloadSelector(service))
+		if hasOutbound {
+			names = append(names, serviceOutboundEnvoyFilterName(service, int(port.Number)))
+		}
+		if hasInbound {
+			names = append(names, inboundEnvoyFilterName(service.Spec.Hosts, int(port.Number)))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// metaRouterStatus holds the names of the EnvoyFilters generated for the service of a MetaRouter
+type metaRouterStatus struct {
+	metaRouter *metaprotocol.MetaRouter
+	names      []string
+}
+
+// updateMetaRouterStatuses records the names of the generated EnvoyFilters in the statuses of the MetaRouters
+func (c *Controller) updateMetaRouterStatuses(statuses []*metaRouterStatus) {
+	for _, status := range statuses {
+		if err := c.updateMetaRouterStatus(status.metaRouter, status.names); err != nil {
+			controllerLog.Errorf("failed to update the status of MetaRouter %s/%s: %v", status.metaRouter.Namespace,
+				status.metaRouter.Name, err)
+		}
+	}
+}
+
+// updateMetaRouterStatus records the names of the generated EnvoyFilters in the status of a MetaRouter. The status is
+// only updated if the names change, a status update doesn't change the generation of the MetaRouter, so it doesn't
+// trigger another generation.
+func (c *Controller) updateMetaRouterStatus(metaRouter *metaprotocol.MetaRouter, names []string) error {
+	message := strings.Join(names, ",")
+	var conditions []*istiometa.IstioCondition
+	for _, condition := range metaRouter.Status.Conditions {
+		if condition.Type != EnvoyFiltersGeneratedCondition {
+			conditions = append(conditions, condition)
+		} else if condition.Message == message {
+			return nil
+		}
+	}
+	updated := metaRouter.DeepCopy()
+	updated.Status.Conditions = append(conditions, &istiometa.IstioCondition{
+		Type:               EnvoyFiltersGeneratedCondition,
+		Status:             "True",
+		LastTransitionTime: types.TimestampNow(),
+		Message:            message,
+	})
+	return c.MetaRouterControllerClient.Status().Update(context.TODO(), updated)
+}
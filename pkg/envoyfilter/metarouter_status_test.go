@@ -0,0 +1,158 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	thrift "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/thrift_proxy/v3"
+	networking "istio.io/api/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	userapi "github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	metaprotocol "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+// generateMetaProtocolPorts generates the EnvoyFilters of the MetaProtocol ports of a service the same way as the
+// MetaProtocol generator
+func generateMetaProtocolPorts(service *model.ServiceEntryWrapper) *model.GenerationResult {
+	result := &model.GenerationResult{}
+	for _, port := range service.Spec.Ports {
+		if !protocol.GetLayer7ProtocolFromPortName(port.Name).IsMetaProtocol() {
+			continue
+		}
+		result.Merge(GenerateReplaceNetworkFilter(service, port, &thrift.ThriftProxy{StatPrefix: "outbound"},
+			&thrift.ThriftProxy{StatPrefix: "inbound"}, "envoy.filters.network.meta_protocol_proxy",
+			"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy"))
+	}
+	return result
+}
+
+func TestMetaProtocolEnvoyFilterNames(t *testing.T) {
+	defer SetInboundDisabled(false)
+	selector := &networking.WorkloadSelector{Labels: map[string]string{"app": "sample-server"}}
+	tests := []struct {
+		name             string
+		addresses        []string
+		workloadSelector *networking.WorkloadSelector
+		inboundDisabled  bool
+		want             int
+	}{
+		{
+			name:             "outbound and inbound",
+			addresses:        []string{"10.0.0.1"},
+			workloadSelector: selector,
+			want:             4,
+		},
+		{
+			name:             "multiple VIPs",
+			addresses:        []string{"10.0.0.1", "10.0.0.2"},
+			workloadSelector: selector,
+			want:             4,
+		},
+		{
+			name:             "no VIP",
+			workloadSelector: selector,
+			want:             2,
+		},
+		{
+			name:      "no workload selector",
+			addresses: []string{"10.0.0.1"},
+			want:      2,
+		},
+		{
+			name:             "inbound disabled",
+			addresses:        []string{"10.0.0.1"},
+			workloadSelector: selector,
+			inboundDisabled:  true,
+			want:             2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetInboundDisabled(tt.inboundDisabled)
+			service := &model.ServiceEntryWrapper{
+				Spec: &networking.ServiceEntry{
+					Hosts:     []string{"sample-server.meta.svc.cluster.local"},
+					Addresses: tt.addresses,
+					Ports: []*networking.Port{
+						{Number: 9090, Name: "tcp-metaprotocol-thrift"},
+						{Number: 9091, Name: "tcp-metaprotocol-dubbo"},
+						{Number: 8080, Name: "http"},
+					},
+					WorkloadSelector: tt.workloadSelector,
+				},
+			}
+
+			var generated []string
+			for _, envoyFilter := range generateMetaProtocolPorts(service).EnvoyFilters {
+				generated = append(generated, envoyFilter.Name)
+			}
+			sort.Strings(generated)
+			got := MetaProtocolEnvoyFilterNames(service)
+			if !reflect.DeepEqual(got, generated) {
+				t.Errorf("MetaProtocolEnvoyFilterNames() = %v, generated %v", got, generated)
+			}
+			if len(got) != tt.want {
+				t.Errorf("MetaProtocolEnvoyFilterNames() got %d names, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateMetaRouterStatus(t *testing.T) {
+	c := newTestController(nil)
+	metaRouter := &metaprotocol.MetaRouter{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-server", Namespace: "meta"},
+		Spec:       userapi.MetaRouter{Hosts: []string{"sample-server.meta.svc.cluster.local"}},
+	}
+	if err := c.MetaRouterControllerClient.Create(context.TODO(), metaRouter); err != nil {
+		t.Fatalf("failed to create the MetaRouter: %v", err)
+	}
+	key := k8stypes.NamespacedName{Name: metaRouter.Name, Namespace: metaRouter.Namespace}
+
+	names := []string{
+		"aeraki-inbound-sample-server.meta.svc.cluster.local-9090",
+		"aeraki-outbound-sample-server.meta.svc.cluster.local-10.0.0.1-9090",
+	}
+	for i := 0; i < 2; i++ {
+		current := &metaprotocol.MetaRouter{}
+		if err := c.MetaRouterControllerClient.Get(context.TODO(), key, current); err != nil {
+			t.Fatalf("failed to get the MetaRouter: %v", err)
+		}
+		if err := c.updateMetaRouterStatus(current, names); err != nil {
+			t.Fatalf("updateMetaRouterStatus() unexpected error: %v", err)
+		}
+		updated := &metaprotocol.MetaRouter{}
+		if err := c.MetaRouterControllerClient.Get(context.TODO(), key, updated); err != nil {
+			t.Fatalf("failed to get the MetaRouter: %v", err)
+		}
+		// the status is left untouched if the names don't change
+		if i > 0 && updated.ResourceVersion != current.ResourceVersion {
+			t.Errorf("status updated with the same names")
+		}
+		conditions := updated.Status.Conditions
+		if len(conditions) != 1 || conditions[0].Type != EnvoyFiltersGeneratedCondition ||
+			conditions[0].Message != names[0]+","+names[1] {
+			t.Errorf("conditions = %v, want the generated EnvoyFilter names", conditions)
+		}
+	}
+}
@@ -17,8 +17,12 @@ package envoyfilter
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	gogojsonpb "github.com/gogo/protobuf/jsonpb"
@@ -27,16 +31,54 @@ import (
 	"google.golang.org/protobuf/proto"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/pkg/log"
+	"k8s.io/apimachinery/pkg/util/validation"
 
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
 )
 
 var generatorLog = log.RegisterScope("aeraki-generator", "aeraki generator", 0)
 
+// virtualInboundListenerPort is the port of the Istio virtualInbound listener
+const virtualInboundListenerPort = 15006
+
+// strictListenerMatch requires both the listener name and port to match in the generated EnvoyFilters
+var strictListenerMatch atomic.Bool
+
+// SetStrictListenerMatch enables or disables the strict listener match. When enabled, the generated EnvoyFilters
+// match the listeners by both name and port number, which protects them from the changes of the listener name format
+// across Istio versions.
+func SetStrictListenerMatch(strict bool) {
+	strictListenerMatch.Store(strict)
+}
+
+// inboundDisabled suppresses the generation of all the inbound EnvoyFilters
+var inboundDisabled atomic.Bool
+
+// SetInboundDisabled enables or disables the generation of the inbound EnvoyFilters. When disabled, only the outbound
+// traffic is handled by the protocol filters, which is useful when the backends handle their own protocol.
+func SetInboundDisabled(disabled bool) {
+	inboundDisabled.Store(disabled)
+}
+
+// listenerMatch builds the listener match, the port number is only set in strict mode
+func listenerMatch(name string, port uint32,
+	filterChain *networking.EnvoyFilter_ListenerMatch_FilterChainMatch) *networking.EnvoyFilter_ListenerMatch {
+	match := &networking.EnvoyFilter_ListenerMatch{
+		Name:        name,
+		FilterChain: filterChain,
+	}
+	if strictListenerMatch.Load() {
+		match.PortNumber = port
+	}
+	return match
+}
+
 // GenerateInsertBeforeNetworkFilter generates an EnvoyFilter that inserts a protocol specified filter before the tcp
 // proxy
 func GenerateInsertBeforeNetworkFilter(service *model.ServiceEntryWrapper, outboundProxy proto.Message,
-	inboundProxy proto.Message, filterName string, filterType string) []*model.EnvoyFilterWrapper {
+	inboundProxy proto.Message, filterName string, filterType string) *model.GenerationResult {
 	return generateNetworkFilter(service, service.Spec.Ports[0], outboundProxy, inboundProxy, filterName,
 		filterType,
 		networking.EnvoyFilter_Patch_INSERT_BEFORE)
@@ -46,7 +88,7 @@ func GenerateInsertBeforeNetworkFilter(service *model.ServiceEntryWrapper, outbo
 // proxy
 func GenerateReplaceNetworkFilter(service *model.ServiceEntryWrapper, port *networking.Port,
 	outboundProxy proto.Message,
-	inboundProxy proto.Message, filterName string, filterType string) []*model.EnvoyFilterWrapper {
+	inboundProxy proto.Message, filterName string, filterType string) *model.GenerationResult {
 	return generateNetworkFilter(service, port, outboundProxy, inboundProxy, filterName, filterType,
 		networking.EnvoyFilter_Patch_REPLACE)
 }
@@ -55,25 +97,48 @@ func GenerateReplaceNetworkFilter(service *model.ServiceEntryWrapper, port *netw
 // proxy
 func generateNetworkFilter(service *model.ServiceEntryWrapper, port *networking.Port, outboundProxy proto.Message,
 	inboundProxy proto.Message, filterName string, filterType string,
-	operation networking.EnvoyFilter_Patch_Operation) []*model.EnvoyFilterWrapper {
-	var envoyFilters []*model.EnvoyFilterWrapper
+	operation networking.EnvoyFilter_Patch_Operation) *model.GenerationResult {
+	result := &model.GenerationResult{}
+	annotationWarnings(service, result)
+	if _, ok := service.Annotations[constants.TCPWeightedClustersAnnotation]; ok &&
+		operation != networking.EnvoyFilter_Patch_INSERT_BEFORE {
+		result.AddWarning("%s annotation is ignored because the TcpProxy is replaced by %s",
+			constants.TCPWeightedClustersAnnotation, filterName)
+	}
+	// the MetaProtocol proxy has its own idle timeout, the other protocol proxies which replace the TcpProxy have none
+	if _, ok := service.Annotations[constants.DownstreamIdleTimeoutAnnotation]; ok &&
+		operation != networking.EnvoyFilter_Patch_INSERT_BEFORE &&
+		!protocol.GetLayer7ProtocolFromPortName(port.Name).IsMetaProtocol() {
+		result.AddWarning("%s annotation is ignored because the TcpProxy is replaced by %s",
+			constants.DownstreamIdleTimeoutAnnotation, filterName)
+	}
 
+	var outboundEnvoyFilters, inboundEnvoyFilters []*model.EnvoyFilterWrapper
 	if outboundProxy != nil {
-		envoyFilters = generateOutboundListenerEnvoyFilters(service, port, outboundProxy, filterName, filterType,
-			operation)
+		outboundEnvoyFilters = generateOutboundListenerEnvoyFilters(service, port, outboundProxy, filterName,
+			filterType, operation)
 	}
 
 	WorkloadSelector := inboundEnvoyFilterWorkloadSelector(service)
 
 	// a workload selector should be set in an inbound envoy filter, so we won't override the inbound config of other
 	// services at the same port
-	if inboundProxy != nil && hasInboundWorkloadSelector(WorkloadSelector) {
-		inboundEnvoyFilters := generateInboundListenerEnvoyFilters(service, port, inboundProxy, filterName, filterType,
-			operation,
-			WorkloadSelector)
-		envoyFilters = append(envoyFilters, inboundEnvoyFilters...)
+	if inboundProxy != nil && !inboundDisabled.Load() {
+		if hasInboundWorkloadSelector(WorkloadSelector) {
+			inboundEnvoyFilters = generateInboundListenerEnvoyFilters(service, port, inboundProxy, filterName,
+				filterType, operation, WorkloadSelector)
+		} else {
+			addMissingWorkloadSelectorWarning(result, port)
+		}
 	}
-	return envoyFilters
+	result.EnvoyFilters = orderEnvoyFilters(outboundEnvoyFilters, inboundEnvoyFilters)
+	return result
+}
+
+// addMissingWorkloadSelectorWarning reports the inbound EnvoyFilter skipped for a service without workload selector
+func addMissingWorkloadSelectorWarning(result *model.GenerationResult, port *networking.Port) {
+	result.AddWarning("the inbound EnvoyFilter for port %d is not generated because the service has no "+
+		"workload selector", port.Number)
 }
 
 func generateOutboundListenerEnvoyFilters(service *model.ServiceEntryWrapper, port *networking.Port,
@@ -87,37 +152,121 @@ func generateOutboundListenerEnvoyFilters(service *model.ServiceEntryWrapper, po
 		return envoyFilters
 	}
 
-	for i := 0; i < len(service.Spec.GetAddresses()); i++ {
-		outboundListenerName := service.Spec.GetAddresses()[i] + "_" + strconv.Itoa(int(port.
-			Number))
-		outboundProxyPatch := &networking.EnvoyFilter_EnvoyConfigObjectPatch{
-			ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
-			Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
-				ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
-					Listener: &networking.EnvoyFilter_ListenerMatch{
-						Name: outboundListenerName,
-						FilterChain: &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
-							Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
-								Name: wellknown.TCPProxy,
-							},
-						},
+	addresses, headless := outboundListenerAddresses(service)
+	if len(addresses) == 0 {
+		return envoyFilters
+	}
+	// the patches of all the VIPs, or the endpoint IPs of a headless service, are put into a single EnvoyFilter, so
+	// there's exactly one outbound EnvoyFilter per service port, and a change to a service port only touches its own
+	// EnvoyFilters
+	var configPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+	for _, listenerName := range outboundListenerNames(addresses, headless, port.Number) {
+		for _, filterChainMatch := range outboundFilterChainMatches(service) {
+			configPatches = append(configPatches, &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+				ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+				Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+					ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+						Listener: listenerMatch(listenerName, port.Number, filterChainMatch),
 					},
 				},
-			},
-			Patch: &networking.EnvoyFilter_Patch{
-				Operation: operation,
-				Value:     outboundProxyStruct,
-			},
+				Patch: &networking.EnvoyFilter_Patch{
+					Operation: operation,
+					Value:     outboundProxyStruct,
+				},
+			})
 		}
+		configPatches = append(configPatches, outboundListenerPatches(service, port, listenerName, filterName,
+			operation)...)
+	}
+	scopeOutboundPatches(service, configPatches)
+	// the clusters are shared by all the VIPs of the service, so they're patched only once
+	configPatches = append(configPatches, upstreamClusterPatches(service, port)...)
+	return append(envoyFilters, &model.EnvoyFilterWrapper{
+		Name: serviceOutboundEnvoyFilterName(service, int(port.Number)),
+		Envoyfilter: &networking.EnvoyFilter{
+			ConfigPatches: configPatches,
+		},
+	})
+}
 
-		envoyFilters = append(envoyFilters, &model.EnvoyFilterWrapper{
-			Name: outboundEnvoyFilterName(service.Spec.Hosts[0], service.Spec.Addresses[i], int(port.Number)),
-			Envoyfilter: &networking.EnvoyFilter{
-				ConfigPatches: []*networking.EnvoyFilter_EnvoyConfigObjectPatch{outboundProxyPatch},
-			},
-		})
+// outboundListenerPatches generates the patches of an outbound listener enabled by the annotations of a service
+func outboundListenerPatches(service *model.ServiceEntryWrapper, port *networking.Port,
+	listenerName, filterName string,
+	operation networking.EnvoyFilter_Patch_Operation) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	var configPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+	if limit, ok, _ := maxConnections(service); ok {
+		patch, err := connectionLimitPatch(networking.EnvoyFilter_ANY, listenerName, port.Number, 0,
+			filterName, listenerName, limit)
+		if err != nil {
+			// This should not happen
+			generatorLog.Errorf("Failed to generate the connection_limit filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
 	}
-	return envoyFilters
+	if percentage, ok, _ := dropPercentage(service); ok {
+		patch, err := dropPatch(networking.EnvoyFilter_ANY, listenerName, port.Number, 0, filterName,
+			listenerName, percentage)
+		if err != nil {
+			generatorLog.Errorf("Failed to generate the connection dropping filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	if config, ok, _ := wasmFilterOf(service); ok {
+		patch, err := wasmFilterPatch(networking.EnvoyFilter_ANY, listenerName, port.Number, 0, filterName,
+			config)
+		if err != nil {
+			generatorLog.Errorf("Failed to generate the wasm filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	if exactConnectionBalance(service) {
+		configPatches = append(configPatches, exactBalanceListenerPatch(listenerName, port.Number))
+	}
+	if bind, ok, _ := listenerBind(service); ok {
+		configPatches = append(configPatches, listenerBindPatch(listenerName, port.Number, bind))
+	}
+	if fields, _ := listenerFiltersTimeout(service); len(fields) > 0 {
+		configPatches = append(configPatches, listenerFiltersTimeoutPatch(listenerName, port.Number, fields))
+	}
+	if drainType, ok, _ := listenerDrainType(service); ok {
+		configPatches = append(configPatches, listenerDrainTypePatch(listenerName, port.Number, drainType))
+	}
+	if limit, ok, _ := downstreamBufferLimit(service); ok {
+		configPatches = append(configPatches, downstreamBufferLimitPatch(listenerName, port.Number, limit))
+	}
+	if passthrough, _ := protocolPassthrough(service); passthrough {
+		patch, err := originalDstListenerPatch(listenerName, port.Number)
+		if err != nil {
+			// This should not happen
+			generatorLog.Errorf("Failed to generate the original_dst listener filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	// the TcpProxy is only kept by the protocol filters inserted before it
+	if weights, ok, _ := tcpWeightedClusters(service); ok && operation == networking.EnvoyFilter_Patch_INSERT_BEFORE {
+		patch, err := tcpWeightedClustersPatch(service, port, listenerName, weights)
+		if err != nil {
+			// This should not happen
+			generatorLog.Errorf("Failed to generate TcpProxy weighted clusters: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	if timeout, ok := IdleTimeout(service, constants.DownstreamIdleTimeoutAnnotation); ok &&
+		operation == networking.EnvoyFilter_Patch_INSERT_BEFORE {
+		patch, err := tcpIdleTimeoutPatch(networking.EnvoyFilter_ANY, listenerName, port.Number, 0, timeout)
+		if err != nil {
+			// This should not happen
+			generatorLog.Errorf("Failed to generate TcpProxy idle timeout: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	return configPatches
 }
 
 func generateInboundListenerEnvoyFilters(service *model.ServiceEntryWrapper, port *networking.Port,
@@ -133,16 +282,15 @@ func generateInboundListenerEnvoyFilters(service *model.ServiceEntryWrapper, por
 		inboundProxyPatch := &networking.EnvoyFilter_EnvoyConfigObjectPatch{
 			ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
 			Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+				Context: networking.EnvoyFilter_SIDECAR_INBOUND,
 				ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
-					Listener: &networking.EnvoyFilter_ListenerMatch{
-						Name: "virtualInbound",
-						FilterChain: &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
-							DestinationPort: port.Number,
+					Listener: listenerMatch("virtualInbound", virtualInboundListenerPort,
+						&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+							DestinationPort: InboundPort(service, port),
 							Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
 								Name: wellknown.TCPProxy,
 							},
-						},
-					},
+						}),
 				},
 			},
 			Patch: &networking.EnvoyFilter_Patch{
@@ -151,17 +299,265 @@ func generateInboundListenerEnvoyFilters(service *model.ServiceEntryWrapper, por
 			},
 		}
 
+		configPatches := []*networking.EnvoyFilter_EnvoyConfigObjectPatch{inboundProxyPatch}
+		configPatches = append(configPatches, inboundListenerPatches(service, port, filterName)...)
+		// the TcpProxy is only kept by the protocol filters inserted before it
+		if timeout, ok := IdleTimeout(service, constants.DownstreamIdleTimeoutAnnotation); ok &&
+			operation == networking.EnvoyFilter_Patch_INSERT_BEFORE {
+			patch, err := tcpIdleTimeoutPatch(networking.EnvoyFilter_SIDECAR_INBOUND, "virtualInbound",
+				virtualInboundListenerPort, InboundPort(service, port), timeout)
+			if err != nil {
+				// This should not happen
+				generatorLog.Errorf("Failed to generate TcpProxy idle timeout: %v", err)
+			} else {
+				configPatches = append(configPatches, patch)
+			}
+		}
 		envoyFilters = append(envoyFilters, &model.EnvoyFilterWrapper{
-			Name: inboundEnvoyFilterName(service.Spec.Hosts[0], int(port.Number)),
+			Name: inboundEnvoyFilterName(service.Spec.Hosts, int(port.Number)),
 			Envoyfilter: &networking.EnvoyFilter{
 				WorkloadSelector: workloadSelector,
-				ConfigPatches:    []*networking.EnvoyFilter_EnvoyConfigObjectPatch{inboundProxyPatch},
+				ConfigPatches:    configPatches,
 			},
 		})
 	}
 	return envoyFilters
 }
 
+// inboundListenerPatches generates the patches of the inbound filter chain enabled by the annotations of a service
+func inboundListenerPatches(service *model.ServiceEntryWrapper, port *networking.Port,
+	filterName string) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	configPatches, err := principalsPatches(service, port, filterName)
+	if err != nil {
+		generatorLog.Errorf("Failed to generate the principals filters: %v", err)
+	}
+	if limit, ok, _ := maxConnections(service); ok {
+		patch, err := connectionLimitPatch(networking.EnvoyFilter_SIDECAR_INBOUND, "virtualInbound",
+			virtualInboundListenerPort, InboundPort(service, port), filterName,
+			fmt.Sprintf("inbound|%d", InboundPort(service, port)), limit)
+		if err != nil {
+			// This should not happen
+			generatorLog.Errorf("Failed to generate the connection_limit filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	if percentage, ok, _ := dropPercentage(service); ok {
+		patch, err := dropPatch(networking.EnvoyFilter_SIDECAR_INBOUND, "virtualInbound",
+			virtualInboundListenerPort, InboundPort(service, port), filterName,
+			fmt.Sprintf("inbound|%d", InboundPort(service, port)), percentage)
+		if err != nil {
+			generatorLog.Errorf("Failed to generate the connection dropping filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	if config, ok, _ := wasmFilterOf(service); ok {
+		patch, err := wasmFilterPatch(networking.EnvoyFilter_SIDECAR_INBOUND, "virtualInbound",
+			virtualInboundListenerPort, InboundPort(service, port), filterName, config)
+		if err != nil {
+			generatorLog.Errorf("Failed to generate the wasm filter: %v", err)
+		} else {
+			configPatches = append(configPatches, patch)
+		}
+	}
+	if patch := destinationCIDRsFilterChainPatch(service, port); patch != nil {
+		configPatches = append(configPatches, patch)
+	}
+	return configPatches
+}
+
+// InboundPort returns the port on which the workloads of a service receive the traffic of a service port, which is the
+// destination port of the inbound filter chain. The target port is used if it's set, otherwise the port of the
+// endpoints if they all agree on it. The service port is used when neither of them differs from it.
+func InboundPort(service *model.ServiceEntryWrapper, port *networking.Port) uint32 {
+	if port.TargetPort != 0 {
+		return port.TargetPort
+	}
+	var endpointPort uint32
+	for _, endpoint := range service.Spec.Endpoints {
+		number, ok := endpoint.Ports[port.Name]
+		if !ok {
+			number = port.Number
+		}
+		if endpointPort != 0 && endpointPort != number {
+			generatorLog.Warnf("the endpoints of service %s listen on different ports for port %s, the service "+
+				"port %d is used in the inbound EnvoyFilter", service.Name, port.Name, port.Number)
+			return port.Number
+		}
+		endpointPort = number
+	}
+	if endpointPort != 0 {
+		return endpointPort
+	}
+	return port.Number
+}
+
+// exactConnectionBalance checks whether the exact connection balance is enabled for a service
+func exactConnectionBalance(service *model.ServiceEntryWrapper) bool {
+	value, ok := service.Annotations[constants.ExactConnectionBalanceAnnotation]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
+
+// IdleTimeout returns the idle timeout set by an annotation of the service. An invalid timeout is ignored, it's
+// reported as a warning of the generation result.
+func IdleTimeout(service *model.ServiceEntryWrapper, annotation string) (time.Duration, bool) {
+	value, ok := service.Annotations[annotation]
+	if !ok {
+		return 0, false
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, false
+	}
+	return timeout, true
+}
+
+// annotationWarnings reports the invalid annotations of a service, which are ignored in the generated EnvoyFilters
+func annotationWarnings(service *model.ServiceEntryWrapper, result *model.GenerationResult) {
+	if value, ok := service.Annotations[constants.ExactConnectionBalanceAnnotation]; ok {
+		if _, err := strconv.ParseBool(value); err != nil {
+			result.AddWarning("invalid %s annotation: %s, it should be a boolean",
+				constants.ExactConnectionBalanceAnnotation, value)
+		}
+	}
+	if value, ok := service.Annotations[constants.PodNameAnnotation]; ok {
+		if _, valid := podNameSelector(service); !valid {
+			result.AddWarning("invalid %s annotation: %s, it should be the name of a single pod", constants.PodNameAnnotation,
+				value)
+		}
+	}
+	if _, _, err := tcpWeightedClusters(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := destinationCIDRs(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := protocolPassthrough(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := tlsPassthrough(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := proxyMetadataMatch(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := outboundHosts(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	listenerAnnotationWarnings(service, result)
+	clusterAnnotationWarnings(service, result)
+	headlessEndpointsWarnings(service, result)
+	externalNameWarnings(service, result)
+	for _, annotation := range []string{constants.DownstreamIdleTimeoutAnnotation,
+		constants.UpstreamIdleTimeoutAnnotation} {
+		if value, ok := service.Annotations[annotation]; ok {
+			if _, valid := IdleTimeout(service, annotation); !valid {
+				result.AddWarning("invalid %s annotation: %s, it should be a non-negative duration", annotation, value)
+			}
+		}
+	}
+}
+
+// listenerAnnotationWarnings reports the invalid annotations of a service which patch its listeners
+func listenerAnnotationWarnings(service *model.ServiceEntryWrapper, result *model.GenerationResult) {
+	if _, _, err := maxConnections(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := listenerBind(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := wasmFilterOf(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := dropPercentage(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := inboundTransportProtocol(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := listenerFiltersTimeout(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := listenerDrainType(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := downstreamBufferLimit(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	for _, annotation := range []string{constants.AllowedPrincipalsAnnotation, constants.DeniedPrincipalsAnnotation} {
+		if _, err := sourcePrincipals(service, annotation); err != nil {
+			result.AddWarning("%v", err)
+		}
+	}
+}
+
+// clusterAnnotationWarnings reports the invalid annotations of a service which patch its upstream clusters
+func clusterAnnotationWarnings(service *model.ServiceEntryWrapper, result *model.GenerationResult) {
+	if _, _, err := tcpKeepalive(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, _, err := upstreamConnectTimeout(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := drainOnClusterChange(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := upstreamProtocol(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+	if _, err := localityWeightedLB(service); err != nil {
+		result.AddWarning("%v", err)
+	}
+}
+
+// upstreamIdleTimeoutClusterPatch sets the idle timeout of the upstream TCP connection pools of all the subset
+// clusters of a service port
+func upstreamIdleTimeoutClusterPatch(host string, port uint32,
+	timeout time.Duration) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return protocolOptionsClusterPatch(host, port, tcpProtocolOptions, map[string]*types.Value{
+		"idle_timeout": {Kind: &types.Value_StringValue{StringValue: DurationJSON(timeout)}},
+	})
+}
+
+// DurationJSON formats a duration in the JSON representation of google.protobuf.Duration
+func DurationJSON(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// exactBalanceListenerPatch generates a patch which sets the exact connection balance on a listener. The patch is only
+// applied to the outbound listeners, because the virtualInbound listener is shared by all the inbound traffic.
+func exactBalanceListenerPatch(listenerName string, port uint32) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_LISTENER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, nil),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"connection_balance_config": {
+						Kind: &types.Value_StructValue{StructValue: &types.Struct{
+							Fields: map[string]*types.Value{
+								"exact_balance": {
+									Kind: &types.Value_StructValue{StructValue: &types.Struct{}},
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
 func hasInboundWorkloadSelector(selector *networking.WorkloadSelector) bool {
 	return len(selector.Labels) != 0
 }
@@ -173,8 +569,9 @@ func inboundEnvoyFilterWorkloadSelector(service *model.ServiceEntryWrapper) *net
 			Labels: make(map[string]string),
 		}
 	}
-	if len(selector.Labels) == 0 {
-		label := strings.ReplaceAll(service.Annotations["workloadSelector"], " ", "")
+	label := strings.ReplaceAll(service.Annotations["workloadSelector"], " ", "")
+	// the service has no workload selector if neither the spec nor the annotation specifies one
+	if len(selector.Labels) == 0 && label != "" {
 		labelSlice := strings.Split(label, ":")
 		if len(labelSlice) == 1 {
 			selector.Labels["app"] = label
@@ -184,22 +581,56 @@ func inboundEnvoyFilterWorkloadSelector(service *model.ServiceEntryWrapper) *net
 			log.Errorf("not support workloadselector")
 		}
 	}
+	if podName, ok := podNameSelector(service); ok {
+		// copy the labels so the workload selector of the service is left untouched
+		labels := make(map[string]string, len(selector.Labels)+1)
+		for key, value := range selector.Labels {
+			labels[key] = value
+		}
+		labels[constants.PodNameLabel] = podName
+		selector = &networking.WorkloadSelector{Labels: labels}
+	}
 	return selector
 }
 
-func outboundEnvoyFilterName(host, vip string, port int) string {
-	return fmt.Sprintf("aeraki-outbound-%s-%s-%d", host, vip, port)
+// podNameSelector returns the pod name set by the annotation of the service. Istio selects the workloads by exact
+// label values, so only a single pod name is supported instead of a pattern, and the pod needs to carry the
+// well-known pod name label, which Kubernetes only sets on the pods of a StatefulSet.
+func podNameSelector(service *model.ServiceEntryWrapper) (string, bool) {
+	podName, ok := service.Annotations[constants.PodNameAnnotation]
+	if !ok || podName == "" || len(validation.IsValidLabelValue(podName)) != 0 {
+		return "", false
+	}
+	return podName, true
 }
 
-func inboundEnvoyFilterName(host string, port int) string {
-	return fmt.Sprintf("aeraki-inbound-%s-%d", host, port)
+func outboundEnvoyFilterName(hosts []string, vips []string, port int) string {
+	return fmt.Sprintf("aeraki-outbound-%s-%s-%d", setName(hosts), setName(vips), port)
 }
 
-func generateValue(proxy proto.Message, filterName, filterType string) (*types.Struct, error) {
-	var buf []byte
-	var err error
+func inboundEnvoyFilterName(hosts []string, port int) string {
+	return fmt.Sprintf("aeraki-inbound-%s-%d", setName(hosts), port)
+}
 
-	if buf, err = protojson.Marshal(proxy); err != nil {
+// setName identifies a set of values, such as the hosts or the VIPs of a service, in the EnvoyFilter names. The value
+// is used as it is for a single-value set, a hash of the whole set is appended for a multi-value set, so the
+// ServiceEntries sharing the first host and the VIP but differing in the other hosts won't override each other's
+// EnvoyFilters.
+func setName(values []string) string {
+	if len(values) <= 1 {
+		return strings.Join(values, "")
+	}
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.Join(sorted, ",")))
+	return fmt.Sprintf("%s-%08x", values[0], hash.Sum32())
+}
+
+// StructValue converts an Envoy config message to the struct used as the value of an EnvoyFilter patch
+func StructValue(message proto.Message) (*types.Struct, error) {
+	buf, err := protojson.Marshal(message)
+	if err != nil {
 		return nil, err
 	}
 
@@ -207,6 +638,14 @@ func generateValue(proxy proto.Message, filterName, filterType string) (*types.S
 	if err := (&gogojsonpb.Unmarshaler{AllowUnknownFields: false}).Unmarshal(bytes.NewBuffer(buf), value); err != nil {
 		return nil, err
 	}
+	return value, nil
+}
+
+func generateValue(proxy proto.Message, filterName, filterType string) (*types.Struct, error) {
+	value, err := StructValue(proxy)
+	if err != nil {
+		return nil, err
+	}
 
 	var out = &types.Struct{}
 	out.Fields = map[string]*types.Value{}
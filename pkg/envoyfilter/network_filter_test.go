@@ -15,16 +15,74 @@
 package envoyfilter
 
 import (
+	"encoding/json"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
+	thrift "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/thrift_proxy/v3"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/protobuf/proto"
 	istioconfig "istio.io/istio/pkg/config"
 
 	networking "istio.io/api/networking/v1alpha3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
+const (
+	thriftFilterName = "envoy.filters.network.thrift_proxy"
+	thriftFilterType = "type.googleapis.com/envoy.extensions.filters.network.thrift_proxy.v3.ThriftProxy"
+)
+
+// generateThrift generates the thrift EnvoyFilters of all the ports of a service. The inbound EnvoyFilters are only
+// generated for a service with a workload selector, so the outbound only tests don't get the missing workload selector
+// warning.
+func generateThrift(service *model.ServiceEntryWrapper) *model.GenerationResult {
+	outboundProxy := &thrift.ThriftProxy{StatPrefix: "thrift"}
+	var inboundProxy proto.Message
+	if hasInboundWorkloadSelector(inboundEnvoyFilterWorkloadSelector(service)) {
+		inboundProxy = &thrift.ThriftProxy{StatPrefix: "thrift"}
+	}
+	result := &model.GenerationResult{}
+	for _, port := range service.Spec.Ports {
+		result.Merge(GenerateReplaceNetworkFilter(service, port, outboundProxy, inboundProxy, thriftFilterName,
+			thriftFilterType))
+	}
+	return result
+}
+
+// patchesOf collects the patches of the generated EnvoyFilters which apply to the given type of Envoy config
+func patchesOf(result *model.GenerationResult,
+	applyTo networking.EnvoyFilter_ApplyTo) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	var patches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+	for _, envoyFilter := range result.EnvoyFilters {
+		for _, patch := range envoyFilter.Envoyfilter.ConfigPatches {
+			if patch.ApplyTo == applyTo {
+				patches = append(patches, patch)
+			}
+		}
+	}
+	return patches
+}
+
+// patchValue converts the value of a patch to plain maps, so it can be compared with the expected one
+func patchValue(t *testing.T, patch *networking.EnvoyFilter_EnvoyConfigObjectPatch) map[string]interface{} {
+	t.Helper()
+	buf, err := (&jsonpb.Marshaler{}).MarshalToString(patch.Patch.Value)
+	if err != nil {
+		t.Fatalf("failed to marshal the patch value: %v", err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(buf), &value); err != nil {
+		t.Fatalf("failed to unmarshal the patch value: %v", err)
+	}
+	return value
+}
+
 func Test_inboudEnvoyFilterWorkloadSelector(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -80,6 +138,66 @@ func Test_inboudEnvoyFilterWorkloadSelector(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test4",
+			service: &model.ServiceEntryWrapper{
+				Spec: &networking.ServiceEntry{},
+			},
+			want: &networking.WorkloadSelector{
+				Labels: map[string]string{},
+			},
+		},
+		{
+			name: "pod name",
+			service: &model.ServiceEntryWrapper{
+				Meta: istioconfig.Meta{
+					Annotations: map[string]string{
+						constants.PodNameAnnotation: "dubbo-sample-provider-0",
+					},
+				},
+				Spec: &networking.ServiceEntry{},
+			},
+			want: &networking.WorkloadSelector{
+				Labels: map[string]string{
+					constants.PodNameLabel: "dubbo-sample-provider-0",
+				},
+			},
+		},
+		{
+			name: "pod name with workload selector",
+			service: &model.ServiceEntryWrapper{
+				Meta: istioconfig.Meta{
+					Annotations: map[string]string{
+						"workloadSelector":          "dubbo-sample-provider",
+						constants.PodNameAnnotation: "dubbo-sample-provider-0",
+					},
+				},
+				Spec: &networking.ServiceEntry{},
+			},
+			want: &networking.WorkloadSelector{
+				Labels: map[string]string{
+					"app":                  "dubbo-sample-provider",
+					constants.PodNameLabel: "dubbo-sample-provider-0",
+				},
+			},
+		},
+		{
+			name: "pod name pattern",
+			service: &model.ServiceEntryWrapper{
+				Meta: istioconfig.Meta{
+					Annotations: map[string]string{
+						"workloadSelector":          "dubbo-sample-provider",
+						constants.PodNameAnnotation: "dubbo-sample-provider-*",
+					},
+				},
+				Spec: &networking.ServiceEntry{},
+			},
+			want: &networking.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "dubbo-sample-provider",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -89,3 +207,719 @@ func Test_inboudEnvoyFilterWorkloadSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateReplaceNetworkFilterStrictListenerMatch(t *testing.T) {
+	service := &model.ServiceEntryWrapper{
+		Spec: &networking.ServiceEntry{
+			Hosts:     []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+			Addresses: []string{"10.0.0.1"},
+			Ports: []*networking.Port{
+				{
+					Number: 9090,
+					Name:   "tcp-thrift",
+				},
+			},
+			WorkloadSelector: &networking.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "thrift-sample-server",
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name          string
+		strict        bool
+		wantOutbound  uint32
+		wantInbound   uint32
+		wantListeners []string
+	}{
+		{
+			name:          "default",
+			wantListeners: []string{"10.0.0.1_9090", "virtualInbound"},
+		},
+		{
+			name:          "strict",
+			strict:        true,
+			wantOutbound:  9090,
+			wantInbound:   virtualInboundListenerPort,
+			wantListeners: []string{"10.0.0.1_9090", "virtualInbound"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetStrictListenerMatch(tt.strict)
+			defer SetStrictListenerMatch(false)
+
+			envoyFilters := generateThrift(service).EnvoyFilters
+			if len(envoyFilters) != 2 {
+				t.Fatalf("GenerateReplaceNetworkFilter() got %d EnvoyFilters, want 2", len(envoyFilters))
+			}
+			wantPorts := []uint32{tt.wantOutbound, tt.wantInbound}
+			for i, envoyFilter := range envoyFilters {
+				listener := envoyFilter.Envoyfilter.ConfigPatches[0].Match.GetListener()
+				if listener.Name != tt.wantListeners[i] {
+					t.Errorf("listener name = %v, want %v", listener.Name, tt.wantListeners[i])
+				}
+				if listener.PortNumber != wantPorts[i] {
+					t.Errorf("listener port = %v, want %v", listener.PortNumber, wantPorts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterMultipleHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+		want  string
+	}{
+		{
+			name:  "single host",
+			hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+			want:  "aeraki-outbound-thrift-sample-server.meta-thrift.svc.cluster.local-10.0.0.1-9090",
+		},
+		{
+			name:  "two hosts",
+			hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local", "thrift.example.com"},
+		},
+		{
+			name:  "two hosts sharing the first host",
+			hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local", "thrift.example.org"},
+		},
+	}
+	names := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Spec: &networking.ServiceEntry{
+					Hosts:     tt.hosts,
+					Addresses: []string{"10.0.0.1"},
+					Ports: []*networking.Port{
+						{
+							Number: 9090,
+							Name:   "tcp-thrift",
+						},
+					},
+				},
+			}
+			generate := func() string {
+				envoyFilters := generateThrift(service).EnvoyFilters
+				if len(envoyFilters) != 1 {
+					t.Fatalf("GenerateReplaceNetworkFilter() got %d EnvoyFilters, want 1", len(envoyFilters))
+				}
+				return envoyFilters[0].Name
+			}
+
+			got := generate()
+			if tt.want != "" && got != tt.want {
+				t.Errorf("name = %v, want %v", got, tt.want)
+			}
+			if again := generate(); again != got {
+				t.Errorf("name = %v, want a stable name %v", again, got)
+			}
+			if other, ok := names[got]; ok {
+				t.Errorf("name = %v, clashes with %s", got, other)
+			}
+			names[got] = tt.name
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterWarnings(t *testing.T) {
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		workloadSelector *networking.WorkloadSelector
+		want             []string
+	}{
+		{
+			name:             "no warning",
+			workloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift-sample-server"}},
+		},
+		{
+			name: "missing workload selector",
+			want: []string{"the inbound EnvoyFilter for port 9090 is not generated because the service has no " +
+				"workload selector"},
+		},
+		{
+			name: "invalid annotations",
+			annotations: map[string]string{
+				constants.ExactConnectionBalanceAnnotation: "exact",
+				constants.UpstreamIdleTimeoutAnnotation:    "30",
+			},
+			workloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift-sample-server"}},
+			want: []string{
+				"invalid exactConnectionBalance annotation: exact, it should be a boolean",
+				"invalid upstreamIdleTimeout annotation: 30, it should be a non-negative duration",
+			},
+		},
+		{
+			name:             "pod name pattern",
+			annotations:      map[string]string{constants.PodNameAnnotation: "thrift-sample-server-*"},
+			workloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift-sample-server"}},
+			want: []string{
+				"invalid podName annotation: thrift-sample-server-*, it should be the name of a single pod",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Meta: istioconfig.Meta{
+					Annotations: tt.annotations,
+				},
+				Spec: &networking.ServiceEntry{
+					Hosts:     []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+					Addresses: []string{"10.0.0.1"},
+					Ports: []*networking.Port{
+						{
+							Number: 9090,
+							Name:   "tcp-thrift",
+						},
+					},
+					WorkloadSelector: tt.workloadSelector,
+				},
+			}
+			// the inbound proxy is passed even without workload selector to get the missing workload selector warning
+			proxy := &thrift.ThriftProxy{StatPrefix: "thrift"}
+			result := GenerateReplaceNetworkFilter(service, service.Spec.Ports[0], proxy, proxy, thriftFilterName,
+				thriftFilterType)
+			if strings.Join(result.Warnings, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("warnings = %v, want %v", result.Warnings, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateInboundDisabled(t *testing.T) {
+	service := &model.ServiceEntryWrapper{
+		Spec: &networking.ServiceEntry{
+			Hosts:     []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+			Addresses: []string{"10.0.0.1"},
+			Ports: []*networking.Port{
+				{
+					Number: 9090,
+					Name:   "tcp-thrift",
+				},
+			},
+			WorkloadSelector: &networking.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "thrift-sample-server",
+				},
+			},
+		},
+	}
+	proxy := &thrift.ThriftProxy{StatPrefix: "thrift"}
+
+	tests := []struct {
+		name     string
+		generate func() *model.GenerationResult
+	}{
+		{
+			name: "network filter",
+			generate: func() *model.GenerationResult {
+				return generateThrift(service)
+			},
+		},
+		{
+			name: "http filter",
+			generate: func() *model.GenerationResult {
+				return GenerateInsertBeforeHTTPFilter(service, service.Spec.Ports[0], proxy, proxy, thriftFilterName,
+					thriftFilterType)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.generate().EnvoyFilters); got != 2 {
+				t.Fatalf("got %d EnvoyFilters with inbound enabled, want 2", got)
+			}
+
+			SetInboundDisabled(true)
+			defer SetInboundDisabled(false)
+			result := tt.generate()
+			if len(result.EnvoyFilters) != 1 {
+				t.Fatalf("got %d EnvoyFilters with inbound disabled, want 1", len(result.EnvoyFilters))
+			}
+			envoyFilter := result.EnvoyFilters[0]
+			if envoyFilter.Envoyfilter.WorkloadSelector != nil || !strings.HasPrefix(envoyFilter.Name, "aeraki-outbound-") {
+				t.Errorf("EnvoyFilter %s should be an outbound one", envoyFilter.Name)
+			}
+			if len(result.Warnings) != 0 {
+				t.Errorf("warnings = %v, want none", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestInboundPort(t *testing.T) {
+	tests := []struct {
+		name       string
+		targetPort uint32
+		endpoints  []*networking.WorkloadEntry
+		want       uint32
+	}{
+		{
+			name: "service port",
+			want: 8080,
+		},
+		{
+			name:       "target port",
+			targetPort: 20880,
+			want:       20880,
+		},
+		{
+			name: "endpoint port",
+			endpoints: []*networking.WorkloadEntry{
+				{Address: "10.0.0.1", Ports: map[string]uint32{"tcp-dubbo": 20880}},
+				{Address: "10.0.0.2", Ports: map[string]uint32{"tcp-dubbo": 20880}},
+			},
+			want: 20880,
+		},
+		{
+			name: "endpoints without port",
+			endpoints: []*networking.WorkloadEntry{
+				{Address: "10.0.0.1"},
+			},
+			want: 8080,
+		},
+		{
+			name: "endpoints on different ports",
+			endpoints: []*networking.WorkloadEntry{
+				{Address: "10.0.0.1", Ports: map[string]uint32{"tcp-dubbo": 20880}},
+				{Address: "10.0.0.2", Ports: map[string]uint32{"tcp-dubbo": 20881}},
+			},
+			want: 8080,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("dubbo", "dubbo.example.com", "tcp-dubbo")
+			service.Spec.Ports[0].Number = 8080
+			service.Spec.Ports[0].TargetPort = tt.targetPort
+			service.Spec.Endpoints = tt.endpoints
+			if got := InboundPort(service, service.Spec.Ports[0]); got != tt.want {
+				t.Errorf("InboundPort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterTargetPort(t *testing.T) {
+	service := testService("dubbo", "dubbo.example.com", "tcp-dubbo")
+	service.Spec.Addresses = []string{"10.0.0.1"}
+	service.Spec.Ports[0].Number = 8080
+	service.Spec.Ports[0].TargetPort = 20880
+	service.Spec.WorkloadSelector = &networking.WorkloadSelector{Labels: map[string]string{"app": "dubbo"}}
+
+	envoyFilters := generateThrift(service).EnvoyFilters
+	if len(envoyFilters) != 2 {
+		t.Fatalf("GenerateReplaceNetworkFilter() got %d EnvoyFilters, want 2", len(envoyFilters))
+	}
+	if got := envoyFilters[0].Envoyfilter.ConfigPatches[0].Match.GetListener().Name; got != "10.0.0.1_8080" {
+		t.Errorf("outbound listener = %v, want 10.0.0.1_8080", got)
+	}
+	inbound := envoyFilters[1].Envoyfilter.ConfigPatches[0].Match.GetListener()
+	if inbound.FilterChain.DestinationPort != 20880 {
+		t.Errorf("inbound destination port = %v, want 20880", inbound.FilterChain.DestinationPort)
+	}
+}
+
+func TestGenerateReplaceNetworkFilterGranularity(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses []string
+	}{
+		{
+			name:      "single VIP",
+			addresses: []string{"10.0.0.1"},
+		},
+		{
+			name:      "multiple VIPs",
+			addresses: []string{"10.0.0.1", "10.0.0.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Spec: &networking.ServiceEntry{
+					Hosts:     []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+					Addresses: tt.addresses,
+					Ports: []*networking.Port{
+						{Number: 9090, Name: "tcp-thrift"},
+						{Number: 9091, Name: "tcp-thrift-admin"},
+					},
+					WorkloadSelector: &networking.WorkloadSelector{
+						Labels: map[string]string{"app": "thrift-sample-server"},
+					},
+				},
+			}
+
+			// one EnvoyFilter per service, port and direction
+			envoyFilters := generateThrift(service).EnvoyFilters
+			if len(envoyFilters) != 2*len(service.Spec.Ports) {
+				t.Fatalf("got %d EnvoyFilters, want the outbound and the inbound ones of each port", len(envoyFilters))
+			}
+			names := make(map[string]bool)
+			var outbound int
+			for _, envoyFilter := range envoyFilters {
+				if names[envoyFilter.Name] {
+					t.Errorf("duplicated EnvoyFilter name %s", envoyFilter.Name)
+				}
+				names[envoyFilter.Name] = true
+				if envoyFilter.Envoyfilter.WorkloadSelector != nil {
+					continue
+				}
+				// the outbound EnvoyFilter of a port patches the listeners of all the VIPs, the ports are generated
+				// in order
+				port := service.Spec.Ports[outbound]
+				outbound++
+				var listeners []string
+				for _, patch := range envoyFilter.Envoyfilter.ConfigPatches {
+					listeners = append(listeners, patch.Match.GetListener().GetName())
+				}
+				var want []string
+				for _, address := range tt.addresses {
+					want = append(want, address+"_"+strconv.Itoa(int(port.Number)))
+				}
+				if !reflect.DeepEqual(listeners, want) {
+					t.Errorf("port %d: outbound listeners = %v, want %v", port.Number, listeners, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterInsertedFilter(t *testing.T) {
+	type config = map[string]*types.Value
+	outboundAndInbound := []string{"10.0.0.1_9090", "virtualInbound"}
+	// each check verifies the config of the i-th inserted filter
+	connectionLimit := func(maxConnections string) func(*testing.T, int, config) {
+		return func(t *testing.T, i int, config config) {
+			// UInt64Value is marshaled as a JSON string
+			if got := config["maxConnections"].GetStringValue(); got != maxConnections {
+				t.Errorf("max connections = %v, want %v", config["maxConnections"], maxConnections)
+			}
+			wantStatPrefix := []string{"10.0.0.1_9090", "inbound|9090"}[i]
+			if got := config["statPrefix"].GetStringValue(); got != wantStatPrefix {
+				t.Errorf("stat prefix = %v, want %v", got, wantStatPrefix)
+			}
+		}
+	}
+	dropConnections := func(percentage string) func(*testing.T, int, config) {
+		return func(t *testing.T, _ int, config config) {
+			rules := config["rules"].GetStructValue().GetFields()
+			if got := rules["action"].GetStringValue(); got != "DENY" {
+				t.Errorf("action = %v, want DENY", got)
+			}
+			policy := rules["policies"].GetStructValue().GetFields()[dropPolicy].GetStructValue().GetFields()
+			// connection.id % 100u < percentage
+			condition := policy["condition"].GetStructValue().GetFields()["callExpr"].GetStructValue().GetFields()
+			if got := condition["function"].GetStringValue(); got != "_<_" {
+				t.Errorf("function = %v, want _<_", got)
+			}
+			args := condition["args"].GetListValue().GetValues()
+			if len(args) != 2 {
+				t.Fatalf("got %d args, want 2", len(args))
+			}
+			constant := args[1].GetStructValue().GetFields()["constExpr"].GetStructValue().GetFields()
+			// uint64 is marshaled as a JSON string
+			if got := constant["uint64Value"].GetStringValue(); got != percentage {
+				t.Errorf("percentage = %v, want %v", constant["uint64Value"], percentage)
+			}
+		}
+	}
+	wasm := func(code, runtime string) func(*testing.T, int, config) {
+		return func(t *testing.T, _ int, config config) {
+			wasmConfig := config["config"].GetStructValue().GetFields()
+			if got := wasmConfig["name"].GetStringValue(); got != "audit" {
+				t.Errorf("name = %v, want audit", got)
+			}
+			vmConfig := wasmConfig["vmConfig"].GetStructValue().GetFields()
+			if got := vmConfig["runtime"].GetStringValue(); got != runtime {
+				t.Errorf("runtime = %v, want %v", got, runtime)
+			}
+			if _, ok := vmConfig["code"].GetStructValue().GetFields()[code]; !ok {
+				t.Errorf("code = %v, want %v code", vmConfig["code"], code)
+			}
+		}
+	}
+	type principalsRule struct {
+		action   string
+		policy   string
+		matchers []string
+	}
+	principals := func(rules ...principalsRule) func(*testing.T, int, config) {
+		return func(t *testing.T, i int, config config) {
+			rules, want := config["rules"].GetStructValue().GetFields(), rules[i]
+			// ALLOW is the default action, which is omitted
+			if got := rules["action"].GetStringValue(); got != want.action && !(got == "" && want.action == "ALLOW") {
+				t.Errorf("action = %v, want %v", got, want.action)
+			}
+			policy := rules["policies"].GetStructValue().GetFields()[want.policy].GetStructValue().GetFields()
+			principals := policy["principals"].GetListValue().GetValues()
+			if len(principals) != len(want.matchers) {
+				t.Fatalf("got %d principals, want %d", len(principals), len(want.matchers))
+			}
+			for j, principal := range principals {
+				name := principal.GetStructValue().GetFields()["authenticated"].GetStructValue().
+					GetFields()["principalName"].GetStructValue().GetFields()
+				var got string
+				for kind, value := range name {
+					got = kind + ":" + `"` + value.GetStringValue() + `"`
+				}
+				if got != want.matchers[j] {
+					t.Errorf("principal = %v, want %v", got, want.matchers[j])
+				}
+			}
+		}
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		filter      string
+		filterType  string
+		// wantListeners are the listeners of the inserted filters, no filter is expected if it's empty
+		wantListeners []string
+		wantOperation networking.EnvoyFilter_Patch_Operation
+		check         func(*testing.T, int, config)
+		wantWarning   bool
+	}{
+		{
+			name:   "connection limit not set",
+			filter: connectionLimitFilter,
+		},
+		{
+			name:          "connection limit",
+			annotations:   map[string]string{constants.MaxConnectionsAnnotation: "1000"},
+			filter:        connectionLimitFilter,
+			filterType:    connectionLimitType,
+			wantListeners: outboundAndInbound,
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check:         connectionLimit("1000"),
+		},
+		{
+			name:        "zero connection limit",
+			annotations: map[string]string{constants.MaxConnectionsAnnotation: "0"},
+			filter:      connectionLimitFilter,
+			wantWarning: true,
+		},
+		{
+			name:        "negative connection limit",
+			annotations: map[string]string{constants.MaxConnectionsAnnotation: "-1"},
+			filter:      connectionLimitFilter,
+			wantWarning: true,
+		},
+		{
+			name:        "invalid connection limit",
+			annotations: map[string]string{constants.MaxConnectionsAnnotation: "unlimited"},
+			filter:      connectionLimitFilter,
+			wantWarning: true,
+		},
+		{
+			name:   "drop percentage not set",
+			filter: rbacFilter,
+		},
+		{
+			name:          "drop partial",
+			annotations:   map[string]string{constants.DropPercentageAnnotation: "30"},
+			filter:        rbacFilter,
+			filterType:    rbacType,
+			wantListeners: outboundAndInbound,
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check:         dropConnections("30"),
+		},
+		{
+			name:          "drop all",
+			annotations:   map[string]string{constants.DropPercentageAnnotation: "100"},
+			filter:        rbacFilter,
+			filterType:    rbacType,
+			wantListeners: outboundAndInbound,
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check:         dropConnections("100"),
+		},
+		{
+			name:        "zero drop percentage",
+			annotations: map[string]string{constants.DropPercentageAnnotation: "0"},
+			filter:      rbacFilter,
+			wantWarning: true,
+		},
+		{
+			name:        "drop percentage over 100",
+			annotations: map[string]string{constants.DropPercentageAnnotation: "101"},
+			filter:      rbacFilter,
+			wantWarning: true,
+		},
+		{
+			name:        "invalid drop percentage",
+			annotations: map[string]string{constants.DropPercentageAnnotation: "half"},
+			filter:      rbacFilter,
+			wantWarning: true,
+		},
+		{
+			name: "allowed principals",
+			annotations: map[string]string{
+				constants.AllowedPrincipalsAnnotation: "cluster.local/ns/default/sa/client, spiffe://cluster.local/ns/a/sa/b",
+			},
+			filter:        rbacFilter,
+			filterType:    rbacType,
+			wantListeners: []string{"virtualInbound"},
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check: principals(principalsRule{
+				action: "ALLOW",
+				policy: allowedPrincipalsPolicy,
+				matchers: []string{
+					`exact:"spiffe://cluster.local/ns/default/sa/client"`,
+					`exact:"spiffe://cluster.local/ns/a/sa/b"`,
+				},
+			}),
+		},
+		{
+			name:          "denied principals with a wildcard",
+			annotations:   map[string]string{constants.DeniedPrincipalsAnnotation: "cluster.local/ns/tenant-b/*"},
+			filter:        rbacFilter,
+			filterType:    rbacType,
+			wantListeners: []string{"virtualInbound"},
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check: principals(principalsRule{
+				action:   "DENY",
+				policy:   deniedPrincipalsPolicy,
+				matchers: []string{`prefix:"spiffe://cluster.local/ns/tenant-b/"`},
+			}),
+		},
+		{
+			name: "denied principals before allowed ones",
+			annotations: map[string]string{
+				constants.AllowedPrincipalsAnnotation: "cluster.local/ns/tenant-a/*",
+				constants.DeniedPrincipalsAnnotation:  "cluster.local/ns/tenant-a/sa/untrusted",
+			},
+			filter:        rbacFilter,
+			filterType:    rbacType,
+			wantListeners: []string{"virtualInbound", "virtualInbound"},
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check: principals(
+				principalsRule{
+					action:   "DENY",
+					policy:   deniedPrincipalsPolicy,
+					matchers: []string{`exact:"spiffe://cluster.local/ns/tenant-a/sa/untrusted"`},
+				},
+				principalsRule{
+					action:   "ALLOW",
+					policy:   allowedPrincipalsPolicy,
+					matchers: []string{`prefix:"spiffe://cluster.local/ns/tenant-a/"`},
+				},
+			),
+		},
+		{
+			name:        "empty principal",
+			annotations: map[string]string{constants.AllowedPrincipalsAnnotation: "cluster.local/ns/a/sa/b,"},
+			filter:      rbacFilter,
+			wantWarning: true,
+		},
+		{
+			name:   "wasm not set",
+			filter: wasmFilter,
+		},
+		{
+			name: "wasm local code",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": "audit", "rootId": "audit_root",
+				"filename": "/etc/wasm/audit.wasm", "configuration": "{\"level\": \"info\"}"}`},
+			filter:        wasmFilter,
+			filterType:    wasmType,
+			wantListeners: outboundAndInbound,
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			check:         wasm("local", defaultWasmRuntime),
+		},
+		{
+			name: "wasm remote code after the protocol filter",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": "audit",
+				"url": "https://wasm.example.com/audit.wasm", "cluster": "wasm", "sha256": "abc",
+				"runtime": "envoy.wasm.runtime.wamr", "position": "after"}`},
+			filter:        wasmFilter,
+			filterType:    wasmType,
+			wantListeners: outboundAndInbound,
+			wantOperation: networking.EnvoyFilter_Patch_INSERT_AFTER,
+			check:         wasm("remote", "envoy.wasm.runtime.wamr"),
+		},
+		{
+			name:        "invalid wasm json",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": `},
+			filter:      wasmFilter,
+			wantWarning: true,
+		},
+		{
+			name:        "wasm without name",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"filename": "/etc/wasm/audit.wasm"}`},
+			filter:      wasmFilter,
+			wantWarning: true,
+		},
+		{
+			name:        "wasm without code",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": "audit"}`},
+			filter:      wasmFilter,
+			wantWarning: true,
+		},
+		{
+			name: "wasm with both codes",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": "audit",
+				"filename": "/etc/wasm/audit.wasm", "url": "https://wasm.example.com/audit.wasm", "cluster": "wasm",
+				"sha256": "abc"}`},
+			filter:      wasmFilter,
+			wantWarning: true,
+		},
+		{
+			name: "wasm remote code without checksum",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": "audit",
+				"url": "https://wasm.example.com/audit.wasm", "cluster": "wasm"}`},
+			filter:      wasmFilter,
+			wantWarning: true,
+		},
+		{
+			name: "invalid wasm position",
+			annotations: map[string]string{constants.WasmFilterAnnotation: `{"name": "audit",
+				"filename": "/etc/wasm/audit.wasm", "position": "replace"}`},
+			filter:      wasmFilter,
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = []string{"10.0.0.1"}
+			service.Spec.WorkloadSelector = &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift"}}
+			service.Annotations = tt.annotations
+			result := generateThrift(service)
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+
+			var filterPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+			for _, patch := range patchesOf(result, networking.EnvoyFilter_NETWORK_FILTER) {
+				if patch.Patch.Value.Fields["name"].GetStringValue() == tt.filter {
+					filterPatches = append(filterPatches, patch)
+				}
+			}
+			if len(filterPatches) != len(tt.wantListeners) {
+				t.Fatalf("got %d %s patches, want %d", len(filterPatches), tt.filter, len(tt.wantListeners))
+			}
+			for i, patch := range filterPatches {
+				if patch.Patch.Operation != tt.wantOperation {
+					t.Errorf("operation = %v, want %v", patch.Patch.Operation, tt.wantOperation)
+				}
+				listener := patch.Match.GetListener()
+				if listener.Name != tt.wantListeners[i] {
+					t.Errorf("listener = %v, want %v", listener.Name, tt.wantListeners[i])
+				}
+				if name := listener.FilterChain.Filter.Name; name != thriftFilterName {
+					t.Errorf("filter match = %v, want %v", name, thriftFilterName)
+				}
+				typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue().GetFields()
+				if got := typedConfig["type_url"].GetStringValue(); got != tt.filterType {
+					t.Errorf("type url = %v, want %v", got, tt.filterType)
+				}
+				tt.check(t, i, typedConfig["value"].GetStructValue().GetFields())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// EnvoyFilterOrder is the order of the outbound and inbound EnvoyFilters of a service port in the generation result
+type EnvoyFilterOrder string
+
+const (
+	// OutboundFirst puts the outbound EnvoyFilters before the inbound ones
+	OutboundFirst EnvoyFilterOrder = "outbound-first"
+	// InboundFirst puts the inbound EnvoyFilters before the outbound ones, so the servers are ready for the protocol
+	// before the clients start sending it
+	InboundFirst EnvoyFilterOrder = "inbound-first"
+)
+
+// inboundFirst puts the inbound EnvoyFilters before the outbound ones in the generation results
+var inboundFirst atomic.Bool
+
+// SetEnvoyFilterOrder sets the order of the outbound and inbound EnvoyFilters of a service port, the outbound ones go
+// first if the order is empty
+func SetEnvoyFilterOrder(order string) error {
+	switch EnvoyFilterOrder(order) {
+	case "", OutboundFirst:
+		inboundFirst.Store(false)
+	case InboundFirst:
+		inboundFirst.Store(true)
+	default:
+		return fmt.Errorf("invalid EnvoyFilter order: %s, it should be %s or %s", order, OutboundFirst, InboundFirst)
+	}
+	return nil
+}
+
+// orderEnvoyFilters concatenates the outbound and inbound EnvoyFilters of a service port in the configured order
+func orderEnvoyFilters(outbound, inbound []*model.EnvoyFilterWrapper) []*model.EnvoyFilterWrapper {
+	if inboundFirst.Load() {
+		outbound, inbound = inbound, outbound
+	}
+	return append(outbound, inbound...)
+}
@@ -0,0 +1,88 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestSetEnvoyFilterOrder(t *testing.T) {
+	defer func() { _ = SetEnvoyFilterOrder("") }()
+	tests := []struct {
+		order            string
+		wantInboundFirst bool
+		wantErr          bool
+	}{
+		{order: ""},
+		{order: "outbound-first"},
+		{order: "inbound-first", wantInboundFirst: true},
+		{order: "random", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			inboundFirst.Store(false)
+			if err := SetEnvoyFilterOrder(tt.order); (err != nil) != tt.wantErr {
+				t.Fatalf("SetEnvoyFilterOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := inboundFirst.Load(); got != tt.wantInboundFirst {
+				t.Errorf("inbound first = %v, want %v", got, tt.wantInboundFirst)
+			}
+		})
+	}
+}
+
+func TestGenerateReplaceNetworkFilterOrder(t *testing.T) {
+	defer func() { _ = SetEnvoyFilterOrder("") }()
+	tests := []struct {
+		order     EnvoyFilterOrder
+		wantNames []string
+	}{
+		{
+			order: OutboundFirst,
+			wantNames: []string{
+				"aeraki-outbound-thrift.example.com-10.0.0.1-9090",
+				"aeraki-inbound-thrift.example.com-9090",
+			},
+		},
+		{
+			order: InboundFirst,
+			wantNames: []string{
+				"aeraki-inbound-thrift.example.com-9090",
+				"aeraki-outbound-thrift.example.com-10.0.0.1-9090",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			if err := SetEnvoyFilterOrder(string(tt.order)); err != nil {
+				t.Fatalf("SetEnvoyFilterOrder() unexpected error: %v", err)
+			}
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = []string{"10.0.0.1"}
+			service.Spec.WorkloadSelector = &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift"}}
+			result := generateThrift(service)
+			var names []string
+			for _, envoyFilter := range result.EnvoyFilters {
+				names = append(names, envoyFilter.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("GenerateReplaceNetworkFilter() EnvoyFilters = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// outboundHosts returns the hosts of a service the outbound patches are scoped to, nil is returned if the patches
+// aren't scoped. The hosts must be the ones of the service.
+func outboundHosts(service *model.ServiceEntryWrapper) ([]string, error) {
+	value, ok := service.Annotations[constants.OutboundHostsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	serviceHosts := make(map[string]bool, len(service.Spec.Hosts))
+	for _, host := range service.Spec.Hosts {
+		serviceHosts[host] = true
+	}
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		host = strings.TrimSpace(host)
+		if !serviceHosts[host] {
+			return nil, fmt.Errorf("invalid %s annotation: %s, it should be a comma separated list of the hosts "+
+				"of the service", constants.OutboundHostsAnnotation, value)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// scopeOutboundPatches restricts the outbound listener patches of a service to the sidecar egress listeners if the
+// patches are scoped to some hosts of the service, so the gateways which route to the other hosts aren't affected
+func scopeOutboundPatches(service *model.ServiceEntryWrapper,
+	configPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch) {
+	if hosts, err := outboundHosts(service); err != nil || hosts == nil {
+		return
+	}
+	for _, patch := range configPatches {
+		if patch.Match != nil && patch.Match.Context == networking.EnvoyFilter_ANY {
+			patch.Match.Context = networking.EnvoyFilter_SIDECAR_OUTBOUND
+		}
+	}
+}
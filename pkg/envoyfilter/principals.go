@@ -0,0 +1,131 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	rbacfilter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/security/authz/matcher"
+	"istio.io/istio/pkg/spiffe"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	allowedPrincipalsPolicy = "allowed-principals"
+	deniedPrincipalsPolicy  = "denied-principals"
+)
+
+// sourcePrincipals returns the SPIFFE principals set by an annotation of a service, the spiffe:// scheme of the
+// principals is optional
+func sourcePrincipals(service *model.ServiceEntryWrapper, annotation string) ([]string, error) {
+	value, ok := service.Annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	var principals []string
+	for _, principal := range strings.Split(value, ",") {
+		principal = strings.TrimPrefix(strings.TrimSpace(principal), spiffe.URIPrefix)
+		if principal == "" {
+			return nil, fmt.Errorf("invalid %s annotation: %s, it should be a comma separated list of principals",
+				annotation, value)
+		}
+		principals = append(principals, principal)
+	}
+	return principals, nil
+}
+
+// principalsPatches inserts the RBAC filters which allow or deny the connections by the SPIFFE identities of the
+// callers before the protocol filter of the inbound listener. The identities are only known with mTLS, so the
+// patches aren't generated for the outbound listeners. The denied principals are checked first.
+func principalsPatches(service *model.ServiceEntryWrapper, port *networking.Port,
+	filterName string) ([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	var configPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+	for _, rule := range []struct {
+		annotation string
+		policy     string
+		action     rbac.RBAC_Action
+	}{
+		{constants.DeniedPrincipalsAnnotation, deniedPrincipalsPolicy, rbac.RBAC_DENY},
+		{constants.AllowedPrincipalsAnnotation, allowedPrincipalsPolicy, rbac.RBAC_ALLOW},
+	} {
+		principals, err := sourcePrincipals(service, rule.annotation)
+		if err != nil {
+			return nil, err
+		}
+		if principals == nil {
+			continue
+		}
+		patch, err := principalsPatch(InboundPort(service, port), filterName, rule.policy, rule.action, principals)
+		if err != nil {
+			return nil, err
+		}
+		configPatches = append(configPatches, patch)
+	}
+	return configPatches, nil
+}
+
+// principalsPatch inserts a RBAC filter matching the given principals before the protocol filter of the inbound
+// listener
+func principalsPatch(destinationPort uint32, filterName, policy string, action rbac.RBAC_Action,
+	principals []string) (*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	rbacPrincipals := make([]*rbac.Principal, 0, len(principals))
+	for _, principal := range principals {
+		rbacPrincipals = append(rbacPrincipals, &rbac.Principal{
+			Identifier: &rbac.Principal_Authenticated_{Authenticated: &rbac.Principal_Authenticated{
+				PrincipalName: matcher.StringMatcherWithPrefix(principal, spiffe.URIPrefix),
+			}},
+		})
+	}
+	value, err := generateValue(&rbacfilter.RBAC{
+		StatPrefix: StatPrefix(fmt.Sprintf("inbound|%d", destinationPort)),
+		Rules: &rbac.RBAC{
+			Action: action,
+			Policies: map[string]*rbac.Policy{
+				policy: {
+					Permissions: []*rbac.Permission{{Rule: &rbac.Permission_Any{Any: true}}},
+					Principals:  rbacPrincipals,
+				},
+			},
+		},
+	}, rbacFilter, rbacType)
+	if err != nil {
+		return nil, err
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_INBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch("virtualInbound", virtualInboundListenerPort,
+					&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						DestinationPort: destinationPort,
+						Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: filterName,
+						},
+					}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     value,
+		},
+	}, nil
+}
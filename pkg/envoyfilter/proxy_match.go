@@ -0,0 +1,71 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// proxyMetadataMatch returns the node metadata the proxies must have to apply the generated EnvoyFilters of a service
+func proxyMetadataMatch(service *model.ServiceEntryWrapper) (map[string]string, error) {
+	value, ok := service.Annotations[constants.ProxyMetadataMatchAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid %s annotation: %s, it should be a comma separated list of key=value pairs",
+				constants.ProxyMetadataMatchAnnotation, value)
+		}
+		metadata[key] = strings.TrimSpace(val)
+	}
+	return metadata, nil
+}
+
+// matchProxyMetadata adds the node metadata required by the proxyMetadataMatch annotation of a service to the proxy
+// match of all the config patches of an EnvoyFilter generated for it, an invalid annotation is reported as a warning
+// by the generation and ignored here
+func matchProxyMetadata(wrapper *model.EnvoyFilterWrapper, service *model.ServiceEntryWrapper) {
+	if service == nil {
+		return
+	}
+	metadata, err := proxyMetadataMatch(service)
+	if err != nil || len(metadata) == 0 {
+		return
+	}
+	for _, patch := range wrapper.Envoyfilter.ConfigPatches {
+		if patch.Match == nil {
+			patch.Match = &networking.EnvoyFilter_EnvoyConfigObjectMatch{}
+		}
+		if patch.Match.Proxy == nil {
+			patch.Match.Proxy = &networking.EnvoyFilter_ProxyMatch{}
+		}
+		if patch.Match.Proxy.Metadata == nil {
+			patch.Match.Proxy.Metadata = make(map[string]string, len(metadata))
+		}
+		for key, value := range metadata {
+			patch.Match.Proxy.Metadata[key] = value
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/anypb"
+	"istio.io/istio/pilot/pkg/model/credentials"
+	securitymodel "istio.io/istio/pilot/pkg/security/model"
+)
+
+// SDSResourceName returns the SDS resource name of a Kubernetes secret. The namespace is always specified, because the
+// EnvoyFilters of a service apply to the proxies in all the namespaces, and a secret name without a namespace is
+// resolved in the namespace of each proxy.
+func SDSResourceName(name, namespace string) string {
+	return credentials.ToResourceName(namespace + "/" + name)
+}
+
+// SDSSecretConfig builds the reference to a Kubernetes secret which Istiod serves to the proxies over SDS, so the
+// generators don't need to inline the credentials into the EnvoyFilters. The secret is fetched through the ADS
+// connection, the same way as the secrets referenced by the credentialName of a DestinationRule.
+func SDSSecretConfig(name, namespace string) *tls.SdsSecretConfig {
+	return &tls.SdsSecretConfig{
+		Name: SDSResourceName(name, namespace),
+		SdsConfig: &core.ConfigSource{
+			ConfigSourceSpecifier: &core.ConfigSource_Ads{
+				Ads: &core.AggregatedConfigSource{},
+			},
+			ResourceApiVersion: core.ApiVersion_V3,
+		},
+	}
+}
+
+// UpstreamTLSTransportSocket builds the transport socket of the TLS connections to an upstream cluster. The client
+// certificate and the CA certificate are fetched over SDS from a Kubernetes secret, the CA certificate is read from the
+// "-cacert" resource of the secret as Istio does.
+func UpstreamTLSTransportSocket(name, namespace, sni string) (*core.TransportSocket, error) {
+	tlsContext, err := anypb.New(&tls.UpstreamTlsContext{
+		CommonTlsContext: &tls.CommonTlsContext{
+			TlsCertificateSdsSecretConfigs: []*tls.SdsSecretConfig{SDSSecretConfig(name, namespace)},
+			ValidationContextType: &tls.CommonTlsContext_ValidationContextSdsSecretConfig{
+				ValidationContextSdsSecretConfig: SDSSecretConfig(name+securitymodel.SdsCaSuffix, namespace),
+			},
+		},
+		Sni: sni,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &core.TransportSocket{
+		Name: wellknown.TransportSocketTLS,
+		ConfigType: &core.TransportSocket_TypedConfig{
+			TypedConfig: tlsContext,
+		},
+	}, nil
+}
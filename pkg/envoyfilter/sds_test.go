@@ -0,0 +1,68 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSDSSecretConfig(t *testing.T) {
+	got := SDSSecretConfig("redis-client-cert", "redis")
+	want := &tls.SdsSecretConfig{
+		Name: "kubernetes://redis/redis-client-cert",
+		SdsConfig: &core.ConfigSource{
+			ConfigSourceSpecifier: &core.ConfigSource_Ads{Ads: &core.AggregatedConfigSource{}},
+			ResourceApiVersion:    core.ApiVersion_V3,
+		},
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("SDSSecretConfig() = %v, want %v", got, want)
+	}
+}
+
+func TestUpstreamTLSTransportSocket(t *testing.T) {
+	transportSocket, err := UpstreamTLSTransportSocket("redis-client-cert", "redis",
+		"redis-cluster.redis.svc.cluster.local")
+	if err != nil {
+		t.Fatalf("UpstreamTLSTransportSocket() unexpected error: %v", err)
+	}
+	if transportSocket.Name != wellknown.TransportSocketTLS {
+		t.Errorf("transport socket name = %v, want %v", transportSocket.Name, wellknown.TransportSocketTLS)
+	}
+	tlsContext := &tls.UpstreamTlsContext{}
+	if err := transportSocket.GetTypedConfig().UnmarshalTo(tlsContext); err != nil {
+		t.Fatalf("the transport socket config should be an UpstreamTlsContext: %v", err)
+	}
+	if tlsContext.Sni != "redis-cluster.redis.svc.cluster.local" {
+		t.Errorf("sni = %v, want redis-cluster.redis.svc.cluster.local", tlsContext.Sni)
+	}
+	certificates := tlsContext.CommonTlsContext.TlsCertificateSdsSecretConfigs
+	if len(certificates) != 1 || certificates[0].Name != "kubernetes://redis/redis-client-cert" {
+		t.Errorf("certificate SDS configs = %v, want the redis-client-cert secret", certificates)
+	}
+	if len(tlsContext.CommonTlsContext.TlsCertificates) != 0 {
+		t.Errorf("the certificates should not be inlined")
+	}
+	validation := tlsContext.CommonTlsContext.GetValidationContextSdsSecretConfig()
+	if validation.GetName() != "kubernetes://redis/redis-client-cert-cacert" ||
+		validation.GetSdsConfig().GetAds() == nil {
+		t.Errorf("validation context SDS config = %v, want the CA certificate of the secret over ADS", validation)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// serviceEntrySelector scopes the ServiceEntries Aeraki generates the configuration for, all the ServiceEntries are
+// selected if it's not set
+var serviceEntrySelector atomic.Pointer[labels.Selector]
+
+// SetServiceEntrySelector sets the label selector of the ServiceEntries Aeraki generates the configuration for, in the
+// Kubernetes label selector syntax such as "aeraki.io/managed=true" or "aeraki.io/opt-out!=true". The ServiceEntries
+// not matching the selector are ignored, as if they didn't carry any Aeraki supported protocol. An empty selector
+// selects all the ServiceEntries.
+func SetServiceEntrySelector(selector string) error {
+	if selector == "" {
+		serviceEntrySelector.Store(nil)
+		return nil
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return fmt.Errorf("invalid ServiceEntry selector %q: %v", selector, err)
+	}
+	serviceEntrySelector.Store(&parsed)
+	return nil
+}
+
+// isServiceEntrySelected returns whether a ServiceEntry with the given labels is selected by the ServiceEntry selector
+func isServiceEntrySelected(serviceLabels map[string]string) bool {
+	selector := serviceEntrySelector.Load()
+	return selector == nil || (*selector).Matches(labels.Set(serviceLabels))
+}
@@ -0,0 +1,129 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// hashStatHost replaces the host of a stat prefix with a hash of it
+	hashStatHost = "hash"
+	// truncateStatHost keeps the leading characters of the host of a stat prefix
+	truncateStatHost = "truncate"
+	// statHostHashLength is the length of the hex encoded hash of a host in a stat prefix
+	statHostHashLength = 16
+)
+
+// statsNamespaceRegex matches the dot separated segments of a stats namespace, the dots separate the elements of the
+// Envoy stats names, so a segment can't be empty
+var statsNamespaceRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+
+// statsNamespace is prepended to the stat prefixes of the generated protocol filters
+var statsNamespace atomic.Value
+
+// statsMeshIDRegex matches a mesh ID in the stat prefixes, which is a single element of the Envoy stats names
+var statsMeshIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// statsMeshID is prepended to the stat prefixes of the generated protocol filters, before the stats namespace
+var statsMeshID atomic.Value
+
+// statHostSanitizer shortens the hosts of the stat prefixes of the generated protocol filters
+var statHostSanitizer atomic.Value
+
+// SetStatsNamespace sets the namespace the stats of the generated protocol filters are emitted under, such as
+// "tenant-a", which turns the stat prefix "outbound|9090||thrift.example.com" into
+// "tenant-a.outbound|9090||thrift.example.com". The stat prefixes are left as they are if the namespace is empty.
+func SetStatsNamespace(namespace string) error {
+	if namespace != "" && !statsNamespaceRegex.MatchString(namespace) {
+		return fmt.Errorf("invalid stats namespace %q, it should be dot separated segments of alphanumeric "+
+			"characters, '-' or '_'", namespace)
+	}
+	statsNamespace.Store(namespace)
+	return nil
+}
+
+// SetStatsMeshID sets the ID of the mesh the stats of the generated protocol filters are emitted under, so the stats
+// collected from the federated meshes can be told apart, such as "mesh-a", which turns the stat prefix
+// "tenant-a.outbound|9090||thrift.example.com" into "mesh-a.tenant-a.outbound|9090||thrift.example.com". The stat
+// prefixes are left as they are if the mesh ID is empty.
+func SetStatsMeshID(meshID string) error {
+	if meshID != "" && !statsMeshIDRegex.MatchString(meshID) {
+		return fmt.Errorf("invalid stats mesh ID %q, it should only contain alphanumeric characters, '-' or '_'",
+			meshID)
+	}
+	statsMeshID.Store(meshID)
+	return nil
+}
+
+// SetStatPrefixHostSanitization sets how the hosts of the stat prefixes of the generated protocol filters are
+// shortened, so the stat names with long host names don't blow up the cardinality of the metric labels. The mode is
+// either hash, which replaces the host with a hash of it, or truncate:<length>, which keeps the given number of leading
+// characters of the host. The hosts are kept as they are if the mode is empty.
+func SetStatPrefixHostSanitization(mode string) error {
+	sanitizer, err := statHostSanitizerOf(mode)
+	if err != nil {
+		return err
+	}
+	statHostSanitizer.Store(sanitizer)
+	return nil
+}
+
+func statHostSanitizerOf(mode string) (func(string) string, error) {
+	if mode == "" {
+		return nil, nil
+	}
+	if mode == hashStatHost {
+		return func(host string) string {
+			sum := sha256.Sum256([]byte(host))
+			return hex.EncodeToString(sum[:])[:statHostHashLength]
+		}, nil
+	}
+	if strings.HasPrefix(mode, truncateStatHost+":") {
+		length, err := strconv.Atoi(strings.TrimPrefix(mode, truncateStatHost+":"))
+		if err == nil && length > 0 {
+			return func(host string) string {
+				if len(host) > length {
+					return host[:length]
+				}
+				return host
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid stat prefix host sanitization %q, it should be %s or %s:<length>", mode,
+		hashStatHost, truncateStatHost)
+}
+
+// StatPrefix returns the stat prefix of a protocol filter in the stats namespace of the mesh, the host of the prefix,
+// which is the part after the last '|' of a cluster name, is sanitized if a sanitization is set
+func StatPrefix(prefix string) string {
+	if sanitize, _ := statHostSanitizer.Load().(func(string) string); sanitize != nil {
+		i := strings.LastIndex(prefix, "|")
+		prefix = prefix[:i+1] + sanitize(prefix[i+1:])
+	}
+	if namespace, _ := statsNamespace.Load().(string); namespace != "" {
+		prefix = namespace + "." + prefix
+	}
+	if meshID, _ := statsMeshID.Load().(string); meshID != "" {
+		prefix = meshID + "." + prefix
+	}
+	return prefix
+}
@@ -0,0 +1,235 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatPrefix(t *testing.T) {
+	defer func() {
+		_ = SetStatsNamespace("")
+	}()
+	tests := []struct {
+		name      string
+		namespace string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "no namespace",
+			want: "outbound|9090||thrift.example.com",
+		},
+		{
+			name:      "namespace",
+			namespace: "tenant-a",
+			want:      "tenant-a.outbound|9090||thrift.example.com",
+		},
+		{
+			name:      "nested namespace",
+			namespace: "tenant_a.team-b",
+			want:      "tenant_a.team-b.outbound|9090||thrift.example.com",
+		},
+		{
+			name:      "empty segment",
+			namespace: "tenant-a.",
+			wantErr:   true,
+		},
+		{
+			name:      "invalid character",
+			namespace: "tenant:a",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetStatsNamespace(""); err != nil {
+				t.Fatalf("SetStatsNamespace() unexpected error: %v", err)
+			}
+			err := SetStatsNamespace(tt.namespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetStatsNamespace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// an invalid namespace leaves the stat prefixes as they are
+				tt.want = "outbound|9090||thrift.example.com"
+			}
+			if got := StatPrefix("outbound|9090||thrift.example.com"); got != tt.want {
+				t.Errorf("StatPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatPrefixMeshID(t *testing.T) {
+	defer func() {
+		_ = SetStatsMeshID("")
+		_ = SetStatsNamespace("")
+	}()
+	tests := []struct {
+		name      string
+		meshID    string
+		namespace string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "no mesh ID",
+			want: "outbound|9090||thrift.example.com",
+		},
+		{
+			name:   "mesh ID",
+			meshID: "mesh-a",
+			want:   "mesh-a.outbound|9090||thrift.example.com",
+		},
+		{
+			name:      "mesh ID and namespace",
+			meshID:    "mesh_b",
+			namespace: "tenant-a",
+			want:      "mesh_b.tenant-a.outbound|9090||thrift.example.com",
+		},
+		{
+			name:    "dotted mesh ID",
+			meshID:  "mesh.a",
+			wantErr: true,
+		},
+		{
+			name:    "invalid character",
+			meshID:  "mesh/a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetStatsMeshID(""); err != nil {
+				t.Fatalf("SetStatsMeshID() unexpected error: %v", err)
+			}
+			if err := SetStatsNamespace(tt.namespace); err != nil {
+				t.Fatalf("SetStatsNamespace() unexpected error: %v", err)
+			}
+			err := SetStatsMeshID(tt.meshID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetStatsMeshID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// an invalid mesh ID leaves the stat prefixes as they are
+				tt.want = "outbound|9090||thrift.example.com"
+			}
+			if got := StatPrefix("outbound|9090||thrift.example.com"); got != tt.want {
+				t.Errorf("StatPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatPrefixHostSanitization(t *testing.T) {
+	defer func() {
+		_ = SetStatPrefixHostSanitization("")
+	}()
+	const host = "thrift-sample-server.meta-thrift.svc.cluster.local"
+	tests := []struct {
+		name       string
+		mode       string
+		prefix     string
+		wantPrefix string
+		wantLength int
+		wantErr    bool
+	}{
+		{
+			name:       "not sanitized",
+			prefix:     "outbound|9090||" + host,
+			wantPrefix: "outbound|9090||" + host,
+			wantLength: len("outbound|9090||" + host),
+		},
+		{
+			name:       "hash",
+			mode:       "hash",
+			prefix:     "outbound|9090||" + host,
+			wantPrefix: "outbound|9090||",
+			wantLength: len("outbound|9090||") + statHostHashLength,
+		},
+		{
+			name:       "truncate",
+			mode:       "truncate:20",
+			prefix:     "outbound|9090||" + host,
+			wantPrefix: "outbound|9090||" + host[:20],
+			wantLength: len("outbound|9090||") + 20,
+		},
+		{
+			name:       "truncate a short host",
+			mode:       "truncate:100",
+			prefix:     "outbound|9090||" + host,
+			wantPrefix: "outbound|9090||" + host,
+			wantLength: len("outbound|9090||" + host),
+		},
+		{
+			name:       "truncate a prefix without cluster name",
+			mode:       "truncate:6",
+			prefix:     host,
+			wantPrefix: host[:6],
+			wantLength: 6,
+		},
+		{
+			name:    "zero length",
+			mode:    "truncate:0",
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			mode:    "md5",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetStatPrefixHostSanitization(""); err != nil {
+				t.Fatalf("SetStatPrefixHostSanitization() unexpected error: %v", err)
+			}
+			err := SetStatPrefixHostSanitization(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetStatPrefixHostSanitization() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := StatPrefix(tt.prefix)
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("StatPrefix() = %v, want prefix %v", got, tt.wantPrefix)
+			}
+			if len(got) != tt.wantLength {
+				t.Errorf("StatPrefix() length = %d, want %d", len(got), tt.wantLength)
+			}
+			// the same host always gets the same prefix, so the stats of a service stay under the same name
+			if again := StatPrefix(tt.prefix); again != got {
+				t.Errorf("StatPrefix() = %v, then %v, want a stable prefix", got, again)
+			}
+		})
+	}
+}
+
+func TestStatPrefixHashDistinctHosts(t *testing.T) {
+	defer func() {
+		_ = SetStatPrefixHostSanitization("")
+	}()
+	if err := SetStatPrefixHostSanitization("hash"); err != nil {
+		t.Fatalf("SetStatPrefixHostSanitization() unexpected error: %v", err)
+	}
+	a := StatPrefix("outbound|9090||a.example.com")
+	b := StatPrefix("outbound|9090||b.example.com")
+	if a == b {
+		t.Errorf("StatPrefix() = %v for both hosts, want distinct prefixes", a)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	"google.golang.org/protobuf/proto"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// GenerateReplaceNetworkFilterForSubset generates the EnvoyFilters that replace the default tcp proxy with a protocol
+// specified proxy for the workloads of a service subset. Istio builds the listeners per service port rather than per
+// subset, so the inbound listeners of a subset are told apart by the workloads they're on: the labels of the subset
+// are added to the workload selector of the inbound EnvoyFilter. The outbound listener is shared by all the subsets
+// and is left to GenerateReplaceNetworkFilter, only the clusters of the subset are patched on the outbound side.
+func GenerateReplaceNetworkFilterForSubset(service *model.ServiceEntryWrapper, port *networking.Port,
+	subset *networking.Subset, inboundProxy proto.Message, filterName string,
+	filterType string) *model.GenerationResult {
+	result := &model.GenerationResult{}
+	if subset == nil || subset.Name == "" {
+		result.AddWarning("the EnvoyFilters for port %d are not generated because the subset has no name",
+			port.Number)
+		return result
+	}
+
+	var outboundEnvoyFilters, inboundEnvoyFilters []*model.EnvoyFilterWrapper
+	if patches := upstreamClusterPatches(service, port); len(patches) > 0 {
+		for _, patch := range patches {
+			patch.Match.GetCluster().Subset = subset.Name
+		}
+		outboundEnvoyFilters = append(outboundEnvoyFilters, &model.EnvoyFilterWrapper{
+			Name: outboundSubsetEnvoyFilterName(service.Spec.Hosts, subset.Name, int(port.Number)),
+			Envoyfilter: &networking.EnvoyFilter{
+				ConfigPatches: patches,
+			},
+		})
+	}
+
+	result.EnvoyFilters = outboundEnvoyFilters
+	if inboundProxy == nil || inboundDisabled.Load() {
+		return result
+	}
+	// the subset labels alone may select the workloads of other services, so the service must have its own selector
+	selector := inboundEnvoyFilterWorkloadSelector(service)
+	if !hasInboundWorkloadSelector(selector) {
+		addMissingWorkloadSelectorWarning(result, port)
+		return result
+	}
+	labels := make(map[string]string, len(selector.Labels)+len(subset.Labels))
+	for key, value := range selector.Labels {
+		labels[key] = value
+	}
+	for key, value := range subset.Labels {
+		labels[key] = value
+	}
+	for _, envoyFilter := range generateInboundListenerEnvoyFilters(service, port, inboundProxy, filterName,
+		filterType, networking.EnvoyFilter_Patch_REPLACE, &networking.WorkloadSelector{Labels: labels}) {
+		envoyFilter.Name = inboundSubsetEnvoyFilterName(service.Spec.Hosts, subset.Name, int(port.Number))
+		envoyFilter.Envoyfilter.ConfigPatches = append(envoyFilter.Envoyfilter.ConfigPatches,
+			replaceProtocolFilterPatch(envoyFilter.Envoyfilter.ConfigPatches[0], filterName))
+		inboundEnvoyFilters = append(inboundEnvoyFilters, envoyFilter)
+	}
+	result.EnvoyFilters = orderEnvoyFilters(outboundEnvoyFilters, inboundEnvoyFilters)
+	return result
+}
+
+// replaceProtocolFilterPatch copies the patch replacing the tcp proxy of the inbound listener to replace the protocol
+// filter instead. The inbound EnvoyFilter of the service also selects the workloads of the subset, and there's no way
+// to order the EnvoyFilters, so the subset proxy replaces either the tcp proxy or the service proxy, whichever is there
+// when the subset EnvoyFilter is applied.
+func replaceProtocolFilterPatch(patch *networking.EnvoyFilter_EnvoyConfigObjectPatch,
+	filterName string) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	copied := gogoproto.Clone(patch).(*networking.EnvoyFilter_EnvoyConfigObjectPatch)
+	copied.Match.GetListener().FilterChain.Filter.Name = filterName
+	return copied
+}
+
+func outboundSubsetEnvoyFilterName(hosts []string, subset string, port int) string {
+	return fmt.Sprintf("aeraki-outbound-%s-%d-%s", setName(hosts), port, subset)
+}
+
+func inboundSubsetEnvoyFilterName(hosts []string, subset string, port int) string {
+	return fmt.Sprintf("aeraki-inbound-%s-%d-%s", setName(hosts), port, subset)
+}
@@ -0,0 +1,127 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"testing"
+
+	thrift "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/thrift_proxy/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerateReplaceNetworkFilterForSubset(t *testing.T) {
+	subset := &networking.Subset{Name: "v1", Labels: map[string]string{"version": "v1"}}
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		workloadSelector *networking.WorkloadSelector
+		subset           *networking.Subset
+		wantInbound      bool
+		wantCluster      bool
+		wantWarning      bool
+	}{
+		{
+			name:             "inbound",
+			workloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift-sample-server"}},
+			subset:           subset,
+			wantInbound:      true,
+		},
+		{
+			name:             "inbound and cluster",
+			annotations:      map[string]string{constants.UpstreamIdleTimeoutAnnotation: "30s"},
+			workloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift-sample-server"}},
+			subset:           subset,
+			wantInbound:      true,
+			wantCluster:      true,
+		},
+		{
+			name:        "no workload selector",
+			annotations: map[string]string{constants.UpstreamIdleTimeoutAnnotation: "30s"},
+			subset:      subset,
+			wantCluster: true,
+			wantWarning: true,
+		},
+		{
+			name:             "no subset name",
+			workloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "thrift-sample-server"}},
+			subset:           &networking.Subset{Labels: map[string]string{"version": "v1"}},
+			wantWarning:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Meta: istioconfig.Meta{Annotations: tt.annotations},
+				Spec: &networking.ServiceEntry{
+					Hosts:            []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+					Addresses:        []string{"10.0.0.1"},
+					Ports:            []*networking.Port{{Number: 9090, Name: "tcp-thrift"}},
+					WorkloadSelector: tt.workloadSelector,
+				},
+			}
+			result := GenerateReplaceNetworkFilterForSubset(service, service.Spec.Ports[0], tt.subset,
+				&thrift.ThriftProxy{StatPrefix: "thrift"}, "envoy.filters.network.thrift_proxy",
+				"type.googleapis.com/envoy.extensions.filters.network.thrift_proxy.v3.ThriftProxy")
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+
+			var inbound, outbound *model.EnvoyFilterWrapper
+			for _, envoyFilter := range result.EnvoyFilters {
+				if envoyFilter.Envoyfilter.WorkloadSelector != nil {
+					inbound = envoyFilter
+				} else {
+					outbound = envoyFilter
+				}
+			}
+			if (inbound != nil) != tt.wantInbound {
+				t.Fatalf("inbound EnvoyFilter = %v, want: %v", inbound, tt.wantInbound)
+			}
+			if inbound != nil {
+				if inbound.Name != "aeraki-inbound-thrift-sample-server.meta-thrift.svc.cluster.local-9090-v1" {
+					t.Errorf("inbound EnvoyFilter name = %v", inbound.Name)
+				}
+				wantLabels := map[string]string{"app": "thrift-sample-server", "version": "v1"}
+				if labels := inbound.Envoyfilter.WorkloadSelector.Labels; !reflect.DeepEqual(labels, wantLabels) {
+					t.Errorf("workload selector = %v, want %v", labels, wantLabels)
+				}
+				// the subset proxy replaces either the tcp proxy or the proxy of the service
+				var filters []string
+				for _, patch := range inbound.Envoyfilter.ConfigPatches {
+					filters = append(filters, patch.Match.GetListener().FilterChain.Filter.Name)
+				}
+				wantFilters := []string{wellknown.TCPProxy, "envoy.filters.network.thrift_proxy"}
+				if !reflect.DeepEqual(filters, wantFilters) {
+					t.Errorf("replaced filters = %v, want %v", filters, wantFilters)
+				}
+			}
+			if (outbound != nil) != tt.wantCluster {
+				t.Fatalf("outbound EnvoyFilter = %v, want: %v", outbound, tt.wantCluster)
+			}
+			if outbound != nil {
+				cluster := outbound.Envoyfilter.ConfigPatches[0].Match.GetCluster()
+				if cluster.Subset != "v1" || cluster.PortNumber != 9090 || cluster.Service != service.Spec.Hosts[0] {
+					t.Errorf("cluster match = %v, want the v1 subset of %s:9090", cluster, service.Spec.Hosts[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// tcpKeepaliveConfig is the TCP keepalive of the upstream connections of a service
+type tcpKeepaliveConfig struct {
+	// Probes is the maximum number of keepalive probes sent without response before the connection is dropped
+	Probes uint32 `json:"probes"`
+	// Time is the idle time of a connection before the keepalive probes are sent, such as 10m
+	Time string `json:"time"`
+	// Interval is the time between the keepalive probes, such as 75s
+	Interval string `json:"interval"`
+}
+
+// tcpKeepalive returns the tcp_keepalive of the upstream connection options of a service in the Envoy cluster config.
+// Envoy sets the keepalive time and interval in whole seconds, so the durations must be multiples of a second.
+func tcpKeepalive(service *model.ServiceEntryWrapper) (map[string]uint32, bool, error) {
+	value, ok := service.Annotations[constants.TCPKeepaliveAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	config := &tcpKeepaliveConfig{}
+	if err := json.Unmarshal([]byte(value), config); err != nil {
+		return nil, false, fmt.Errorf("invalid %s annotation: %v", constants.TCPKeepaliveAnnotation, err)
+	}
+	keepalive := make(map[string]uint32)
+	if config.Probes > 0 {
+		keepalive["keepalive_probes"] = config.Probes
+	}
+	for field, duration := range map[string]string{
+		"keepalive_time":     config.Time,
+		"keepalive_interval": config.Interval,
+	} {
+		if duration == "" {
+			continue
+		}
+		d, err := time.ParseDuration(duration)
+		if err != nil || d < time.Second || d%time.Second != 0 {
+			return nil, false, fmt.Errorf("invalid %s annotation: %s, the time and interval should be positive "+
+				"durations in whole seconds", constants.TCPKeepaliveAnnotation, value)
+		}
+		keepalive[field] = uint32(d / time.Second)
+	}
+	return keepalive, true, nil
+}
+
+// tcpKeepaliveClusterPatch enables the TCP keepalive of the upstream connections of all the subset clusters of a
+// service port
+func tcpKeepaliveClusterPatch(host string, port uint32,
+	keepalive map[string]uint32) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	fields := make(map[string]*types.Value, len(keepalive))
+	for field, value := range keepalive {
+		fields[field] = &types.Value{Kind: &types.Value_NumberValue{NumberValue: float64(value)}}
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_CLUSTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &networking.EnvoyFilter_ClusterMatch{
+					PortNumber: port,
+					Service:    host,
+				},
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"upstream_connection_options": {
+						Kind: &types.Value_StructValue{StructValue: &types.Struct{
+							Fields: map[string]*types.Value{
+								"tcp_keepalive": {
+									Kind: &types.Value_StructValue{StructValue: &types.Struct{Fields: fields}},
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// upstreamClusterPatches generates the patches of the subset clusters of a service port enabled by the annotations of
+// the service, the clusters of the first host are patched unless the outbound patches are scoped to some hosts
+func upstreamClusterPatches(service *model.ServiceEntryWrapper,
+	port *networking.Port) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	hosts := service.Spec.Hosts[:1]
+	if scoped, _ := outboundHosts(service); scoped != nil {
+		hosts = scoped
+	}
+	protocol, _ := upstreamProtocol(service)
+	var configPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+	for _, host := range hosts {
+		timeout, hasIdleTimeout := IdleTimeout(service, constants.UpstreamIdleTimeoutAnnotation)
+		if hasIdleTimeout {
+			configPatches = append(configPatches, upstreamIdleTimeoutClusterPatch(host, port.Number, timeout))
+		}
+		// the idle timeout is set in the TCP protocol options, which already makes the upstream connections plain TCP
+		if protocol == upstreamProtocolHTTP2 || (protocol == upstreamProtocolTCP && !hasIdleTimeout) {
+			configPatches = append(configPatches, upstreamProtocolClusterPatch(host, port.Number, protocol))
+		}
+		if timeout, ok, _ := upstreamConnectTimeout(service); ok {
+			configPatches = append(configPatches, upstreamConnectTimeoutClusterPatch(host, port.Number, timeout))
+		}
+		if keepalive, ok, _ := tcpKeepalive(service); ok {
+			configPatches = append(configPatches, tcpKeepaliveClusterPatch(host, port.Number, keepalive))
+		}
+		if drain, _ := drainOnClusterChange(service); drain {
+			configPatches = append(configPatches, drainClusterPatch(host, port.Number))
+		}
+		if enabled, _ := localityWeightedLB(service); enabled {
+			configPatches = append(configPatches, localityWeightedLBClusterPatch(host, port.Number))
+		}
+	}
+	return configPatches
+}
@@ -0,0 +1,156 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/protobuf/types/known/durationpb"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const tcpProxyType = "type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy"
+
+// subsetWeight is the weight of a subset in the TcpProxy weighted clusters
+type subsetWeight struct {
+	subset string
+	weight uint32
+}
+
+// tcpWeightedClusters parses the TcpProxy split of a service set by its annotation. The split should contain at least
+// two distinct subsets, and the weights should be positive and add up to 100.
+func tcpWeightedClusters(service *model.ServiceEntryWrapper) ([]subsetWeight, bool, error) {
+	value, ok := service.Annotations[constants.TCPWeightedClustersAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	var weights []subsetWeight
+	subsets := make(map[string]bool)
+	var total uint32
+	for _, item := range strings.Split(strings.ReplaceAll(value, " ", ""), ",") {
+		parts := strings.Split(item, ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, false, fmt.Errorf("invalid %s annotation: %s, it should be a list of subset:weight",
+				constants.TCPWeightedClustersAnnotation, value)
+		}
+		weight, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil || weight == 0 {
+			return nil, false, fmt.Errorf("invalid %s annotation: %s, the weight of subset %s should be a positive "+
+				"integer", constants.TCPWeightedClustersAnnotation, value, parts[0])
+		}
+		if subsets[parts[0]] {
+			return nil, false, fmt.Errorf("invalid %s annotation: %s, duplicated subset %s",
+				constants.TCPWeightedClustersAnnotation, value, parts[0])
+		}
+		subsets[parts[0]] = true
+		total += uint32(weight)
+		weights = append(weights, subsetWeight{subset: parts[0], weight: uint32(weight)})
+	}
+	if len(weights) < 2 || total != 100 {
+		return nil, false, fmt.Errorf("invalid %s annotation: %s, the weights of at least two subsets should add "+
+			"up to 100", constants.TCPWeightedClustersAnnotation, value)
+	}
+	return weights, true, nil
+}
+
+// tcpWeightedClustersPatch generates a patch which merges the weighted clusters into the TcpProxy of an outbound
+// listener. The weighted clusters take the place of the single cluster set by Istio, since they're one of a kind.
+func tcpWeightedClustersPatch(service *model.ServiceEntryWrapper, port *networking.Port, listenerName string,
+	weights []subsetWeight) (*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	weightedClusters := &tcp.TcpProxy_WeightedCluster{}
+	for _, weight := range weights {
+		weightedClusters.Clusters = append(weightedClusters.Clusters, &tcp.TcpProxy_WeightedCluster_ClusterWeight{
+			Name:   ExpectedOutboundClusterName(service.Spec, port, weight.subset),
+			Weight: weight.weight,
+		})
+	}
+	value, err := StructValue(&tcp.TcpProxy{
+		ClusterSpecifier: &tcp.TcpProxy_WeightedClusters{WeightedClusters: weightedClusters},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the typed config is merged into the one generated by Istio, so it has to be of the same type
+	value.Fields["@type"] = &types.Value{Kind: &types.Value_StringValue{StringValue: tcpProxyType}}
+
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port.Number,
+					&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: wellknown.TCPProxy,
+						},
+					}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"name":         {Kind: &types.Value_StringValue{StringValue: wellknown.TCPProxy}},
+					"typed_config": {Kind: &types.Value_StructValue{StructValue: value}},
+				},
+			},
+		},
+	}, nil
+}
+
+// tcpIdleTimeoutPatch generates a patch which merges the idle timeout of the downstream connections into the TcpProxy
+// of a listener
+func tcpIdleTimeoutPatch(context networking.EnvoyFilter_PatchContext, listenerName string, listenerPort,
+	destinationPort uint32, timeout time.Duration) (*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	value, err := StructValue(&tcp.TcpProxy{IdleTimeout: durationpb.New(timeout)})
+	if err != nil {
+		return nil, err
+	}
+	// the typed config is merged into the one generated by Istio, so it has to be of the same type
+	value.Fields["@type"] = &types.Value{Kind: &types.Value_StringValue{StringValue: tcpProxyType}}
+
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: context,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, listenerPort,
+					&networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						DestinationPort: destinationPort,
+						Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: wellknown.TCPProxy,
+						},
+					}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"name":         {Kind: &types.Value_StringValue{StringValue: wellknown.TCPProxy}},
+					"typed_config": {Kind: &types.Value_StructValue{StructValue: value}},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,183 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"reflect"
+	"testing"
+
+	zookeeper "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/zookeeper_proxy/v3"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+)
+
+func TestTCPWeightedClusters(t *testing.T) {
+	tests := []struct {
+		name    string
+		split   string
+		want    []subsetWeight
+		wantErr bool
+	}{
+		{
+			name:  "70/30 split",
+			split: "v1:70, v2:30",
+			want:  []subsetWeight{{subset: "v1", weight: 70}, {subset: "v2", weight: 30}},
+		},
+		{
+			name: "not set",
+		},
+		{
+			name:    "single subset",
+			split:   "v1:100",
+			wantErr: true,
+		},
+		{
+			name:    "weights not adding up to 100",
+			split:   "v1:70,v2:40",
+			wantErr: true,
+		},
+		{
+			name:    "zero weight",
+			split:   "v1:100,v2:0",
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			split:   "v1:130,v2:-30",
+			wantErr: true,
+		},
+		{
+			name:    "duplicated subset",
+			split:   "v1:70,v1:30",
+			wantErr: true,
+		},
+		{
+			name:    "missing weight",
+			split:   "v1,v2:30",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("zookeeper", "zookeeper.example.com", "tcp-zookeeper")
+			if tt.split != "" {
+				service.Annotations = map[string]string{constants.TCPWeightedClustersAnnotation: tt.split}
+			}
+			got, ok, err := tcpWeightedClusters(service)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tcpWeightedClusters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tcpWeightedClusters() = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateInsertBeforeNetworkFilterWeightedClusters(t *testing.T) {
+	service := testService("zookeeper", "zookeeper.example.com", "tcp-zookeeper")
+	service.Spec.Addresses = []string{"10.0.0.1"}
+	service.Annotations = map[string]string{constants.TCPWeightedClustersAnnotation: "v1:70,v2:30"}
+	proxy := &zookeeper.ZooKeeperProxy{StatPrefix: "zookeeper"}
+
+	result := GenerateInsertBeforeNetworkFilter(service, proxy, nil, "envoy.filters.network.zookeeper_proxy",
+		"type.googleapis.com/envoy.extensions.filters.network.zookeeper_proxy.v3.ZooKeeperProxy")
+	if len(result.EnvoyFilters) != 1 {
+		t.Fatalf("GenerateInsertBeforeNetworkFilter() got %d EnvoyFilters, want 1", len(result.EnvoyFilters))
+	}
+	patches := result.EnvoyFilters[0].Envoyfilter.ConfigPatches
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches, want the zookeeper filter and the TcpProxy weighted clusters", len(patches))
+	}
+	patch := patches[1]
+	if patch.Patch.Operation != networking.EnvoyFilter_Patch_MERGE ||
+		patch.Match.GetListener().Name != "10.0.0.1_9090" {
+		t.Errorf("patch = %v, want a merge into the TcpProxy of the outbound listener", patch)
+	}
+	typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue()
+	if typedConfig.Fields["@type"].GetStringValue() != tcpProxyType {
+		t.Errorf("typed_config type = %v, want %v", typedConfig.Fields["@type"], tcpProxyType)
+	}
+	clusters := typedConfig.Fields["weightedClusters"].GetStructValue().Fields["clusters"].GetListValue().Values
+	want := map[string]float64{
+		"outbound|9090|v1|zookeeper.example.com": 70,
+		"outbound|9090|v2|zookeeper.example.com": 30,
+	}
+	if len(clusters) != len(want) {
+		t.Fatalf("weighted clusters = %v, want %v", clusters, want)
+	}
+	for _, cluster := range clusters {
+		fields := cluster.GetStructValue().Fields
+		if weight := want[fields["name"].GetStringValue()]; weight != fields["weight"].GetNumberValue() {
+			t.Errorf("weighted cluster = %v, want %v", fields, want)
+		}
+	}
+
+	replaced := GenerateReplaceNetworkFilter(service, service.Spec.Ports[0], proxy, nil,
+		"envoy.filters.network.zookeeper_proxy",
+		"type.googleapis.com/envoy.extensions.filters.network.zookeeper_proxy.v3.ZooKeeperProxy")
+	if len(replaced.EnvoyFilters[0].Envoyfilter.ConfigPatches) != 1 || len(replaced.Warnings) != 1 {
+		t.Errorf("the weighted clusters should be ignored with a warning when the TcpProxy is replaced: %v",
+			replaced.Warnings)
+	}
+}
+
+func TestGenerateNetworkFilterDownstreamIdleTimeout(t *testing.T) {
+	service := testService("zookeeper", "zookeeper.example.com", "tcp-zookeeper")
+	service.Spec.Addresses = []string{"10.0.0.1"}
+	service.Spec.WorkloadSelector = &networking.WorkloadSelector{Labels: map[string]string{"app": "zookeeper"}}
+	service.Annotations = map[string]string{constants.DownstreamIdleTimeoutAnnotation: "30s"}
+	proxy := &zookeeper.ZooKeeperProxy{StatPrefix: "zookeeper"}
+
+	result := GenerateInsertBeforeNetworkFilter(service, proxy, proxy, "envoy.filters.network.zookeeper_proxy",
+		"type.googleapis.com/envoy.extensions.filters.network.zookeeper_proxy.v3.ZooKeeperProxy")
+	if len(result.EnvoyFilters) != 2 || len(result.Warnings) != 0 {
+		t.Fatalf("GenerateInsertBeforeNetworkFilter() got %d EnvoyFilters and warnings %v, want 2 EnvoyFilters",
+			len(result.EnvoyFilters), result.Warnings)
+	}
+	for _, envoyFilter := range result.EnvoyFilters {
+		patches := envoyFilter.Envoyfilter.ConfigPatches
+		if len(patches) != 2 {
+			t.Fatalf("%s: got %d patches, want the zookeeper filter and the TcpProxy idle timeout",
+				envoyFilter.Name, len(patches))
+		}
+		patch := patches[1]
+		if patch.Patch.Operation != networking.EnvoyFilter_Patch_MERGE {
+			t.Errorf("%s: patch = %v, want a merge into the TcpProxy", envoyFilter.Name, patch)
+		}
+		typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue()
+		if typedConfig.Fields["@type"].GetStringValue() != tcpProxyType ||
+			typedConfig.Fields["idleTimeout"].GetStringValue() != "30s" {
+			t.Errorf("%s: typed_config = %v, want the TcpProxy idle timeout 30s", envoyFilter.Name, typedConfig)
+		}
+	}
+
+	replaced := GenerateReplaceNetworkFilter(service, service.Spec.Ports[0], proxy, nil,
+		"envoy.filters.network.zookeeper_proxy",
+		"type.googleapis.com/envoy.extensions.filters.network.zookeeper_proxy.v3.ZooKeeperProxy")
+	if len(replaced.EnvoyFilters[0].Envoyfilter.ConfigPatches) != 1 || len(replaced.Warnings) != 1 {
+		t.Errorf("the idle timeout should be ignored with a warning when the TcpProxy is replaced: %v",
+			replaced.Warnings)
+	}
+
+	service.Spec.Ports[0].Name = "tcp-metaprotocol-dubbo"
+	metaProtocol := GenerateReplaceNetworkFilter(service, service.Spec.Ports[0], proxy, nil,
+		"envoy.filters.network.meta_protocol_proxy",
+		"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy")
+	if len(metaProtocol.Warnings) != 0 {
+		t.Errorf("the MetaProtocol proxy sets its own idle timeout, got warnings %v", metaProtocol.Warnings)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// tlsPassthrough checks whether the outbound filter chains of a service are matched by the TLS requested server names
+func tlsPassthrough(service *model.ServiceEntryWrapper) (bool, error) {
+	value, ok := service.Annotations[constants.TLSPassthroughAnnotation]
+	if !ok {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s, it should be a boolean",
+			constants.TLSPassthroughAnnotation, value)
+	}
+	return enabled, nil
+}
+
+// outboundFilterChainMatches returns the matches of the outbound filter chains of a service which carry the tcp proxy.
+// Istio builds a filter chain for each host of a TLS passthrough service, with the host as the requested server name,
+// so there's a match for each of the hosts, or each of the hosts the outbound patches are scoped to.
+func outboundFilterChainMatches(
+	service *model.ServiceEntryWrapper) []*networking.EnvoyFilter_ListenerMatch_FilterChainMatch {
+	filter := &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+		Name: wellknown.TCPProxy,
+	}
+	if passthrough, _ := tlsPassthrough(service); !passthrough {
+		return []*networking.EnvoyFilter_ListenerMatch_FilterChainMatch{{Filter: filter}}
+	}
+	hosts := service.Spec.Hosts
+	if scoped, _ := outboundHosts(service); scoped != nil {
+		hosts = scoped
+	}
+	var matches []*networking.EnvoyFilter_ListenerMatch_FilterChainMatch
+	for _, host := range hosts {
+		matches = append(matches, &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+			Sni:               host,
+			TransportProtocol: "tls",
+			Filter:            filter,
+		})
+	}
+	return matches
+}
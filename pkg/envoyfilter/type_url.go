@@ -0,0 +1,86 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	typeURLPrefix     = "type.googleapis.com/"
+	envoyTypePrefix   = "envoy."
+	envoyAPIVersion   = "v3"
+	typeURLField      = "@type"
+	typedStructURLKey = "type_url"
+)
+
+// validateTypeURLs checks that the Envoy types referenced in the patches of an EnvoyFilter are of the v3 API, the v2
+// types have been removed from Envoy and a proxy rejects the whole EnvoyFilter if one of them shows up
+func validateTypeURLs(wrapper *model.EnvoyFilterWrapper) error {
+	for _, patch := range wrapper.Envoyfilter.GetConfigPatches() {
+		if err := validateStructTypeURLs(patch.GetPatch().GetValue()); err != nil {
+			return fmt.Errorf("envoy filter %s: %v", wrapper.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateStructTypeURLs(value *types.Struct) error {
+	for key, field := range value.GetFields() {
+		if key == typeURLField || key == typedStructURLKey {
+			if err := validateTypeURL(field.GetStringValue()); err != nil {
+				return err
+			}
+		}
+		if err := validateValueTypeURLs(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValueTypeURLs(value *types.Value) error {
+	switch kind := value.GetKind().(type) {
+	case *types.Value_StructValue:
+		return validateStructTypeURLs(kind.StructValue)
+	case *types.Value_ListValue:
+		for _, item := range kind.ListValue.GetValues() {
+			if err := validateValueTypeURLs(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateTypeURL returns an error if the type URL references an Envoy type which isn't of the v3 API, the types out of
+// the envoy package, such as the Aeraki and udpa ones, are left alone
+func validateTypeURL(typeURL string) error {
+	name := strings.TrimPrefix(typeURL, typeURLPrefix)
+	if !strings.HasPrefix(name, envoyTypePrefix) {
+		return nil
+	}
+	for _, segment := range strings.Split(name, ".") {
+		if segment == envoyAPIVersion {
+			return nil
+		}
+	}
+	return fmt.Errorf("type URL %s is not of the Envoy %s API", typeURL, envoyAPIVersion)
+}
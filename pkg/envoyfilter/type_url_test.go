@@ -0,0 +1,102 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"testing"
+
+	thrift "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/thrift_proxy/v3"
+)
+
+func TestValidateTypeURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		typeURL string
+		wantErr bool
+	}{
+		{
+			name:    "v3",
+			typeURL: "type.googleapis.com/envoy.extensions.filters.network.thrift_proxy.v3.ThriftProxy",
+		},
+		{
+			name:    "v2",
+			typeURL: "type.googleapis.com/envoy.config.filter.network.thrift_proxy.v2alpha1.ThriftProxy",
+			wantErr: true,
+		},
+		{
+			name:    "v2 without prefix",
+			typeURL: "envoy.api.v2.Cluster",
+			wantErr: true,
+		},
+		{
+			name:    "v3alpha",
+			typeURL: "type.googleapis.com/envoy.extensions.filters.network.kafka_mesh.v3alpha.KafkaMesh",
+			wantErr: true,
+		},
+		{
+			name:    "aeraki",
+			typeURL: "type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy",
+		},
+		{
+			name:    "udpa",
+			typeURL: "type.googleapis.com/udpa.type.v1.TypedStruct",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTypeURL(tt.typeURL); (err != nil) != tt.wantErr {
+				t.Errorf("validateTypeURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTypeURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		typeURL string
+		wantErr bool
+	}{
+		{
+			name:    "v3",
+			typeURL: "type.googleapis.com/envoy.extensions.filters.network.thrift_proxy.v3.ThriftProxy",
+		},
+		{
+			name:    "v2",
+			typeURL: "type.googleapis.com/envoy.config.filter.network.thrift_proxy.v2alpha1.ThriftProxy",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testService("thrift", "thrift.example.com", "tcp-thrift")
+			service.Spec.Addresses = []string{"10.0.0.1"}
+			result := GenerateReplaceNetworkFilter(service, service.Spec.Ports[0],
+				&thrift.ThriftProxy{StatPrefix: "thrift"}, nil, thriftFilterName, tt.typeURL)
+			if len(result.EnvoyFilters) == 0 {
+				t.Fatalf("no envoy filter generated")
+			}
+			var err error
+			for _, wrapper := range result.EnvoyFilters {
+				if err = validateTypeURLs(wrapper); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateTypeURLs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strconv"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/protobuf/proto"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// GenerateUDPListenerFilter generates an EnvoyFilter that adds an outbound UDP listener with a protocol specified UDP
+// listener filter for each VIP of a service port. Istio only builds TCP listeners for the services, so unlike the TCP
+// protocols, there's no listener to patch and the whole listener is added. The listener binds the VIP with freebind,
+// the UDP traffic to the VIP has to be redirected to the proxy, which the Istio traffic interception doesn't do.
+func GenerateUDPListenerFilter(service *model.ServiceEntryWrapper, port *networking.Port, proxy proto.Message,
+	filterName string, filterType string) *model.GenerationResult {
+	result := &model.GenerationResult{}
+	addresses := service.Spec.GetAddresses()
+	if len(addresses) == 0 {
+		result.AddWarning("the UDP listener for port %d is not generated because the service has no VIP",
+			port.Number)
+		return result
+	}
+	filter, err := generateValue(proxy, filterName, filterType)
+	if err != nil {
+		// This should not happen
+		generatorLog.Errorf("Failed to generate UDP listener EnvoyFilter: %v", err)
+		return result
+	}
+
+	var configPatches []*networking.EnvoyFilter_EnvoyConfigObjectPatch
+	for _, address := range addresses {
+		value, err := udpListenerValue(address, port.Number, filter)
+		if err != nil {
+			// This should not happen
+			generatorLog.Errorf("Failed to generate UDP listener EnvoyFilter: %v", err)
+			return result
+		}
+		configPatches = append(configPatches, &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+			ApplyTo: networking.EnvoyFilter_LISTENER,
+			Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+				Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			},
+			Patch: &networking.EnvoyFilter_Patch{
+				Operation: networking.EnvoyFilter_Patch_ADD,
+				Value:     value,
+			},
+		})
+	}
+	result.EnvoyFilters = []*model.EnvoyFilterWrapper{
+		{
+			Name: outboundUDPEnvoyFilterName(service.Spec.Hosts, addresses, int(port.Number)),
+			Envoyfilter: &networking.EnvoyFilter{
+				ConfigPatches: configPatches,
+			},
+		},
+	}
+	return result
+}
+
+// udpListenerValue builds the UDP listener of a VIP with the UDP listener filter
+func udpListenerValue(address string, port uint32, filter *types.Struct) (*types.Struct, error) {
+	value, err := StructValue(&listener.Listener{
+		Name: udpListenerName(address, port),
+		Address: &core.Address{
+			Address: &core.Address_SocketAddress{
+				SocketAddress: &core.SocketAddress{
+					Protocol: core.SocketAddress_UDP,
+					Address:  address,
+					PortSpecifier: &core.SocketAddress_PortValue{
+						PortValue: port,
+					},
+				},
+			},
+		},
+		Freebind:          &wrappers.BoolValue{Value: true},
+		UdpListenerConfig: &listener.UdpListenerConfig{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	value.Fields["listenerFilters"] = &types.Value{
+		Kind: &types.Value_ListValue{
+			ListValue: &types.ListValue{
+				Values: []*types.Value{{Kind: &types.Value_StructValue{StructValue: filter}}},
+			},
+		},
+	}
+	return value, nil
+}
+
+// udpListenerName is the name of the UDP listener of a VIP, which differs from the name of the TCP listener Istio
+// builds for the same VIP and port
+func udpListenerName(address string, port uint32) string {
+	return address + "_" + strconv.Itoa(int(port)) + "_udp"
+}
+
+func outboundUDPEnvoyFilterName(hosts []string, vips []string, port int) string {
+	return fmt.Sprintf("aeraki-outbound-udp-%s-%s-%d", setName(hosts), setName(vips), port)
+}
@@ -0,0 +1,111 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"testing"
+
+	udpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerateUDPListenerFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		addresses   []string
+		wantWarning bool
+	}{
+		{
+			name:      "single VIP",
+			addresses: []string{"10.0.0.1"},
+		},
+		{
+			name:      "multiple VIPs",
+			addresses: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:        "no VIP",
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Spec: &networking.ServiceEntry{
+					Hosts:     []string{"coap-server.meta-coap.svc.cluster.local"},
+					Addresses: tt.addresses,
+					Ports:     []*networking.Port{{Number: 5683, Name: "udp-coap", Protocol: "UDP"}},
+				},
+			}
+			proxy := &udpproxy.UdpProxyConfig{
+				StatPrefix: "outbound|5683||coap-server.meta-coap.svc.cluster.local",
+				RouteSpecifier: &udpproxy.UdpProxyConfig_Cluster{
+					Cluster: "outbound|5683||coap-server.meta-coap.svc.cluster.local",
+				},
+			}
+			result := GenerateUDPListenerFilter(service, service.Spec.Ports[0], proxy,
+				"envoy.filters.udp_listener.udp_proxy",
+				"type.googleapis.com/envoy.extensions.filters.udp.udp_proxy.v3.UdpProxyConfig")
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantWarning {
+				if len(result.EnvoyFilters) != 0 {
+					t.Errorf("unexpected EnvoyFilters: %v", result.EnvoyFilters)
+				}
+				return
+			}
+			if len(result.EnvoyFilters) != 1 {
+				t.Fatalf("GenerateUDPListenerFilter() got %d EnvoyFilters, want 1", len(result.EnvoyFilters))
+			}
+			patches := result.EnvoyFilters[0].Envoyfilter.ConfigPatches
+			if len(patches) != len(tt.addresses) {
+				t.Fatalf("got %d patches, want one per VIP", len(patches))
+			}
+			for i, patch := range patches {
+				if patch.ApplyTo != networking.EnvoyFilter_LISTENER ||
+					patch.Patch.Operation != networking.EnvoyFilter_Patch_ADD ||
+					patch.Match.Context != networking.EnvoyFilter_SIDECAR_OUTBOUND {
+					t.Errorf("patch = %v, want an outbound listener added", patch)
+				}
+				listener := patch.Patch.Value.Fields
+				if name := listener["name"].GetStringValue(); name != tt.addresses[i]+"_5683_udp" {
+					t.Errorf("listener name = %v, want %v_5683_udp", name, tt.addresses[i])
+				}
+				socketAddress := listener["address"].GetStructValue().Fields["socketAddress"].GetStructValue().Fields
+				if socketAddress["protocol"].GetStringValue() != "UDP" ||
+					socketAddress["address"].GetStringValue() != tt.addresses[i] ||
+					socketAddress["portValue"].GetNumberValue() != 5683 {
+					t.Errorf("listener address = %v, want UDP %v:5683", socketAddress, tt.addresses[i])
+				}
+				filters := listener["listenerFilters"].GetListValue().GetValues()
+				if len(filters) != 1 {
+					t.Fatalf("listener filters = %v, want the udp_proxy filter", filters)
+				}
+				filter := filters[0].GetStructValue().Fields
+				if name := filter["name"].GetStringValue(); name != "envoy.filters.udp_listener.udp_proxy" {
+					t.Errorf("listener filter = %v, want envoy.filters.udp_listener.udp_proxy", name)
+				}
+				typedConfig := filter["typed_config"].GetStructValue().Fields
+				if got := typedConfig["type_url"].GetStringValue(); got !=
+					"type.googleapis.com/envoy.extensions.filters.udp.udp_proxy.v3.UdpProxyConfig" {
+					t.Errorf("typed_config type_url = %v, want UdpProxyConfig", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	// upstreamProtocolHTTP2 sends the upstream requests of a service over HTTP/2
+	upstreamProtocolHTTP2 = "http2"
+	// upstreamProtocolTCP uses plain TCP connections to the upstream hosts of a service
+	upstreamProtocolTCP = "tcp"
+
+	httpProtocolOptions = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+	tcpProtocolOptions  = "envoy.extensions.upstreams.tcp.v3.TcpProtocolOptions"
+)
+
+// upstreamProtocol returns the protocol of the upstream connections of a service set by the annotation of the service,
+// an empty string is returned if it's not set
+func upstreamProtocol(service *model.ServiceEntryWrapper) (string, error) {
+	value, ok := service.Annotations[constants.UpstreamProtocolAnnotation]
+	if !ok {
+		return "", nil
+	}
+	switch value {
+	case upstreamProtocolHTTP2, upstreamProtocolTCP:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation: %s, it should be %s or %s", constants.UpstreamProtocolAnnotation,
+			value, upstreamProtocolHTTP2, upstreamProtocolTCP)
+	}
+}
+
+// upstreamProtocolClusterPatch sets the protocol options of the upstream connections of all the subset clusters of a
+// service port
+func upstreamProtocolClusterPatch(host string, port uint32,
+	protocol string) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	if protocol == upstreamProtocolTCP {
+		return protocolOptionsClusterPatch(host, port, tcpProtocolOptions, map[string]*types.Value{})
+	}
+	return protocolOptionsClusterPatch(host, port, httpProtocolOptions, map[string]*types.Value{
+		"explicit_http_config": {Kind: &types.Value_StructValue{StructValue: &types.Struct{
+			Fields: map[string]*types.Value{
+				"http2_protocol_options": {Kind: &types.Value_StructValue{StructValue: &types.Struct{
+					Fields: map[string]*types.Value{},
+				}}},
+			},
+		}}},
+	})
+}
+
+// protocolOptionsClusterPatch merges the protocol options of the given type into the typed_extension_protocol_options
+// of all the subset clusters of a service port
+func protocolOptionsClusterPatch(host string, port uint32, optionsType string,
+	options map[string]*types.Value) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	options["@type"] = &types.Value{Kind: &types.Value_StringValue{StringValue: typeURLPrefix + optionsType}}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_CLUSTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &networking.EnvoyFilter_ClusterMatch{
+					PortNumber: port,
+					Service:    host,
+				},
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: networking.EnvoyFilter_Patch_MERGE,
+			Value: &types.Struct{
+				Fields: map[string]*types.Value{
+					"typed_extension_protocol_options": {
+						Kind: &types.Value_StructValue{StructValue: &types.Struct{
+							Fields: map[string]*types.Value{
+								optionsType: {
+									Kind: &types.Value_StructValue{StructValue: &types.Struct{Fields: options}},
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	wasmfilter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
+	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+const (
+	wasmFilter         = "envoy.filters.network.wasm"
+	wasmType           = "type.googleapis.com/envoy.extensions.filters.network.wasm.v3.Wasm"
+	defaultWasmRuntime = "envoy.wasm.runtime.v8"
+	// wasmFetchTimeout is the timeout of fetching the code of a WASM filter from a remote source
+	wasmFetchTimeout = 30 * time.Second
+
+	wasmPositionBefore = "before"
+	wasmPositionAfter  = "after"
+)
+
+// wasmFilterConfig is the WASM network filter inserted next to the protocol filter of a service
+type wasmFilterConfig struct {
+	// Name is the name of the WASM plugin
+	Name string `json:"name"`
+	// RootID is the root context of the WASM plugin, the plugin may have more than one root context
+	RootID string `json:"rootId"`
+	// Runtime is the WASM runtime of the VM, defaults to envoy.wasm.runtime.v8
+	Runtime string `json:"runtime"`
+	// Filename is the path of the WASM code on the proxy
+	Filename string `json:"filename"`
+	// URL is the HTTP URL the WASM code is fetched from, via the given cluster
+	URL string `json:"url"`
+	// Cluster is the cluster the WASM code is fetched through
+	Cluster string `json:"cluster"`
+	// SHA256 is the checksum of the WASM code fetched from the URL
+	SHA256 string `json:"sha256"`
+	// Configuration is passed to the WASM plugin as a string
+	Configuration string `json:"configuration"`
+	// Position is where the WASM filter is inserted, before or after the protocol filter, defaults to before
+	Position string `json:"position"`
+}
+
+// wasmFilterOf returns the WASM network filter set by the annotation of a service. The code of the filter is either a
+// file on the proxy or fetched from a URL, the checksum is required for the remote code.
+func wasmFilterOf(service *model.ServiceEntryWrapper) (*wasmFilterConfig, bool, error) {
+	value, ok := service.Annotations[constants.WasmFilterAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	config := &wasmFilterConfig{}
+	if err := json.Unmarshal([]byte(value), config); err != nil {
+		return nil, false, fmt.Errorf("invalid %s annotation: %v", constants.WasmFilterAnnotation, err)
+	}
+	if config.Name == "" {
+		return nil, false, fmt.Errorf("invalid %s annotation: the name is required", constants.WasmFilterAnnotation)
+	}
+	if (config.Filename == "") == (config.URL == "") {
+		return nil, false, fmt.Errorf("invalid %s annotation: either the filename or the url is required",
+			constants.WasmFilterAnnotation)
+	}
+	if config.URL != "" && (config.Cluster == "" || config.SHA256 == "") {
+		return nil, false, fmt.Errorf("invalid %s annotation: the cluster and the sha256 are required by the url",
+			constants.WasmFilterAnnotation)
+	}
+	switch config.Position {
+	case "":
+		config.Position = wasmPositionBefore
+	case wasmPositionBefore, wasmPositionAfter:
+	default:
+		return nil, false, fmt.Errorf("invalid %s annotation: position %s, it should be %s or %s",
+			constants.WasmFilterAnnotation, config.Position, wasmPositionBefore, wasmPositionAfter)
+	}
+	if config.Runtime == "" {
+		config.Runtime = defaultWasmRuntime
+	}
+	return config, true, nil
+}
+
+// buildWasmFilter builds the config of the WASM network filter
+func buildWasmFilter(config *wasmFilterConfig) (*wasmfilter.Wasm, error) {
+	code := &core.AsyncDataSource{}
+	if config.Filename != "" {
+		code.Specifier = &core.AsyncDataSource_Local{Local: &core.DataSource{
+			Specifier: &core.DataSource_Filename{Filename: config.Filename},
+		}}
+	} else {
+		code.Specifier = &core.AsyncDataSource_Remote{Remote: &core.RemoteDataSource{
+			HttpUri: &core.HttpUri{
+				Uri:              config.URL,
+				HttpUpstreamType: &core.HttpUri_Cluster{Cluster: config.Cluster},
+				Timeout:          durationpb.New(wasmFetchTimeout),
+			},
+			Sha256: config.SHA256,
+		}}
+	}
+	plugin := &wasm.PluginConfig{
+		Name:   config.Name,
+		RootId: config.RootID,
+		Vm: &wasm.PluginConfig_VmConfig{VmConfig: &wasm.VmConfig{
+			Runtime: config.Runtime,
+			Code:    code,
+		}},
+	}
+	if config.Configuration != "" {
+		configuration, err := anypb.New(wrapperspb.String(config.Configuration))
+		if err != nil {
+			return nil, err
+		}
+		plugin.Configuration = configuration
+	}
+	return &wasmfilter.Wasm{Config: plugin}, nil
+}
+
+// wasmFilterPatch inserts the WASM filter before or after the protocol filter of a listener. The patch has to follow
+// the one which puts the protocol filter into the filter chain, since the patches are applied in order.
+func wasmFilterPatch(context networking.EnvoyFilter_PatchContext, listenerName string, port,
+	destinationPort uint32, filterName string,
+	config *wasmFilterConfig) (*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	filter, err := buildWasmFilter(config)
+	if err != nil {
+		return nil, err
+	}
+	value, err := generateValue(filter, wasmFilter, wasmType)
+	if err != nil {
+		return nil, err
+	}
+	operation := networking.EnvoyFilter_Patch_INSERT_BEFORE
+	if config.Position == wasmPositionAfter {
+		operation = networking.EnvoyFilter_Patch_INSERT_AFTER
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+		Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: context,
+			ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: listenerMatch(listenerName, port, &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+					DestinationPort: destinationPort,
+					Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+						Name: filterName,
+					},
+				}),
+			},
+		},
+		Patch: &networking.EnvoyFilter_Patch{
+			Operation: operation,
+			Value:     value,
+		},
+	}, nil
+}
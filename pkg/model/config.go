@@ -15,6 +15,8 @@
 package model
 
 import (
+	"fmt"
+
 	networking "istio.io/api/networking/v1alpha3"
 	istioconfig "istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/mesh"
@@ -53,9 +55,33 @@ type DestinationRuleWrapper struct {
 type EnvoyFilterWrapper struct {
 	Name        string
 	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
 	Envoyfilter *networking.EnvoyFilter
 }
 
+// GenerationResult is the result of an EnvoyFilter generation. Besides the generated EnvoyFilters, it carries the
+// warnings about the problems which don't fail the generation, such as an ignored invalid annotation, so they can be
+// surfaced to the users.
+type GenerationResult struct {
+	EnvoyFilters []*EnvoyFilterWrapper
+	Warnings     []string
+}
+
+// AddWarning adds a warning to the result
+func (r *GenerationResult) AddWarning(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Merge appends the EnvoyFilters and warnings of another result to the result
+func (r *GenerationResult) Merge(other *GenerationResult) {
+	if other == nil {
+		return
+	}
+	r.EnvoyFilters = append(r.EnvoyFilters, other.EnvoyFilters...)
+	r.Warnings = append(r.Warnings, other.Warnings...)
+}
+
 // EnvoyFilterContext provides an aggregate API for EnvoyFilter generator
 type EnvoyFilterContext struct {
 
@@ -78,4 +104,8 @@ type EnvoyFilterContext struct {
 	// Only one VirtualService is allowed for a Service.
 	// The value of VirtualService is nil in case that no VirtualService defined for the service.
 	MetaRouter *metaprotocol.MetaRouter
+
+	// DestinationRule is the related DestinationRule of the ServiceEntry, which defines the load balancing policy.
+	// The value of DestinationRule is nil in case that no DestinationRule defined for the service.
+	DestinationRule *DestinationRuleWrapper
 }
@@ -16,6 +16,7 @@ package metaprotocol
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -26,6 +27,16 @@ var applicationProtocols = map[string]string{
 	"thrift": "aeraki.meta_protocol.codec.thrift",
 }
 
+// builtinAttributes holds the names of the attributes extracted by the built-in codecs
+var builtinAttributes = map[string][]string{
+	"dubbo":  {"interface", "method"},
+	"thrift": {"method"},
+}
+
+// applicationProtocolAttributes holds the names of the attributes declared in the ApplicationProtocols, which are
+// added to the built-in ones
+var applicationProtocolAttributes = map[string][]string{}
+
 // SetApplicationProtocolCodec sets the codec for a specific protocol
 func SetApplicationProtocolCodec(protocol, codec string) {
 	lock.Lock()
@@ -44,6 +55,54 @@ func GetApplicationProtocolCodec(protocol string) (string, error) {
 	return "", fmt.Errorf("can't find codec for protocol: %s", protocol)
 }
 
+// SetApplicationProtocolAttributes sets the names of the attributes declared in the ApplicationProtocol of a specific
+// protocol, which are added to the attributes of the built-in codec
+func SetApplicationProtocolAttributes(protocol string, attributes []string) {
+	lock.Lock()
+	defer lock.Unlock()
+	if len(attributes) == 0 {
+		delete(applicationProtocolAttributes, protocol)
+		return
+	}
+	applicationProtocolAttributes[protocol] = append([]string(nil), attributes...)
+}
+
+// GetApplicationProtocolAttributes gets the sorted names of the attributes extracted by the codec of a specific
+// protocol
+func GetApplicationProtocolAttributes(protocol string) []string {
+	lock.Lock()
+	defer lock.Unlock()
+	return attributesOf(protocol)
+}
+
+// ValidateApplicationProtocolAttribute checks whether an attribute is declared by a specific protocol
+func ValidateApplicationProtocolAttribute(protocol, attribute string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	for _, name := range attributesOf(protocol) {
+		if name == attribute {
+			return nil
+		}
+	}
+	return fmt.Errorf("attribute %s is not declared by application protocol: %s", attribute, protocol)
+}
+
+func attributesOf(protocol string) []string {
+	declared := make(map[string]bool)
+	for _, name := range builtinAttributes[protocol] {
+		declared[name] = true
+	}
+	for _, name := range applicationProtocolAttributes[protocol] {
+		declared[name] = true
+	}
+	attributes := make([]string, 0, len(declared))
+	for name := range declared {
+		attributes = append(attributes, name)
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
 // GetApplicationProtocolFromPortName extracts the application protocol name from metaprotocol port name
 func GetApplicationProtocolFromPortName(portName string) (string, error) {
 	s := strings.Split(portName, "-")
@@ -0,0 +1,93 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateApplicationProtocolAttribute(t *testing.T) {
+	SetApplicationProtocolAttributes("test-protocol", []string{"user-id"})
+	defer SetApplicationProtocolAttributes("test-protocol", nil)
+
+	tests := []struct {
+		name      string
+		protocol  string
+		attribute string
+		wantErr   bool
+	}{
+		{
+			name:      "built-in attribute",
+			protocol:  "dubbo",
+			attribute: "interface",
+		},
+		{
+			name:      "declared attribute",
+			protocol:  "test-protocol",
+			attribute: "user-id",
+		},
+		{
+			name:      "undeclared attribute",
+			protocol:  "test-protocol",
+			attribute: "method",
+			wantErr:   true,
+		},
+		{
+			name:      "unknown protocol",
+			protocol:  "unknown",
+			attribute: "user-id",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateApplicationProtocolAttribute(tt.protocol, tt.attribute); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateApplicationProtocolAttribute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetApplicationProtocolAttributes(t *testing.T) {
+	attributes := []string{"user-id", "tenant"}
+	SetApplicationProtocolAttributes("test-protocol", attributes)
+	defer SetApplicationProtocolAttributes("test-protocol", nil)
+
+	// neither the input nor the output should share the registered attributes
+	attributes[0] = "changed"
+	got := GetApplicationProtocolAttributes("test-protocol")
+	if want := []string{"tenant", "user-id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetApplicationProtocolAttributes() = %v, want %v", got, want)
+	}
+	got[0] = "changed"
+	if GetApplicationProtocolAttributes("test-protocol")[0] != "tenant" {
+		t.Errorf("GetApplicationProtocolAttributes() should return a copy")
+	}
+}
+
+func TestSetApplicationProtocolAttributesKeepsBuiltin(t *testing.T) {
+	SetApplicationProtocolAttributes("dubbo", []string{"user-id", "method"})
+	got := GetApplicationProtocolAttributes("dubbo")
+	if want := []string{"interface", "method", "user-id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetApplicationProtocolAttributes() = %v, want %v", got, want)
+	}
+
+	SetApplicationProtocolAttributes("dubbo", nil)
+	got = GetApplicationProtocolAttributes("dubbo")
+	if want := []string{"interface", "method"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetApplicationProtocolAttributes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultIdleTimeouts holds the default idle timeouts of the downstream connections of the application protocols, which
+// are used for the services without the downstream idle timeout annotation
+var defaultIdleTimeouts = map[string]time.Duration{}
+
+// SetDefaultIdleTimeouts replaces the default idle timeouts of the downstream connections of the application protocols
+func SetDefaultIdleTimeouts(timeouts map[string]time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+	defaultIdleTimeouts = make(map[string]time.Duration, len(timeouts))
+	for protocol, timeout := range timeouts {
+		defaultIdleTimeouts[protocol] = timeout
+	}
+}
+
+// GetDefaultIdleTimeout gets the default idle timeout of the downstream connections of a specific protocol, it returns
+// false if the protocol has no default idle timeout
+func GetDefaultIdleTimeout(protocol string) (time.Duration, bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	timeout, ok := defaultIdleTimeouts[protocol]
+	return timeout, ok
+}
+
+// ParseDefaultTimeouts parses the default timeouts of the application protocols from a comma separated list of
+// protocol=duration pairs, such as dubbo=10m,thrift=5m
+func ParseDefaultTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		protocol, duration, found := strings.Cut(pair, "=")
+		protocol = strings.TrimSpace(protocol)
+		if !found || protocol == "" {
+			return nil, fmt.Errorf("invalid default timeout: %s, it should be protocol=duration", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid default timeout of protocol %s: %s, it should be a positive duration",
+				protocol, duration)
+		}
+		timeouts[protocol] = timeout
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,78 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDefaultTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  map[string]time.Duration{},
+		},
+		{
+			name:  "timeouts",
+			value: "dubbo=10s, thrift=500ms",
+			want:  map[string]time.Duration{"dubbo": 10 * time.Second, "thrift": 500 * time.Millisecond},
+		},
+		{
+			name:    "no duration",
+			value:   "dubbo",
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			value:   "dubbo=ten",
+			wantErr: true,
+		},
+		{
+			name:    "negative duration",
+			value:   "dubbo=-1s",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDefaultTimeouts(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDefaultTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDefaultTimeouts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDefaultIdleTimeout(t *testing.T) {
+	SetDefaultIdleTimeouts(map[string]time.Duration{"dubbo": time.Minute})
+	defer SetDefaultIdleTimeouts(nil)
+	if timeout, ok := GetDefaultIdleTimeout("dubbo"); !ok || timeout != time.Minute {
+		t.Errorf("GetDefaultIdleTimeout() = %v, %v, want 1m, true", timeout, ok)
+	}
+	if _, ok := GetDefaultIdleTimeout("thrift"); ok {
+		t.Errorf("GetDefaultIdleTimeout() = true, want a protocol without default idle timeout")
+	}
+}
@@ -38,6 +38,12 @@ const (
 	Zookeeper Instance = "Zookeeper"
 	// MetaProtocol declares that the port carries MetaProtocol traffic.
 	MetaProtocol Instance = "MetaProtocol"
+	// Raw declares that the port carries the traffic of a protocol whose filter config is supplied as raw JSON.
+	Raw Instance = "Raw"
+	// GRPCWeb declares that the port carries gRPC traffic which is bridged from gRPC-Web by the proxy.
+	GRPCWeb Instance = "GRPCWeb"
+	// Triple declares that the port carries Dubbo3 Triple traffic, which is gRPC compatible and runs over HTTP/2.
+	Triple Instance = "Triple"
 	// Unsupported - value to signify that the protocol is unsupported.
 	Unsupported Instance = "UnsupportedProtocol"
 )
@@ -54,6 +60,9 @@ func init() {
 	protocolMap["kafka"] = Kafka
 	protocolMap["zookeeper"] = Zookeeper
 	protocolMap["metaprotocol"] = MetaProtocol
+	protocolMap["raw"] = Raw
+	protocolMap["grpcweb"] = GRPCWeb
+	protocolMap["triple"] = Triple
 }
 
 // RegisterProtocol register custom protocol
@@ -29,6 +29,9 @@ func Test_getLayer7ProtocolFromPortName(t *testing.T) {
 		{testName: "tcp-dubbo", portName: "tcp-dubbo", want: protocol.Dubbo},
 		{testName: "tcp-Dubbo", portName: "tcp-Dubbo", want: protocol.Dubbo},
 		{testName: "tcp-Dubbo-28001", portName: "tcp-Dubbo-28001", want: protocol.Dubbo},
+		{testName: "tcp-raw-echo", portName: "tcp-raw-echo", want: protocol.Raw},
+		{testName: "grpc-grpcweb-echo", portName: "grpc-grpcweb-echo", want: protocol.GRPCWeb},
+		{testName: "grpc-triple-greeter", portName: "grpc-triple-greeter", want: protocol.Triple},
 		{testName: "Dubbo", portName: "Dubbo", want: protocol.Unsupported},
 	}
 	for _, tt := range tests {
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"strconv"
 	"strings"
+	"sync"
 
 	networking "istio.io/api/networking/v1alpha3"
 
@@ -35,9 +36,36 @@ const (
 	TrafficDirectionOutbound TrafficDirection = "outbound"
 )
 
-// BuildClusterName the cluster name referencing service instances for a given service name, a subset and a port,
-// which is the same as the cluster name generated by Istio.
+// ClusterNameFormatter builds the cluster name referencing service instances for a given service name, a subset and
+// a port.
+type ClusterNameFormatter func(direction TrafficDirection, subsetName, hostname string, port int) string
+
+var (
+	formatterLock        sync.RWMutex
+	clusterNameFormatter ClusterNameFormatter = IstioClusterNameFormatter
+)
+
+// SetClusterNameFormatter overrides the formatter used by BuildClusterName. Passing a nil formatter restores the
+// Istio default.
+func SetClusterNameFormatter(formatter ClusterNameFormatter) {
+	formatterLock.Lock()
+	defer formatterLock.Unlock()
+	if formatter == nil {
+		formatter = IstioClusterNameFormatter
+	}
+	clusterNameFormatter = formatter
+}
+
+// BuildClusterName the cluster name referencing service instances for a given service name, a subset and a port.
+// The Istio naming scheme is used unless it has been overridden by SetClusterNameFormatter.
 func BuildClusterName(direction TrafficDirection, subsetName, hostname string, port int) string {
+	formatterLock.RLock()
+	defer formatterLock.RUnlock()
+	return clusterNameFormatter(direction, subsetName, hostname, port)
+}
+
+// IstioClusterNameFormatter builds the cluster name in the same way as Istio, e.g. outbound|port|subset|host.
+func IstioClusterNameFormatter(direction TrafficDirection, subsetName, hostname string, port int) string {
 	if direction == TrafficDirectionInbound {
 		// On 1.8+ Proxies, Istio uses format inbound|port||. Telemetry no longer requires the hostname
 		return istiomodel.BuildSubsetKey(istiomodel.TrafficDirection(direction), subsetName, "", port)
@@ -45,7 +73,18 @@ func BuildClusterName(direction TrafficDirection, subsetName, hostname string, p
 	return istiomodel.BuildSubsetKey(istiomodel.TrafficDirection(direction), subsetName, host.Name(hostname), port)
 }
 
-// BuildMetaProtocolRouteName the route name for a given metaProtocol service
+// DefaultMetaRouteName is the name of the route generated for the MetaProtocol services without MetaRouter routes
+const DefaultMetaRouteName = "default"
+
+// FallbackMetaRouteName is the name of the catch-all route to the fallback cluster of a MetaRouter
+const FallbackMetaRouteName = "fallback"
+
+// PassthroughMetaRouteName is the name of the catch-all route to the original destination of the unmatched requests
+const PassthroughMetaRouteName = "passthrough"
+
+// BuildMetaProtocolRouteName the route name for a given metaProtocol service.
+// The name is unique for each host and port, both the MetaProtocol proxy and the RDS server must use it to key the
+// route configuration.
 func BuildMetaProtocolRouteName(host string, port int) string {
 	return host + "_" + strconv.Itoa(port)
 }
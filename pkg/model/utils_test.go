@@ -0,0 +1,120 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuildClusterName(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction TrafficDirection
+		subset    string
+		host      string
+		port      int
+		want      string
+	}{
+		{
+			name:      "outbound",
+			direction: TrafficDirectionOutbound,
+			host:      "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port:      9090,
+			want:      "outbound|9090||thrift-sample-server.meta-thrift.svc.cluster.local",
+		},
+		{
+			name:      "outbound with subset",
+			direction: TrafficDirectionOutbound,
+			subset:    "v1",
+			host:      "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port:      9090,
+			want:      "outbound|9090|v1|thrift-sample-server.meta-thrift.svc.cluster.local",
+		},
+		{
+			name:      "inbound",
+			direction: TrafficDirectionInbound,
+			host:      "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port:      9090,
+			want:      "inbound|9090||",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildClusterName(tt.direction, tt.subset, tt.host, tt.port); got != tt.want {
+				t.Errorf("BuildClusterName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetClusterNameFormatter(t *testing.T) {
+	defer SetClusterNameFormatter(nil)
+
+	SetClusterNameFormatter(func(direction TrafficDirection, subsetName, hostname string, port int) string {
+		return fmt.Sprintf("%s~%s~%s~%d", direction, hostname, subsetName, port)
+	})
+	want := "outbound~dubbo.example.com~v1~20880"
+	if got := BuildClusterName(TrafficDirectionOutbound, "v1", "dubbo.example.com", 20880); got != want {
+		t.Errorf("BuildClusterName() = %v, want %v", got, want)
+	}
+
+	SetClusterNameFormatter(nil)
+	want = "outbound|20880|v1|dubbo.example.com"
+	if got := BuildClusterName(TrafficDirectionOutbound, "v1", "dubbo.example.com", 20880); got != want {
+		t.Errorf("BuildClusterName() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildMetaProtocolRouteName(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		port int
+		want string
+	}{
+		{
+			name: "thrift",
+			host: "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port: 9090,
+			want: "thrift-sample-server.meta-thrift.svc.cluster.local_9090",
+		},
+		{
+			name: "same host with another port",
+			host: "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port: 9091,
+			want: "thrift-sample-server.meta-thrift.svc.cluster.local_9091",
+		},
+		{
+			name: "another host with the same port",
+			host: "dubbo-sample-provider.meta-dubbo.svc.cluster.local",
+			port: 9090,
+			want: "dubbo-sample-provider.meta-dubbo.svc.cluster.local_9090",
+		},
+	}
+	names := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMetaProtocolRouteName(tt.host, tt.port)
+			if got != tt.want {
+				t.Errorf("BuildMetaProtocolRouteName() = %v, want %v", got, tt.want)
+			}
+			if other, ok := names[got]; ok {
+				t.Errorf("BuildMetaProtocolRouteName() = %v, clashes with %s", got, other)
+			}
+			names[got] = tt.name
+		})
+	}
+}
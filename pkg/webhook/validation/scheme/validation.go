@@ -203,9 +203,10 @@ var ValidateMetaRouter = func(cfg config.Config) (validation.Warning, error) {
 		}
 	}
 
-	if len(metaRouter.Routes) == 0 && metaRouter.GlobalRateLimit == nil && metaRouter.LocalRateLimit == nil {
+	if len(metaRouter.Routes) == 0 && metaRouter.GlobalRateLimit == nil && metaRouter.LocalRateLimit == nil &&
+		metaRouter.OutlierDetection == nil {
 		errs = appendValidation(errs, errors.New("meta router must at least have one of routes, globalRateLimit,"+
-			" or LocalRateLimit"))
+			" LocalRateLimit or outlierDetection"))
 	}
 
 	for _, route := range metaRouter.Routes {
@@ -216,6 +217,12 @@ var ValidateMetaRouter = func(cfg config.Config) (validation.Warning, error) {
 		errs = appendValidation(errs, validateMetaRoute(route))
 	}
 
+	errs = appendValidation(errs, validateDestination(metaRouter.FallbackCluster))
+	if metaRouter.FallbackCluster != nil && metaRouter.PassthroughUnmatched {
+		errs = appendValidation(errs, errors.New("meta router can't have both fallbackCluster and "+
+			"passthroughUnmatched"))
+	}
+
 	errs = appendValidation(errs, validateExportTo(cfg.Namespace, metaRouter.ExportTo))
 
 	warnUnused := func(ruleno, reason string) {
@@ -238,6 +245,7 @@ var ValidateMetaRouter = func(cfg config.Config) (validation.Warning, error) {
 
 	errs = appendValidation(errs, validateGlobalRateLimit(metaRouter.GlobalRateLimit))
 	errs = appendValidation(errs, validateLocalRateLimit(metaRouter.LocalRateLimit))
+	errs = appendValidation(errs, validateOutlierDetection(metaRouter.OutlierDetection))
 	return errs.Unwrap()
 }
 
@@ -289,6 +297,23 @@ func validateTokenBucket(bucket *metaprotocol.LocalRateLimit_TokenBucket) (errs
 	return
 }
 
+func validateOutlierDetection(outlier *metaprotocol.OutlierDetection) (errs error) {
+	if outlier == nil {
+		return
+	}
+	if outlier.ConsecutiveErrors < 1 {
+		errs = appendErrors(errs, errors.New("outlierDetection consecutiveErrors must be greater than 0"))
+	}
+	if outlier.Interval != nil {
+		errs = appendErrors(errs, validation.ValidateDuration(outlier.Interval))
+	}
+	if outlier.BaseEjectionTime != nil {
+		errs = appendErrors(errs, validation.ValidateDuration(outlier.BaseEjectionTime))
+	}
+	errs = appendErrors(errs, ValidatePercent(outlier.MaxEjectionPercent))
+	return
+}
+
 func validateNoneEmptyString(str, name string) error {
 	if str == "" {
 		return errors.New(name + " cannot be empty")
@@ -344,25 +369,49 @@ func validateMetaRoute(route *metaprotocol.MetaRoute) (errs Validation) {
 		errs = appendValidation(errs, fmt.Errorf("mirrorPercentage and mirror must be set together"))
 	}
 
-	if route.MirrorPercentage != nil {
-		value := route.MirrorPercentage.GetValue()
-		if value > 100 {
-			errs = appendValidation(errs, fmt.Errorf("mirrorPercentage must not be greater than 100 (it has %f)",
-				value))
-		}
-		if value <= 0 {
-			errs = appendValidation(errs, fmt.Errorf("mirrorPercentage must not be less than or equal to 0 ("+
-				"it has %f)",
-				value))
+	errs = appendValidation(errs, validateMirrorPercentage(route.MirrorPercentage, "mirrorPercentage"))
+	errs = appendValidation(errs, validateDestination(route.Mirror))
+	for i, mirror := range route.Mirrors {
+		if mirror.GetDestination() == nil {
+			errs = appendValidation(errs, fmt.Errorf("mirrors[%d] must have a destination", i))
 		}
+		errs = appendValidation(errs, validateDestination(mirror.GetDestination()))
+		errs = appendValidation(errs, validateMirrorPercentage(mirror.GetPercentage(),
+			fmt.Sprintf("mirrors[%d].percentage", i)))
 	}
-
-	errs = appendValidation(errs, validateDestination(route.Mirror))
 	errs = appendValidation(errs, validateMetaRouteDestinations(route.Route))
+	errs = appendValidation(errs, validateHashPolicy(route.HashPolicy))
 
 	return errs
 }
 
+// validateMirrorPercentage checks that a mirror percentage is in (0, 100]
+func validateMirrorPercentage(percentage *metaprotocol.Percent, name string) (errs error) {
+	if percentage == nil {
+		return nil
+	}
+	value := percentage.GetValue()
+	if value > 100 {
+		errs = appendErrors(errs, fmt.Errorf("%s must not be greater than 100 (it has %f)", name, value))
+	}
+	if value <= 0 {
+		errs = appendErrors(errs, fmt.Errorf("%s must not be less than or equal to 0 (it has %f)", name, value))
+	}
+	return errs
+}
+
+func validateHashPolicy(hashPolicy *metaprotocol.HashPolicy) (errs error) {
+	if hashPolicy != nil {
+		if len(hashPolicy.Attributes) == 0 {
+			errs = appendErrors(errs, errors.New("hashPolicy must have at least one attribute"))
+		}
+		for _, attribute := range hashPolicy.Attributes {
+			errs = appendErrors(errs, ValidateMetaAttributeName(attribute))
+		}
+	}
+	return errs
+}
+
 func validateMetaRouteMatch(match *metaprotocol.MetaRouteMatch) (errs error) {
 	if match != nil {
 		for name, attribute := range match.Attributes {
@@ -371,6 +420,7 @@ func validateMetaRouteMatch(match *metaprotocol.MetaRouteMatch) (errs error) {
 			}
 			errs = appendErrors(errs, ValidateMetaAttributeName(name))
 			errs = appendErrors(errs, validateStringMatchRegexp(attribute, "attributes"))
+			errs = appendErrors(errs, validateStringMatchRange(attribute, "attributes"))
 		}
 	}
 	return errs
@@ -445,6 +495,18 @@ func validateStringMatchRegexp(sm *metaprotocol.StringMatch, where string) error
 		where, err)
 }
 
+func validateStringMatchRange(sm *metaprotocol.StringMatch, where string) error {
+	r := sm.GetRange()
+	if r == nil {
+		return nil
+	}
+	if r.End <= r.Start {
+		return fmt.Errorf("%q: the end of range string match should be greater than its start, got [%d, %d)",
+			where, r.Start, r.End)
+	}
+	return nil
+}
+
 func validateMetaRouteDestinations(destinations []*metaprotocol.MetaRouteDestination) (errs error) {
 	if len(destinations) == 0 {
 		return errors.New("a route must has at least one destination")
@@ -17,6 +17,7 @@ package xds
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
 
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	istioconfig "istio.io/istio/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -34,13 +36,13 @@ import (
 	metaprotocol "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
 
 	"github.com/aeraki-mesh/aeraki/pkg/model"
+	metaprotocolmodel "github.com/aeraki-mesh/aeraki/pkg/model/metaprotocol"
 
 	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	networking "istio.io/api/networking/v1alpha3"
 
 	metaroute "github.com/aeraki-mesh/meta-protocol-control-plane-api/aeraki/meta_protocol_proxy/config/route/v1alpha"
 
-	"github.com/zhaohuabing/debounce"
 	istiomodel "istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/schema/collections"
 )
@@ -62,7 +64,7 @@ type CacheMgr struct {
 	configStore                istiomodel.ConfigStore
 	routeCache                 cachev3.SnapshotCache
 	// Sending on this channel results in a push.
-	pushChannel chan istiomodel.Event
+	pushChannel chan pushEvent
 }
 
 // NewCacheMgr creates a new controller instance based on the provided arguments.
@@ -70,7 +72,7 @@ func NewCacheMgr(store istiomodel.ConfigStore) *CacheMgr {
 	controller := &CacheMgr{
 		configStore: store,
 		routeCache:  cachev3.NewSnapshotCache(false, cachev3.IDHash{}, logger{}),
-		pushChannel: make(chan istiomodel.Event, 100),
+		pushChannel: make(chan pushEvent, 100),
 	}
 	return controller
 }
@@ -101,18 +103,18 @@ func (c *CacheMgr) mainLoop(stop <-chan struct{}) {
 				return
 			}
 			retries++
-			c.pushChannel <- istiomodel.EventUpdate
+			c.pushChannel <- pushEvent{event: istiomodel.EventUpdate, debounceAfter: debounceAfter}
 			return
 		}
 		retries = 0
 		xdsLog.Infof("route cache updated")
 	}
-	debouncer := debounce.New(debounceAfter, debounceMax, callback, stop)
+	debouncers := newDebouncers(callback, stop)
 	for {
 		select {
 		case e := <-c.pushChannel:
-			xdsLog.Debugf("receive event from push chanel : %v", e)
-			debouncer.Bounce()
+			xdsLog.Debugf("receive event from push chanel : %v, debounce after: %v", e.event, e.debounceAfter)
+			debouncers.bounce(e.debounceAfter)
 		case <-stop:
 			break
 		}
@@ -194,18 +196,28 @@ func (c *CacheMgr) generateMetaRoutes(serviceEntries []istioconfig.Config) []*me
 				if destinationRule != nil {
 					xdsLog.Debugf("find destination rule ：%s for : %s", destinationRule.Name, config.Name)
 				}
-				if metaRouter != nil {
-					routes = append(routes, c.constructRoute(service, port, metaRouter, destinationRule))
-				} else {
+				if metaRouter == nil {
 					xdsLog.Debugf("no meta router for : %s", config.Name)
-					routes = append(routes, c.defaultRoute(service, port, destinationRule))
 				}
+				routes = append(routes, BuildMetaRouteConfiguration(service, port, metaRouter, destinationRule))
 			}
 		}
 	}
 	return routes
 }
 
+// BuildMetaRouteConfiguration builds the MetaProtocol route configuration of a service port from the related
+// MetaRouter and DestinationRule, a default route to the service is used if the service has no MetaRouter. A MetaRouter
+// without routes gives a route configuration without routes, so the requests are rejected unless the MetaRouter
+// specifies how to handle the unmatched requests.
+func BuildMetaRouteConfiguration(service *networking.ServiceEntry, port *networking.Port,
+	metaRouter *metaprotocol.MetaRouter, dr *model.DestinationRuleWrapper) *metaroute.RouteConfiguration {
+	if metaRouter == nil {
+		return defaultRoute(service, port, dr)
+	}
+	return constructRoute(service, port, metaRouter, dr)
+}
+
 func isMetaProtocolService(service *networking.ServiceEntry) bool {
 	for _, port := range service.Ports {
 		if protocol.GetLayer7ProtocolFromPortName(port.Name).IsMetaProtocol() {
@@ -215,19 +227,42 @@ func isMetaProtocolService(service *networking.ServiceEntry) bool {
 	return false
 }
 
-func (c *CacheMgr) constructRoute(service *networking.ServiceEntry,
+func constructRoute(service *networking.ServiceEntry,
 	port *networking.Port, metaRouter *metaprotocol.MetaRouter, dr *model.DestinationRuleWrapper) *metaroute.
 	RouteConfiguration {
 	var routes []*metaroute.Route
-	for _, route := range metaRouter.Spec.Routes {
+	for _, route := range sortMetaRoutes(metaRouter.Spec.Routes) {
 		routes = append(routes, &metaroute.Route{
 			Name: route.Name,
 			Match: &metaroute.RouteMatch{
 				Metadata: MetaMatch2HttpHeaderMatch(route.Match),
 			},
-			Route:            c.constructAction(port, route, dr),
-			RequestMutation:  c.constructMutation(route.RequestMutation),
-			ResponseMutation: c.constructMutation(route.ResponseMutation),
+			Route:            constructAction(port, route, dr),
+			RequestMutation:  constructMutation(route.RequestMutation),
+			ResponseMutation: constructMutation(route.ResponseMutation),
+		})
+	}
+	// The requests matching none of the routes go to the fallback cluster or pass through to their original
+	// destination instead of being rejected
+	if fallback := metaRouter.Spec.FallbackCluster; fallback != nil {
+		routes = append(routes, &metaroute.Route{
+			Name: model.FallbackMetaRouteName,
+			Match: &metaroute.RouteMatch{
+				Metadata: []*routev3.HeaderMatcher{},
+			},
+			Route: constructAction(port, &metaprotocolapi.MetaRoute{
+				Route: []*metaprotocolapi.MetaRouteDestination{{Destination: fallback}},
+			}, dr),
+		})
+	} else if metaRouter.Spec.PassthroughUnmatched {
+		routes = append(routes, &metaroute.Route{
+			Name: model.PassthroughMetaRouteName,
+			Match: &metaroute.RouteMatch{
+				Metadata: []*routev3.HeaderMatcher{},
+			},
+			Route: &metaroute.RouteAction{
+				ClusterSpecifier: &metaroute.RouteAction_Cluster{Cluster: istionetworking.PassthroughCluster},
+			},
 		})
 	}
 	// Currently, the routes for different port are the same, but we may need different routes for different ports in
@@ -239,7 +274,18 @@ func (c *CacheMgr) constructRoute(service *networking.ServiceEntry,
 	return &metaRoute
 }
 
-func (c *CacheMgr) constructAction(port *networking.Port,
+// sortMetaRoutes returns the routes in the order they are matched by the MetaProtocol router: the routes with higher
+// priorities go first, and the ties are broken by the order of the routes in the MetaRouter.
+func sortMetaRoutes(routes []*metaprotocolapi.MetaRoute) []*metaprotocolapi.MetaRoute {
+	sorted := make([]*metaprotocolapi.MetaRoute, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
+
+func constructAction(port *networking.Port,
 	route *metaprotocolapi.MetaRoute, dr *model.DestinationRuleWrapper) *metaroute.RouteAction {
 	var routeAction = &metaroute.RouteAction{}
 
@@ -293,37 +339,72 @@ func (c *CacheMgr) constructAction(port *networking.Port,
 		}
 
 		if route.Mirror != nil {
-			dstPort := port.Number
-			if route.Mirror.Port != nil && route.Mirror.Port.Number != 0 {
-				dstPort = route.Mirror.Port.Number
-			}
-			routeAction.RequestMirrorPolicies = []*metaroute.RouteAction_RequestMirrorPolicy{
-				{
-					Cluster: model.BuildClusterName(model.TrafficDirectionOutbound, route.Mirror.Subset,
-						route.Mirror.Host, int(dstPort)),
-				},
-			}
-			var mirrorPercent float64
-			mirrorPercent = 100
-			if route.MirrorPercentage != nil && route.MirrorPercentage.Value != 0 {
-				mirrorPercent = route.MirrorPercentage.Value
-			}
-			routeAction.RequestMirrorPolicies[0].RuntimeFraction = &corev3.RuntimeFractionalPercent{
-				DefaultValue: translatePercentToFractionalPercent(mirrorPercent),
+			routeAction.RequestMirrorPolicies = append(routeAction.RequestMirrorPolicies,
+				constructMirrorPolicy(port, route.Mirror, route.MirrorPercentage))
+		}
+		for _, mirror := range route.Mirrors {
+			if mirror.GetDestination() == nil {
+				continue
 			}
+			routeAction.RequestMirrorPolicies = append(routeAction.RequestMirrorPolicies,
+				constructMirrorPolicy(port, mirror.GetDestination(), mirror.GetPercentage()))
+		}
+
+		// the hash policy of the route takes precedence over the one defined in the DestinationRule
+		if route.HashPolicy != nil {
+			routeAction.HashPolicy = constructHashPolicy(port, route.HashPolicy)
 		}
 	}
 
 	return routeAction
 }
 
-func (c *CacheMgr) defaultRoute(service *networking.ServiceEntry, port *networking.Port,
+// constructMirrorPolicy builds the policy mirroring the given percentage of the traffic of a route to a destination,
+// all the traffic is mirrored if the percentage is not set
+func constructMirrorPolicy(port *networking.Port, mirror *metaprotocolapi.Destination,
+	percentage *metaprotocolapi.Percent) *metaroute.RouteAction_RequestMirrorPolicy {
+	dstPort := port.Number
+	if mirror.Port != nil && mirror.Port.Number != 0 {
+		dstPort = mirror.Port.Number
+	}
+	mirrorPercent := float64(100)
+	if percentage != nil && percentage.Value != 0 {
+		mirrorPercent = percentage.Value
+	}
+	return &metaroute.RouteAction_RequestMirrorPolicy{
+		Cluster: model.BuildClusterName(model.TrafficDirectionOutbound, mirror.Subset, mirror.Host, int(dstPort)),
+		RuntimeFraction: &corev3.RuntimeFractionalPercent{
+			DefaultValue: translatePercentToFractionalPercent(mirrorPercent),
+		},
+	}
+}
+
+// constructHashPolicy builds the hash policy from the attributes declared by the application protocol of the port,
+// the undeclared attributes are ignored because the codec can't extract them from the requests
+func constructHashPolicy(port *networking.Port, hashPolicy *metaprotocolapi.HashPolicy) []string {
+	applicationProtocol, err := metaprotocolmodel.GetApplicationProtocolFromPortName(port.Name)
+	if err != nil {
+		xdsLog.Errorf("failed to build hash policy: %v", err)
+		return nil
+	}
+	var policy []string
+	for _, attribute := range hashPolicy.Attributes {
+		if err := metaprotocolmodel.ValidateApplicationProtocolAttribute(applicationProtocol, attribute); err != nil {
+			xdsLog.Errorf("ignore hash attribute: %v", err)
+			continue
+		}
+		policy = append(policy, attribute)
+	}
+	return policy
+}
+
+func defaultRoute(service *networking.ServiceEntry, port *networking.Port,
 	dr *model.DestinationRuleWrapper) *metaroute.RouteConfiguration {
 	metaRoute := metaroute.RouteConfiguration{
 		Name: model.BuildMetaProtocolRouteName(service.Hosts[0], int(port.Number)),
 		Routes: []*metaroute.Route{
 			{
-				Name: "default",
+				Name: model.DefaultMetaRouteName,
 				Match: &metaroute.RouteMatch{
 					Metadata: []*routev3.HeaderMatcher{},
 				},
@@ -413,22 +494,26 @@ func (c *CacheMgr) findRelatedDestinationRule(service *model.ServiceEntryWrapper
 
 // ConfigUpdated sends a config change event to the pushChannel when Istio config changed
 func (c *CacheMgr) ConfigUpdated(prev, curr *istioconfig.Config, event istiomodel.Event) {
-	if c.shouldUpdateCache(curr) {
-		c.pushChannel <- event
-	} else if c.shouldUpdateCache(prev) {
-		c.pushChannel <- event
+	if interval, ok := c.shouldUpdateCache(curr); ok {
+		c.pushChannel <- pushEvent{event: event, debounceAfter: interval}
+	} else if interval, ok := c.shouldUpdateCache(prev); ok {
+		c.pushChannel <- pushEvent{event: event, debounceAfter: interval}
 	}
 }
 
-func (c *CacheMgr) shouldUpdateCache(config *istioconfig.Config) bool {
+// shouldUpdateCache returns whether the config change relates to a MetaProtocol service, and the debounce interval
+// of the route updates of that service
+func (c *CacheMgr) shouldUpdateCache(config *istioconfig.Config) (time.Duration, bool) {
 	var serviceEntry *networking.ServiceEntry
+	var annotations map[string]string
 	if config.GroupVersionKind == collections.IstioNetworkingV1Alpha3Serviceentries.Resource().GroupVersionKind() {
 		service, ok := config.Spec.(*networking.ServiceEntry)
 		if !ok {
 			xdsLog.Errorf("Failed in getting a service entry: %v", config.Name)
-			return false
+			return 0, false
 		}
 		serviceEntry = service
+		annotations = config.Annotations
 	}
 
 	// Cache needs to be updated if dr changed, the hash policy in the dr is used to generate routes
@@ -436,7 +521,7 @@ func (c *CacheMgr) shouldUpdateCache(config *istioconfig.Config) bool {
 		dr, ok := config.Spec.(*networking.DestinationRule)
 		if !ok {
 			xdsLog.Errorf("Failed in getting a destination rule: %v", config.Name)
-			return false
+			return 0, false
 		}
 
 		se, err := c.findRelatedServiceEntry(&model.DestinationRuleWrapper{
@@ -448,6 +533,7 @@ func (c *CacheMgr) shouldUpdateCache(config *istioconfig.Config) bool {
 		}
 		if se != nil {
 			serviceEntry = se.Spec
+			annotations = se.Annotations
 		}
 	}
 
@@ -455,23 +541,23 @@ func (c *CacheMgr) shouldUpdateCache(config *istioconfig.Config) bool {
 		for _, port := range serviceEntry.Ports {
 			if strings.HasPrefix(port.Name,
 				"tcp-metaprotocol") {
-				return true
+				return rdsDebounce(annotations), true
 			}
 		}
 	}
-	return false
+	return 0, false
 }
 
 // UpdateRoute sends a config change event to the pushChannel when Meta Router changed
 func (c *CacheMgr) UpdateRoute() {
-	c.pushChannel <- istiomodel.EventUpdate
+	c.pushChannel <- pushEvent{event: istiomodel.EventUpdate, debounceAfter: debounceAfter}
 }
 
 func (c *CacheMgr) initNode(_ string) {
 	// send a update event to pushChannel to trigger initialization of cache for a node.
 	// we use update event here because update events are debounced, so the initialization of a large number of nodes
 	// won't cause high cpu consumption.
-	c.pushChannel <- istiomodel.EventUpdate
+	c.pushChannel <- pushEvent{event: istiomodel.EventUpdate, debounceAfter: debounceAfter}
 }
 
 func (c *CacheMgr) hasNode(node string) bool {
@@ -485,7 +571,7 @@ func (c *CacheMgr) cache() cachev3.SnapshotCache {
 	return c.routeCache
 }
 
-func (c *CacheMgr) constructMutation(mutation []*metaprotocolapi.KeyValue) []*metaroute.KeyValue {
+func constructMutation(mutation []*metaprotocolapi.KeyValue) []*metaroute.KeyValue {
 	var result []*metaroute.KeyValue
 	for _, keyValue := range mutation {
 		result = append(result, &metaroute.KeyValue{Key: keyValue.Key, Value: keyValue.Value})
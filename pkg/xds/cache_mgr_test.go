@@ -0,0 +1,502 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"reflect"
+	"testing"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/proto"
+	networking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+
+	metaprotocolapi "github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	metaprotocol "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	metaprotocolmodel "github.com/aeraki-mesh/aeraki/pkg/model/metaprotocol"
+)
+
+func Test_constructActionHashPolicy(t *testing.T) {
+	metaprotocolmodel.SetApplicationProtocolAttributes("testproto", []string{"user-id", "method"})
+	defer metaprotocolmodel.SetApplicationProtocolAttributes("testproto", nil)
+
+	port := &networking.Port{Number: 20880, Name: "tcp-metaprotocol-testproto"}
+	destination := []*metaprotocolapi.MetaRouteDestination{
+		{Destination: &metaprotocolapi.Destination{Host: "test.example.com"}},
+	}
+	dr := &model.DestinationRuleWrapper{
+		Meta: istioconfig.Meta{Name: "test", Namespace: "test"},
+		Spec: &networking.DestinationRule{
+			Host: "test.example.com",
+			TrafficPolicy: &networking.TrafficPolicy{
+				LoadBalancer: &networking.LoadBalancerSettings{
+					LbPolicy: &networking.LoadBalancerSettings_ConsistentHash{
+						ConsistentHash: &networking.LoadBalancerSettings_ConsistentHashLB{
+							HashKey: &networking.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{
+								HttpHeaderName: "method",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		route *metaprotocolapi.MetaRoute
+		dr    *model.DestinationRuleWrapper
+		want  []string
+	}{
+		{
+			name: "hash on a declared attribute",
+			route: &metaprotocolapi.MetaRoute{
+				Route:      destination,
+				HashPolicy: &metaprotocolapi.HashPolicy{Attributes: []string{"user-id"}},
+			},
+			want: []string{"user-id"},
+		},
+		{
+			name: "undeclared attribute is ignored",
+			route: &metaprotocolapi.MetaRoute{
+				Route:      destination,
+				HashPolicy: &metaprotocolapi.HashPolicy{Attributes: []string{"user-id", "region"}},
+			},
+			want: []string{"user-id"},
+		},
+		{
+			name: "route hash policy takes precedence over destination rule",
+			route: &metaprotocolapi.MetaRoute{
+				Route:      destination,
+				HashPolicy: &metaprotocolapi.HashPolicy{Attributes: []string{"user-id"}},
+			},
+			dr:   dr,
+			want: []string{"user-id"},
+		},
+		{
+			name: "destination rule hash policy",
+			route: &metaprotocolapi.MetaRoute{
+				Route: destination,
+			},
+			dr:   dr,
+			want: []string{"method"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := constructAction(port, tt.route, tt.dr).HashPolicy
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("constructAction() hash policy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMetaRouteConfigurationAttributeMatch(t *testing.T) {
+	// testproto stands for a protocol declared by an ApplicationProtocol with a numeric attribute
+	metaprotocolmodel.SetApplicationProtocolCodec("testproto", "aeraki.meta_protocol.codec.testproto")
+	metaprotocolmodel.SetApplicationProtocolAttributes("testproto", []string{"code"})
+	defer metaprotocolmodel.SetApplicationProtocolAttributes("testproto", nil)
+	tests := []struct {
+		name      string
+		host      string
+		port      *networking.Port
+		attribute string
+		match     *metaprotocolapi.StringMatch
+		want      *routev3.HeaderMatcher
+	}{
+		{
+			name:      "request code range",
+			host:      "test-server.meta-test.svc.cluster.local",
+			port:      &networking.Port{Number: 10911, Name: "tcp-metaprotocol-testproto-server"},
+			attribute: "code",
+			match: &metaprotocolapi.StringMatch{
+				MatchType: &metaprotocolapi.StringMatch_Range{Range: &metaprotocolapi.Int64Range{Start: 10, End: 20}},
+			},
+			want: &routev3.HeaderMatcher{
+				Name:                 "code",
+				HeaderMatchSpecifier: &routev3.HeaderMatcher_RangeMatch{RangeMatch: &typev3.Int64Range{Start: 10, End: 20}},
+			},
+		},
+		{
+			name:      "single request code",
+			host:      "test-server.meta-test.svc.cluster.local",
+			port:      &networking.Port{Number: 10911, Name: "tcp-metaprotocol-testproto-server"},
+			attribute: "code",
+			match: &metaprotocolapi.StringMatch{
+				MatchType: &metaprotocolapi.StringMatch_Range{Range: &metaprotocolapi.Int64Range{Start: 10, End: 11}},
+			},
+			want: &routev3.HeaderMatcher{
+				Name:                 "code",
+				HeaderMatchSpecifier: &routev3.HeaderMatcher_RangeMatch{RangeMatch: &typev3.Int64Range{Start: 10, End: 11}},
+			},
+		},
+		{
+			name:      "request code range from zero",
+			host:      "test-server.meta-test.svc.cluster.local",
+			port:      &networking.Port{Number: 10911, Name: "tcp-metaprotocol-testproto-server"},
+			attribute: "code",
+			match: &metaprotocolapi.StringMatch{
+				MatchType: &metaprotocolapi.StringMatch_Range{Range: &metaprotocolapi.Int64Range{End: 16}},
+			},
+			want: &routev3.HeaderMatcher{
+				Name:                 "code",
+				HeaderMatchSpecifier: &routev3.HeaderMatcher_RangeMatch{RangeMatch: &typev3.Int64Range{End: 16}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applicationProtocol, err := metaprotocolmodel.GetApplicationProtocolFromPortName(tt.port.Name)
+			if err != nil {
+				t.Fatalf("GetApplicationProtocolFromPortName() unexpected error: %v", err)
+			}
+			if err := metaprotocolmodel.ValidateApplicationProtocolAttribute(applicationProtocol,
+				tt.attribute); err != nil {
+				t.Fatalf("ValidateApplicationProtocolAttribute() unexpected error: %v", err)
+			}
+
+			service := &networking.ServiceEntry{
+				Hosts: []string{tt.host},
+				Ports: []*networking.Port{tt.port},
+			}
+			metaRouter := &metaprotocol.MetaRouter{
+				Spec: metaprotocolapi.MetaRouter{
+					Hosts: []string{tt.host},
+					Routes: []*metaprotocolapi.MetaRoute{
+						{
+							Name: tt.name,
+							Match: &metaprotocolapi.MetaRouteMatch{
+								Attributes: map[string]*metaprotocolapi.StringMatch{tt.attribute: tt.match},
+							},
+							Route: []*metaprotocolapi.MetaRouteDestination{
+								{Destination: &metaprotocolapi.Destination{Host: tt.host, Subset: "v1"}},
+							},
+						},
+					},
+				},
+			}
+			routeConfig := BuildMetaRouteConfiguration(service, tt.port, metaRouter, nil)
+			if len(routeConfig.Routes) != 1 {
+				t.Fatalf("BuildMetaRouteConfiguration() got %d routes, want 1", len(routeConfig.Routes))
+			}
+			route := routeConfig.Routes[0]
+			if len(route.Match.Metadata) != 1 || !proto.Equal(route.Match.Metadata[0], tt.want) {
+				t.Errorf("route match = %v, want %v", route.Match.Metadata, tt.want)
+			}
+			wantCluster := model.BuildClusterName(model.TrafficDirectionOutbound, "v1", tt.host,
+				int(tt.port.Number))
+			if got := route.Route.GetCluster(); got != wantCluster {
+				t.Errorf("route cluster = %v, want %v", got, wantCluster)
+			}
+		})
+	}
+}
+
+func TestBuildMetaRouteConfigurationMirrors(t *testing.T) {
+	const host = "thrift-sample-server.meta-thrift.svc.cluster.local"
+	port := &networking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift-server"}
+	service := &networking.ServiceEntry{
+		Hosts: []string{host},
+		Ports: []*networking.Port{port},
+	}
+	tests := []struct {
+		name         string
+		mirror       *metaprotocolapi.Destination
+		mirrors      []*metaprotocolapi.MetaRouteMirror
+		wantClusters []string
+		wantPercents []uint32
+	}{
+		{
+			name: "two mirrors",
+			mirrors: []*metaprotocolapi.MetaRouteMirror{
+				{
+					Destination: &metaprotocolapi.Destination{Host: host, Subset: "v2"},
+					Percentage:  &metaprotocolapi.Percent{Value: 10},
+				},
+				{
+					Destination: &metaprotocolapi.Destination{Host: host, Subset: "v3",
+						Port: &metaprotocolapi.PortSelector{Number: 9091}},
+				},
+			},
+			wantClusters: []string{
+				"outbound|9090|v2|" + host,
+				"outbound|9091|v3|" + host,
+			},
+			wantPercents: []uint32{100000, 1000000},
+		},
+		{
+			name:   "mirror and mirrors",
+			mirror: &metaprotocolapi.Destination{Host: host, Subset: "v2"},
+			mirrors: []*metaprotocolapi.MetaRouteMirror{
+				{
+					Destination: &metaprotocolapi.Destination{Host: host, Subset: "v3"},
+					Percentage:  &metaprotocolapi.Percent{Value: 0.5},
+				},
+				{
+					Percentage: &metaprotocolapi.Percent{Value: 50},
+				},
+			},
+			wantClusters: []string{
+				"outbound|9090|v2|" + host,
+				"outbound|9090|v3|" + host,
+			},
+			wantPercents: []uint32{1000000, 5000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metaRouter := &metaprotocol.MetaRouter{
+				Spec: metaprotocolapi.MetaRouter{
+					Hosts: []string{host},
+					Routes: []*metaprotocolapi.MetaRoute{
+						{
+							Name: "v1",
+							Route: []*metaprotocolapi.MetaRouteDestination{
+								{Destination: &metaprotocolapi.Destination{Host: host, Subset: "v1"}},
+							},
+							Mirror:  tt.mirror,
+							Mirrors: tt.mirrors,
+						},
+					},
+				},
+			}
+			routeConfig := BuildMetaRouteConfiguration(service, port, metaRouter, nil)
+			if len(routeConfig.Routes) != 1 {
+				t.Fatalf("BuildMetaRouteConfiguration() got %d routes, want 1", len(routeConfig.Routes))
+			}
+			var clusters []string
+			var percents []uint32
+			for _, policy := range routeConfig.Routes[0].Route.RequestMirrorPolicies {
+				clusters = append(clusters, policy.Cluster)
+				percents = append(percents, policy.RuntimeFraction.DefaultValue.Numerator)
+			}
+			if !reflect.DeepEqual(clusters, tt.wantClusters) {
+				t.Errorf("mirror clusters = %v, want %v", clusters, tt.wantClusters)
+			}
+			if !reflect.DeepEqual(percents, tt.wantPercents) {
+				t.Errorf("mirror percents = %v, want %v", percents, tt.wantPercents)
+			}
+		})
+	}
+}
+
+func TestBuildMetaRouteConfigurationPriority(t *testing.T) {
+	const host = "thrift-sample-server.meta-thrift.svc.cluster.local"
+	port := &networking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift-server"}
+	service := &networking.ServiceEntry{
+		Hosts: []string{host},
+		Ports: []*networking.Port{port},
+	}
+
+	tests := []struct {
+		name   string
+		routes []*metaprotocolapi.MetaRoute
+		want   []string
+	}{
+		{
+			name: "no priority",
+			routes: []*metaprotocolapi.MetaRoute{
+				{Name: "first"}, {Name: "second"}, {Name: "third"},
+			},
+			want: []string{"first", "second", "third"},
+		},
+		{
+			name: "mixed priorities",
+			routes: []*metaprotocolapi.MetaRoute{
+				{Name: "default"},
+				{Name: "v1", Priority: 10},
+				{Name: "canary", Priority: 20},
+				{Name: "v2", Priority: 10},
+				{Name: "fallback"},
+			},
+			want: []string{"canary", "v1", "v2", "default", "fallback"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, route := range tt.routes {
+				route.Route = []*metaprotocolapi.MetaRouteDestination{
+					{Destination: &metaprotocolapi.Destination{Host: host}},
+				}
+			}
+			metaRouter := &metaprotocol.MetaRouter{
+				Spec: metaprotocolapi.MetaRouter{
+					Hosts:  []string{host},
+					Routes: tt.routes,
+				},
+			}
+
+			first := metaRouter.Spec.Routes[0].Name
+			routeConfig := BuildMetaRouteConfiguration(service, port, metaRouter, nil)
+			var got []string
+			for _, route := range routeConfig.Routes {
+				got = append(got, route.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("route order = %v, want %v", got, tt.want)
+			}
+			if metaRouter.Spec.Routes[0].Name != first {
+				t.Errorf("the routes of the MetaRouter should not be reordered")
+			}
+		})
+	}
+}
+
+func TestBuildMetaRouteConfigurationUnmatchedRequests(t *testing.T) {
+	const host = "thrift-sample-server.meta-thrift.svc.cluster.local"
+	port := &networking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift-server"}
+	service := &networking.ServiceEntry{
+		Hosts: []string{host},
+		Ports: []*networking.Port{port},
+	}
+
+	tests := []struct {
+		name         string
+		fallback     *metaprotocolapi.Destination
+		passthrough  bool
+		wantRoutes   []string
+		wantFallback string
+	}{
+		{
+			name:       "rejected",
+			wantRoutes: []string{"v1"},
+		},
+		{
+			name:         "fallback subset",
+			fallback:     &metaprotocolapi.Destination{Host: host, Subset: "v2"},
+			wantRoutes:   []string{"v1", "fallback"},
+			wantFallback: "outbound|9090|v2|" + host,
+		},
+		{
+			name: "fallback service",
+			fallback: &metaprotocolapi.Destination{
+				Host: "legacy-server.meta-thrift.svc.cluster.local",
+				Port: &metaprotocolapi.PortSelector{Number: 7090},
+			},
+			wantRoutes:   []string{"v1", "fallback"},
+			wantFallback: "outbound|7090||legacy-server.meta-thrift.svc.cluster.local",
+		},
+		{
+			name:         "passthrough",
+			passthrough:  true,
+			wantRoutes:   []string{"v1", "passthrough"},
+			wantFallback: "PassthroughCluster",
+		},
+		{
+			name:         "fallback over passthrough",
+			fallback:     &metaprotocolapi.Destination{Host: host, Subset: "v2"},
+			passthrough:  true,
+			wantRoutes:   []string{"v1", "fallback"},
+			wantFallback: "outbound|9090|v2|" + host,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metaRouter := &metaprotocol.MetaRouter{
+				Spec: metaprotocolapi.MetaRouter{
+					Hosts: []string{host},
+					Routes: []*metaprotocolapi.MetaRoute{
+						{
+							Name: "v1",
+							Match: &metaprotocolapi.MetaRouteMatch{
+								Attributes: map[string]*metaprotocolapi.StringMatch{
+									"method": {MatchType: &metaprotocolapi.StringMatch_Exact{Exact: "sayHello"}},
+								},
+							},
+							Route: []*metaprotocolapi.MetaRouteDestination{
+								{Destination: &metaprotocolapi.Destination{Host: host, Subset: "v1"}},
+							},
+						},
+					},
+					FallbackCluster:      tt.fallback,
+					PassthroughUnmatched: tt.passthrough,
+				},
+			}
+
+			routeConfig := BuildMetaRouteConfiguration(service, port, metaRouter, nil)
+			var got []string
+			for _, route := range routeConfig.Routes {
+				got = append(got, route.Name)
+			}
+			if !reflect.DeepEqual(got, tt.wantRoutes) {
+				t.Fatalf("routes = %v, want %v", got, tt.wantRoutes)
+			}
+			if tt.wantFallback == "" {
+				return
+			}
+			catchAll := routeConfig.Routes[len(routeConfig.Routes)-1]
+			if len(catchAll.Match.Metadata) != 0 {
+				t.Errorf("catch-all route match = %v, want no conditions", catchAll.Match.Metadata)
+			}
+			if got := catchAll.Route.GetCluster(); got != tt.wantFallback {
+				t.Errorf("catch-all route cluster = %v, want %v", got, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestBuildMetaRouteConfigurationWithoutRoutes(t *testing.T) {
+	const host = "thrift-sample-server.meta-thrift.svc.cluster.local"
+	port := &networking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift-server"}
+	service := &networking.ServiceEntry{
+		Hosts: []string{host},
+		Ports: []*networking.Port{port},
+	}
+
+	tests := []struct {
+		name       string
+		metaRouter *metaprotocol.MetaRouter
+		wantRoutes []string
+	}{
+		{
+			name:       "no MetaRouter",
+			wantRoutes: []string{"default"},
+		},
+		{
+			name: "MetaRouter without routes",
+			metaRouter: &metaprotocol.MetaRouter{
+				Spec: metaprotocolapi.MetaRouter{Hosts: []string{host}},
+			},
+		},
+		{
+			name: "MetaRouter without routes but a fallback",
+			metaRouter: &metaprotocol.MetaRouter{
+				Spec: metaprotocolapi.MetaRouter{
+					Hosts:           []string{host},
+					FallbackCluster: &metaprotocolapi.Destination{Host: host, Subset: "v2"},
+				},
+			},
+			wantRoutes: []string{"fallback"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routeConfig := BuildMetaRouteConfiguration(service, port, tt.metaRouter, nil)
+			if want := model.BuildMetaProtocolRouteName(host, 9090); routeConfig.Name != want {
+				t.Errorf("route config name = %v, want %v", routeConfig.Name, want)
+			}
+			var got []string
+			for _, route := range routeConfig.Routes {
+				got = append(got, route.Name)
+			}
+			if !reflect.DeepEqual(got, tt.wantRoutes) {
+				t.Errorf("routes = %v, want %v", got, tt.wantRoutes)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zhaohuabing/debounce"
+	istiomodel "istio.io/istio/pilot/pkg/model"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+)
+
+// pushEvent is a config change event along with the debounce interval of the service it relates to
+type pushEvent struct {
+	event         istiomodel.Event
+	debounceAfter time.Duration
+}
+
+// rdsDebounce returns the debounce interval of the route updates of a service, which can be overridden by the
+// rdsDebounce annotation of the service
+func rdsDebounce(annotations map[string]string) time.Duration {
+	value, ok := annotations[constants.RDSDebounceAnnotation]
+	if !ok {
+		return debounceAfter
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		xdsLog.Warnf("invalid %s annotation %q, it should be a positive duration, the default %v is used",
+			constants.RDSDebounceAnnotation, value, debounceAfter)
+		return debounceAfter
+	}
+	return interval
+}
+
+// debouncers debounces the route updates with a debouncer per debounce interval, so the services with a shorter
+// interval get their route updates pushed without waiting for the ones with a longer interval. The debouncers which
+// haven't been bounced for a while are removed, so the services can't pile up goroutines by changing the interval.
+type debouncers struct {
+	mutex      sync.Mutex
+	callback   func()
+	lock       sync.Mutex
+	stopped    bool
+	byInterval map[time.Duration]*intervalDebouncer
+}
+
+// intervalDebouncer is the debouncer of an interval, it has its own stop channel so it can be removed when idle
+type intervalDebouncer struct {
+	*debounce.Debouncer
+	stop       chan struct{}
+	lastBounce time.Time
+}
+
+// idle checks whether the debouncer has fired the callback of its last bounce, the callback is fired at most the
+// interval after the last bounce, debounceMax is added as a safety margin
+func (d *intervalDebouncer) idle(after time.Duration, now time.Time) bool {
+	return now.Sub(d.lastBounce) > after+debounceMax
+}
+
+func newDebouncers(callback func(), stop <-chan struct{}) *debouncers {
+	d := &debouncers{
+		byInterval: make(map[time.Duration]*intervalDebouncer),
+	}
+	// The callbacks of different debouncers run in their own goroutines, serialize them
+	d.callback = func() {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		callback()
+	}
+	go func() {
+		<-stop
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		d.stopped = true
+		for after, debouncer := range d.byInterval {
+			close(debouncer.stop)
+			delete(d.byInterval, after)
+		}
+	}()
+	return d
+}
+
+// bounce results a new bounce event on the debouncer of the given interval
+func (d *debouncers) bounce(after time.Duration) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.stopped {
+		return
+	}
+	now := time.Now()
+	for interval, debouncer := range d.byInterval {
+		if interval != after && debouncer.idle(interval, now) {
+			close(debouncer.stop)
+			delete(d.byInterval, interval)
+		}
+	}
+	debouncer, ok := d.byInterval[after]
+	if !ok {
+		max := debounceMax
+		if after > max {
+			max = after
+		}
+		stop := make(chan struct{})
+		debouncer = &intervalDebouncer{
+			Debouncer: debounce.New(after, max, d.callback, stop),
+			stop:      stop,
+		}
+		d.byInterval[after] = debouncer
+	}
+	debouncer.lastBounce = now
+	debouncer.Bounce()
+}
@@ -0,0 +1,188 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+	istiomodel "istio.io/istio/pilot/pkg/model"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+)
+
+func TestRDSDebounce(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Duration
+	}{
+		{
+			name: "default",
+			want: debounceAfter,
+		},
+		{
+			name:        "shorter interval",
+			annotations: map[string]string{constants.RDSDebounceAnnotation: "100ms"},
+			want:        100 * time.Millisecond,
+		},
+		{
+			name:        "longer interval",
+			annotations: map[string]string{constants.RDSDebounceAnnotation: "5s"},
+			want:        5 * time.Second,
+		},
+		{
+			name:        "invalid interval",
+			annotations: map[string]string{constants.RDSDebounceAnnotation: "fast"},
+			want:        debounceAfter,
+		},
+		{
+			name:        "zero interval",
+			annotations: map[string]string{constants.RDSDebounceAnnotation: "0s"},
+			want:        debounceAfter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rdsDebounce(tt.annotations); got != tt.want {
+				t.Errorf("rdsDebounce() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigUpdatedDebounce(t *testing.T) {
+	tests := []struct {
+		name        string
+		portName    string
+		annotations map[string]string
+		wantPush    bool
+		want        time.Duration
+	}{
+		{
+			name:     "default interval",
+			portName: "tcp-metaprotocol-dubbo",
+			wantPush: true,
+			want:     debounceAfter,
+		},
+		{
+			name:        "overridden interval",
+			portName:    "tcp-metaprotocol-dubbo",
+			annotations: map[string]string{constants.RDSDebounceAnnotation: "200ms"},
+			wantPush:    true,
+			want:        200 * time.Millisecond,
+		},
+		{
+			name:        "not a MetaProtocol service",
+			portName:    "http",
+			annotations: map[string]string{constants.RDSDebounceAnnotation: "200ms"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCacheMgr(nil)
+			config := &istioconfig.Config{
+				Meta: istioconfig.Meta{
+					GroupVersionKind: collections.IstioNetworkingV1Alpha3Serviceentries.Resource().GroupVersionKind(),
+					Name:             "test",
+					Namespace:        "meta",
+					Annotations:      tt.annotations,
+				},
+				Spec: &networking.ServiceEntry{
+					Hosts: []string{"test.meta.svc.cluster.local"},
+					Ports: []*networking.Port{{Number: 20880, Name: tt.portName}},
+				},
+			}
+			c.ConfigUpdated(config, config, istiomodel.EventUpdate)
+			select {
+			case e := <-c.pushChannel:
+				if !tt.wantPush {
+					t.Fatalf("unexpected push event: %v", e)
+				}
+				if e.debounceAfter != tt.want {
+					t.Errorf("debounceAfter = %v, want %v", e.debounceAfter, tt.want)
+				}
+			default:
+				if tt.wantPush {
+					t.Fatalf("no push event sent")
+				}
+			}
+		})
+	}
+}
+
+func TestDebouncers(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	pushed := make(chan struct{}, 10)
+	d := newDebouncers(func() { pushed <- struct{}{} }, stop)
+
+	// A service with a long interval doesn't hold back the push of a service with a short one
+	d.bounce(time.Hour)
+	start := time.Now()
+	d.bounce(10 * time.Millisecond)
+	select {
+	case <-pushed:
+		if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+			t.Errorf("pushed after %v, want at least 10ms", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("route update of the short interval not pushed")
+	}
+
+	select {
+	case <-pushed:
+		t.Fatalf("route update of the long interval pushed too early")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if len(d.byInterval) != 2 {
+		t.Errorf("got %d debouncers, want 2", len(d.byInterval))
+	}
+}
+
+func TestDebouncersRemoveIdle(t *testing.T) {
+	stop := make(chan struct{})
+	d := newDebouncers(func() {}, stop)
+
+	d.bounce(time.Hour)
+	d.bounce(10 * time.Millisecond)
+	idle := d.byInterval[10*time.Millisecond]
+	idle.lastBounce = time.Now().Add(-debounceMax - time.Second)
+	d.bounce(time.Hour)
+
+	if _, ok := d.byInterval[10*time.Millisecond]; ok {
+		t.Errorf("the idle debouncer is not removed")
+	}
+	select {
+	case <-idle.stop:
+	default:
+		t.Errorf("the idle debouncer is not stopped")
+	}
+	if _, ok := d.byInterval[time.Hour]; !ok {
+		t.Errorf("the debouncer of a pending bounce is removed")
+	}
+
+	close(stop)
+	time.Sleep(100 * time.Millisecond)
+	d.bounce(time.Minute)
+	if len(d.byInterval) != 0 {
+		t.Errorf("got %d debouncers after stop, want 0", len(d.byInterval))
+	}
+}
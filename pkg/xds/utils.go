@@ -179,6 +179,14 @@ func MetaMatch2HttpHeaderMatch(matchCrd *userapi.MetaRouteMatch) []*httproute.He
 							Regex:      attribute.GetRegex(),
 						},
 					}
+				case *userapi.StringMatch_Range:
+					// both Aeraki and Envoy ranges are [start, end)
+					headerMatcher.HeaderMatchSpecifier = &httproute.HeaderMatcher_RangeMatch{
+						RangeMatch: &typev3.Int64Range{
+							Start: attribute.GetRange().GetStart(),
+							End:   attribute.GetRange().GetEnd(),
+						},
+					}
 				default:
 					continue
 				}
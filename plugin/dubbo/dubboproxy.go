@@ -20,6 +20,7 @@ import (
 	"istio.io/istio/pkg/spiffe"
 
 	dubbov1alpha1 "github.com/aeraki-mesh/aeraki/client-go/pkg/clientset/versioned/typed/dubbo/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 	"github.com/aeraki-mesh/aeraki/plugin/dubbo/authz/builder"
 )
@@ -32,8 +33,8 @@ func buildOutboundProxy(context *model.EnvoyFilterContext) *dubbo.DubboProxy {
 	}
 
 	return &dubbo.DubboProxy{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionOutbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionOutbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 		ProtocolType:      dubbo.ProtocolType_Dubbo,
 		SerializationType: dubbo.SerializationType_Hessian2,
 		// we only support one to one mapping of interface and service. If there're multiple interfaces in one process,
@@ -62,8 +63,8 @@ func buildInboundProxy(context *model.EnvoyFilterContext,
 	})
 
 	return &dubbo.DubboProxy{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionInbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionInbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 		ProtocolType:      dubbo.ProtocolType_Dubbo,
 		SerializationType: dubbo.SerializationType_Hessian2,
 		// we only support one to one mapping of interface and service. If there're multiple interfaces in one process,
@@ -46,7 +46,7 @@ func NewGenerator(cfg *rest.Config) *Generator {
 }
 
 // Generate create EnvoyFilters for Dubbo services
-func (g *Generator) Generate(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error) {
+func (g *Generator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
 	return envoyfilter.GenerateReplaceNetworkFilter(
 		context.ServiceEntry,
 		context.ServiceEntry.Spec.Ports[0],
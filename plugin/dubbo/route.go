@@ -23,6 +23,7 @@ import (
 	"github.com/golang/protobuf/ptypes/wrappers"
 	networking "istio.io/api/networking/v1alpha3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
@@ -63,7 +64,7 @@ func buildOutboundRouteConfig(context *model.EnvoyFilterContext) (*dubbo.RouteCo
 
 func buildInboundRouteConfig(context *model.EnvoyFilterContext) *dubbo.RouteConfiguration {
 	clusterName := model.BuildClusterName(model.TrafficDirectionInbound, "", "",
-		int(context.ServiceEntry.Spec.Ports[0].Number))
+		int(envoyfilter.InboundPort(context.ServiceEntry, context.ServiceEntry.Spec.Ports[0])))
 	route := []*dubbo.Route{defaultRoute(clusterName)}
 	return &dubbo.RouteConfiguration{
 		Name:      clusterName,
@@ -0,0 +1,84 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dubbo
+
+import (
+	"fmt"
+	"strings"
+
+	grpcstats "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_stats/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	istionetworking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+const grpcStatsType = "type.googleapis.com/envoy.extensions.filters.http.grpc_stats.v3.FilterConfig"
+
+// http2PortPrefixes are the Istio protocols of a port for which Istio builds an HTTP connection manager with the
+// HTTP/2 codec, the Triple requests are carried by HTTP/2 frames
+var http2PortPrefixes = []string{"grpc", "http2"}
+
+// TripleGenerator defines a Generator of the EnvoyFilters for the Dubbo3 services using the Triple protocol
+type TripleGenerator struct {
+}
+
+// NewTripleGenerator creates an new Triple Generator instance
+func NewTripleGenerator() *TripleGenerator {
+	return &TripleGenerator{}
+}
+
+// Generate create EnvoyFilters which insert the grpc_stats filter into the HTTP connection manager of the Triple port
+// of a service. Triple is gRPC compatible, so the gRPC HTTP filters work with the Triple requests, and the per method
+// stats are collected for the Dubbo3 service methods.
+func (*TripleGenerator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
+	port, err := triplePort(context.ServiceEntry.Spec)
+	if err != nil {
+		return nil, err
+	}
+	return envoyfilter.GenerateInsertBeforeHTTPFilter(context.ServiceEntry, port, buildTripleFilter(),
+		buildTripleFilter(), wellknown.HTTPGRPCStats, grpcStatsType), nil
+}
+
+// triplePort returns the Triple port of a service, the port must be named in the form of grpc-triple-xxx or
+// http2-triple-xxx so Istio builds an HTTP/2 connection manager for it instead of a tcp proxy
+func triplePort(service *istionetworking.ServiceEntry) (*istionetworking.Port, error) {
+	for _, port := range service.Ports {
+		if protocol.GetLayer7ProtocolFromPortName(port.Name) != protocol.Triple {
+			continue
+		}
+		istioProtocol := strings.ToLower(strings.Split(port.Name, "-")[0])
+		for _, prefix := range http2PortPrefixes {
+			if istioProtocol == prefix {
+				return port, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid Triple port name: %s, it should start with %s so the HTTP/2 codec is used",
+			port.Name, strings.Join(http2PortPrefixes, " or "))
+	}
+	return nil, fmt.Errorf("no Triple port found in service: %s", service.Hosts[0])
+}
+
+func buildTripleFilter() *grpcstats.FilterConfig {
+	return &grpcstats.FilterConfig{
+		EmitFilterState: true,
+		PerMethodStatSpecifier: &grpcstats.FilterConfig_StatsForAllMethods{
+			StatsForAllMethods: wrapperspb.Bool(true),
+		},
+	}
+}
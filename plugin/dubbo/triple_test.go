@@ -0,0 +1,97 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dubbo
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestTripleGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   []*networking.Port
+		wantErr bool
+	}{
+		{
+			name:  "gRPC port",
+			ports: []*networking.Port{{Number: 50052, Name: "grpc-triple-greeter"}},
+		},
+		{
+			name:  "HTTP/2 port",
+			ports: []*networking.Port{{Number: 50052, Name: "http2-triple-greeter"}},
+		},
+		{
+			name:    "tcp port",
+			ports:   []*networking.Port{{Number: 50052, Name: "tcp-triple-greeter"}},
+			wantErr: true,
+		},
+		{
+			name:    "no Triple port",
+			ports:   []*networking.Port{{Number: 20880, Name: "tcp-dubbo-greeter"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Spec: &networking.ServiceEntry{
+						Hosts:            []string{"greeter.dubbo.svc.cluster.local"},
+						Ports:            tt.ports,
+						WorkloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "greeter"}},
+					},
+				},
+			}
+			result, err := NewTripleGenerator().Generate(context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(result.EnvoyFilters) != 2 {
+				t.Fatalf("Generate() got %d EnvoyFilters, want the outbound and the inbound ones",
+					len(result.EnvoyFilters))
+			}
+			for _, envoyFilter := range result.EnvoyFilters {
+				patch := envoyFilter.Envoyfilter.ConfigPatches[0]
+				if patch.ApplyTo != networking.EnvoyFilter_HTTP_FILTER {
+					t.Errorf("patch applyTo = %v, want HTTP_FILTER", patch.ApplyTo)
+				}
+				filter := patch.Match.GetListener().GetFilterChain().GetFilter()
+				if filter.GetName() != wellknown.HTTPConnectionManager ||
+					filter.GetSubFilter().GetName() != wellknown.Router {
+					t.Errorf("patch match = %v, want the router filter of the HTTP connection manager", filter)
+				}
+				if got := patch.Patch.Value.Fields["name"].GetStringValue(); got != wellknown.HTTPGRPCStats {
+					t.Errorf("filter name = %v, want %v", got, wellknown.HTTPGRPCStats)
+				}
+				typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue().Fields
+				if got := typedConfig["type_url"].GetStringValue(); got != grpcStatsType {
+					t.Errorf("typed_config type_url = %v, want %v", got, grpcStatsType)
+				}
+				value := typedConfig["value"].GetStructValue().GetFields()
+				if !value["statsForAllMethods"].GetBoolValue() {
+					t.Errorf("stats_for_all_methods = %v, want true", value["statsForAllMethods"])
+				}
+			}
+		})
+	}
+}
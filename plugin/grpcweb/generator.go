@@ -0,0 +1,50 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcweb
+
+import (
+	"fmt"
+
+	grpcweb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_web/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/model/protocol"
+)
+
+const grpcWebType = "type.googleapis.com/envoy.extensions.filters.http.grpc_web.v3.GrpcWeb"
+
+// Generator defines a Generator of the EnvoyFilters which bridge the gRPC-Web requests to the gRPC services
+type Generator struct {
+}
+
+// NewGenerator creates an new gRPC-Web Generator instance
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate create EnvoyFilters which insert the grpc_web filter into the HTTP connection manager of the gRPC-Web port
+// of a service, the port name should be in the form of grpc-grpcweb-xxx so Istio builds an HTTP connection manager
+// for it
+func (*Generator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
+	for _, port := range context.ServiceEntry.Spec.Ports {
+		if protocol.GetLayer7ProtocolFromPortName(port.Name) == protocol.GRPCWeb {
+			return envoyfilter.GenerateInsertBeforeHTTPFilter(context.ServiceEntry, port, &grpcweb.GrpcWeb{},
+				&grpcweb.GrpcWeb{}, wellknown.GRPCWeb, grpcWebType), nil
+		}
+	}
+	return nil, fmt.Errorf("no gRPC-Web port found in service: %s", context.ServiceEntry.Spec.Hosts[0])
+}
@@ -0,0 +1,95 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcweb
+
+import (
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   []*networking.Port
+		wantErr bool
+	}{
+		{
+			name:  "gRPC-Web port",
+			ports: []*networking.Port{{Number: 8080, Name: "grpc-grpcweb-echo"}},
+		},
+		{
+			name: "gRPC-Web port among others",
+			ports: []*networking.Port{
+				{Number: 9090, Name: "grpc-echo"},
+				{Number: 8080, Name: "grpc-grpcweb-echo"},
+			},
+		},
+		{
+			name:    "no gRPC-Web port",
+			ports:   []*networking.Port{{Number: 9090, Name: "grpc-echo"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Spec: &networking.ServiceEntry{
+						Hosts:            []string{"echo.example.com"},
+						Ports:            tt.ports,
+						WorkloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "echo"}},
+					},
+				},
+			}
+			result, err := NewGenerator().Generate(context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(result.EnvoyFilters) != 2 {
+				t.Fatalf("Generate() got %d EnvoyFilters, want the outbound and the inbound ones",
+					len(result.EnvoyFilters))
+			}
+			for _, envoyFilter := range result.EnvoyFilters {
+				patch := envoyFilter.Envoyfilter.ConfigPatches[0]
+				if patch.ApplyTo != networking.EnvoyFilter_HTTP_FILTER {
+					t.Errorf("patch applyTo = %v, want HTTP_FILTER", patch.ApplyTo)
+				}
+				filter := patch.Match.GetListener().GetFilterChain().GetFilter()
+				if filter.GetName() != wellknown.HTTPConnectionManager ||
+					filter.GetSubFilter().GetName() != wellknown.Router {
+					t.Errorf("patch match = %v, want the router filter of the HTTP connection manager", filter)
+				}
+				if patch.Patch.Operation != networking.EnvoyFilter_Patch_INSERT_BEFORE {
+					t.Errorf("patch operation = %v, want the filter inserted before the router",
+						patch.Patch.Operation)
+				}
+				if got := patch.Patch.Value.Fields["name"].GetStringValue(); got != wellknown.GRPCWeb {
+					t.Errorf("filter name = %v, want %v", got, wellknown.GRPCWeb)
+				}
+				typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue().Fields
+				if got := typedConfig["type_url"].GetStringValue(); got != grpcWebType {
+					t.Errorf("typed_config type_url = %v, want %v", got, grpcWebType)
+				}
+			}
+		})
+	}
+}
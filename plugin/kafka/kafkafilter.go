@@ -17,19 +17,20 @@ package kafka
 import (
 	kafka "github.com/envoyproxy/go-control-plane/contrib/envoy/extensions/filters/network/kafka_broker/v3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
 func buildOutboundProxy(context *model.EnvoyFilterContext) *kafka.KafkaBroker {
 	return &kafka.KafkaBroker{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionOutbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionOutbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 	}
 }
 
 func buildInboundProxy(context *model.EnvoyFilterContext) *kafka.KafkaBroker {
 	return &kafka.KafkaBroker{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionInbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionInbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 	}
 }
@@ -15,6 +15,10 @@
 package metaprotocol
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"google.golang.org/protobuf/types/known/structpb"
 	"istio.io/istio/pkg/util/protomarshal"
 	"istio.io/pkg/log"
@@ -25,10 +29,14 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/networking/util"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
 const (
 	envoyLogFilePath   = "/dev/stdout"
+	errorsOnlyFilter   = "errors"
 	envoyTextLogFormat = "[%START_TIME%] %REQ(X-META-PROTOCOL-APPLICATION-PROTOCOL)% " +
 		"%RESPONSE_CODE% %RESPONSE_CODE_DETAILS% %CONNECTION_TERMINATION_DETAILS% " +
 		"\"%UPSTREAM_TRANSPORT_FAILURE_REASON%\" %BYTES_RECEIVED% %BYTES_SENT% " +
@@ -114,3 +122,41 @@ func buildFileAccessLogHelper(path string, mesh *meshconfig.MeshConfig) *accessl
 
 	return al
 }
+
+// accessLogFilter returns the filter of the access log of a service set by the annotation of the service, nil is
+// returned if it's not set. The errors filter matches the requests with any response flag, such as the upstream
+// connection failures and the no route found errors.
+func accessLogFilter(service *model.ServiceEntryWrapper) (*accesslog.AccessLogFilter, error) {
+	value, ok := service.Annotations[constants.AccessLogFilterAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	responseFlagFilter := &accesslog.ResponseFlagFilter{}
+	if value != errorsOnlyFilter {
+		for _, flag := range strings.Split(value, ",") {
+			responseFlagFilter.Flags = append(responseFlagFilter.Flags, strings.TrimSpace(flag))
+		}
+	}
+	if err := responseFlagFilter.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %s, it should be %s or a comma separated list of "+
+			"response flags", constants.AccessLogFilterAnnotation, value, errorsOnlyFilter)
+	}
+	return &accesslog.AccessLogFilter{
+		FilterSpecifier: &accesslog.AccessLogFilter_ResponseFlagFilter{ResponseFlagFilter: responseFlagFilter},
+	}, nil
+}
+
+// debugLog returns whether every request of a service is logged for debugging, which is set by the annotation of the
+// service
+func debugLog(service *model.ServiceEntryWrapper) (bool, error) {
+	value, ok := service.Annotations[constants.DebugLogAnnotation]
+	if !ok {
+		return false, nil
+	}
+	debug, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s, it should be a boolean", constants.DebugLogAnnotation,
+			value)
+	}
+	return debug, nil
+}
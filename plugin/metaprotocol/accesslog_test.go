@@ -0,0 +1,190 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"reflect"
+	"testing"
+
+	metaprotocol "github.com/aeraki-mesh/meta-protocol-control-plane-api/aeraki/meta_protocol_proxy/v1alpha"
+	fileaccesslog "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	istionetworking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func Test_buildProxyAccessLogFilter(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	meshConfig.AccessLogFile = "/dev/stdout"
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantFilter  bool
+		wantFlags   []string
+		wantErr     bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:        "errors only",
+			annotations: map[string]string{constants.AccessLogFilterAnnotation: "errors"},
+			wantFilter:  true,
+		},
+		{
+			name:        "response flags",
+			annotations: map[string]string{constants.AccessLogFilterAnnotation: "UF, URX"},
+			wantFilter:  true,
+			wantFlags:   []string{"UF", "URX"},
+		},
+		{
+			name:        "invalid response flag",
+			annotations: map[string]string{constants.AccessLogFilterAnnotation: "UF,FAILED"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Meta: istioconfig.Meta{Annotations: tt.annotations},
+					Spec: &istionetworking.ServiceEntry{
+						Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+						Ports: []*istionetworking.Port{port},
+					},
+				},
+			}
+			outboundProxy, err := buildOutboundProxy(context, port, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildOutboundProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			inboundProxy, err := buildInboundProxy(context, port, FailOpen)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildInboundProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, proxy := range []*metaprotocol.MetaProtocolProxy{outboundProxy, inboundProxy} {
+				if len(proxy.AccessLog) != 1 {
+					t.Fatalf("got %d access logs, want 1", len(proxy.AccessLog))
+				}
+				filter := proxy.AccessLog[0].Filter
+				if !tt.wantFilter {
+					if filter != nil {
+						t.Errorf("access log filter = %v, want nil", filter)
+					}
+					continue
+				}
+				responseFlagFilter := filter.GetResponseFlagFilter()
+				if responseFlagFilter == nil {
+					t.Fatalf("access log filter = %v, want a response flag filter", filter)
+				}
+				if !reflect.DeepEqual(responseFlagFilter.Flags, tt.wantFlags) {
+					t.Errorf("response flags = %v, want %v", responseFlagFilter.Flags, tt.wantFlags)
+				}
+			}
+		})
+	}
+}
+
+func Test_buildProxyDebugLog(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	meshConfig.AccessLogFile = ""
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantLog     bool
+		wantErr     bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:        "enabled",
+			annotations: map[string]string{constants.DebugLogAnnotation: "true"},
+			wantLog:     true,
+		},
+		{
+			name: "enabled with access log filter",
+			annotations: map[string]string{
+				constants.DebugLogAnnotation:        "true",
+				constants.AccessLogFilterAnnotation: "errors",
+			},
+			wantLog: true,
+		},
+		{
+			name:        "disabled",
+			annotations: map[string]string{constants.DebugLogAnnotation: "false"},
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{constants.DebugLogAnnotation: "verbose"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Meta: istioconfig.Meta{Annotations: tt.annotations},
+					Spec: &istionetworking.ServiceEntry{
+						Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+						Ports: []*istionetworking.Port{port},
+					},
+				},
+			}
+			outboundProxy, err := buildOutboundProxy(context, port, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildOutboundProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			inboundProxy, err := buildInboundProxy(context, port, FailOpen)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildInboundProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, proxy := range []*metaprotocol.MetaProtocolProxy{outboundProxy, inboundProxy} {
+				if !tt.wantLog {
+					if len(proxy.AccessLog) != 0 {
+						t.Errorf("unexpected access logs: %v", proxy.AccessLog)
+					}
+					continue
+				}
+				if len(proxy.AccessLog) != 1 {
+					t.Fatalf("got %d access logs, want 1", len(proxy.AccessLog))
+				}
+				if filter := proxy.AccessLog[0].Filter; filter != nil {
+					t.Errorf("access log filter = %v, want nil", filter)
+				}
+				fileAccessLog := &fileaccesslog.FileAccessLog{}
+				if err := proxy.AccessLog[0].GetTypedConfig().UnmarshalTo(fileAccessLog); err != nil {
+					t.Fatalf("failed to unmarshal the access log: %v", err)
+				}
+				if fileAccessLog.Path != envoyLogFilePath {
+					t.Errorf("access log path = %s, want %s", fileAccessLog.Path, envoyLogFilePath)
+				}
+			}
+		})
+	}
+}
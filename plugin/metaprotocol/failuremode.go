@@ -0,0 +1,66 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// FailureMode defines the behavior of the filters inserted into the MetaProtocol proxies, such as the global rate
+// limit filter, when the services they depend on are unavailable
+type FailureMode string
+
+const (
+	// FailOpen lets the requests through when the service a filter depends on is unavailable
+	FailOpen FailureMode = "fail-open"
+	// FailClosed rejects the requests when the service a filter depends on is unavailable
+	FailClosed FailureMode = "fail-closed"
+)
+
+// ParseFailureMode parses a failure mode, the filters fail open if the value is empty
+func ParseFailureMode(value string) (FailureMode, error) {
+	switch mode := FailureMode(value); mode {
+	case "":
+		return FailOpen, nil
+	case FailOpen, FailClosed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid failure mode: %s, it should be %s or %s", value, FailOpen, FailClosed)
+	}
+}
+
+// failClosed makes the global rate limit filters fail closed when the rate limit service is unavailable, the
+// MetaRouters with denyOnFail always fail closed
+var failClosed atomic.Bool
+
+// SetFailureMode sets the behavior of the global rate limit filters when the rate limit service is unavailable, the
+// filters fail open if the mode is empty
+func SetFailureMode(value string) error {
+	mode, err := ParseFailureMode(value)
+	if err != nil {
+		return err
+	}
+	failClosed.Store(mode == FailClosed)
+	return nil
+}
+
+// currentFailureMode returns the failure mode set by SetFailureMode
+func currentFailureMode() FailureMode {
+	if failClosed.Load() {
+		return FailClosed
+	}
+	return FailOpen
+}
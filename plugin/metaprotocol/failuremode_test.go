@@ -0,0 +1,134 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"testing"
+
+	//nolint: lll
+	grldpl "github.com/aeraki-mesh/meta-protocol-control-plane-api/aeraki/meta_protocol_proxy/filters/global_ratelimit/v1alpha"
+	"github.com/gogo/protobuf/types"
+	istionetworking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/mesh"
+
+	userapi "github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	mpclient "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestParseFailureMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    FailureMode
+		wantErr bool
+	}{
+		{value: "", want: FailOpen},
+		{value: "fail-open", want: FailOpen},
+		{value: "fail-closed", want: FailClosed},
+		{value: "deny", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseFailureMode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFailureMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFailureMode() = %v, want %v", got, tt.want)
+			}
+
+			defer failClosed.Store(false)
+			if err := SetFailureMode(tt.value); (err != nil) != tt.wantErr {
+				t.Fatalf("SetFailureMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && currentFailureMode() != tt.want {
+				t.Errorf("currentFailureMode() = %v, want %v", currentFailureMode(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_buildInboundProxyFailureMode(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name        string
+		failureMode FailureMode
+		denyOnFail  bool
+		wantDeny    bool
+	}{
+		{
+			name:        "fail open",
+			failureMode: FailOpen,
+		},
+		{
+			name:        "fail closed",
+			failureMode: FailClosed,
+			wantDeny:    true,
+		},
+		{
+			name:        "fail open with deny on fail",
+			failureMode: FailOpen,
+			denyOnFail:  true,
+			wantDeny:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Spec: &istionetworking.ServiceEntry{
+						Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+						Ports: []*istionetworking.Port{port},
+					},
+				},
+				MetaRouter: &mpclient.MetaRouter{
+					Spec: userapi.MetaRouter{
+						Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+						GlobalRateLimit: &userapi.GlobalRateLimit{
+							Domain:           "production",
+							RateLimitService: "outbound|8081||rate-limit-server.meta-thrift.svc.cluster.local",
+							RequestTimeout:   &types.Duration{Nanos: 100000000},
+							DenyOnFail:       tt.denyOnFail,
+							Descriptors: []*userapi.GlobalRateLimit_Descriptor{
+								{Property: "method", DescriptorKey: "method"},
+							},
+						},
+					},
+				},
+			}
+			proxy, err := buildInboundProxy(context, port, tt.failureMode)
+			if err != nil {
+				t.Fatalf("buildInboundProxy() unexpected error: %v", err)
+			}
+			var rateLimit *grldpl.RateLimit
+			for _, filter := range proxy.MetaProtocolFilters {
+				if filter.Name == "aeraki.meta_protocol.filters.ratelimit" {
+					rateLimit = &grldpl.RateLimit{}
+					if err := filter.Config.UnmarshalTo(rateLimit); err != nil {
+						t.Fatalf("failed to unmarshal the global rate limit config: %v", err)
+					}
+				}
+			}
+			if rateLimit == nil {
+				t.Fatalf("global rate limit filter not found in %v", proxy.MetaProtocolFilters)
+			}
+			if rateLimit.FailureModeDeny != tt.wantDeny {
+				t.Errorf("failure_mode_deny = %v, want %v", rateLimit.FailureModeDeny, tt.wantDeny)
+			}
+		})
+	}
+}
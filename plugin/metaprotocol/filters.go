@@ -44,7 +44,8 @@ func buildOutboundFilters(host string) []*mpdataplane.MetaProtocolFilter {
 	return filters
 }
 
-func buildInboundFilters(metaRouter *mpclient.MetaRouter, host string) ([]*mpdataplane.MetaProtocolFilter, error) {
+func buildInboundFilters(metaRouter *mpclient.MetaRouter, host string,
+	failureMode FailureMode) ([]*mpdataplane.MetaProtocolFilter, error) {
 	var filters []*mpdataplane.MetaProtocolFilter
 	var err error
 	if metaRouter != nil {
@@ -54,7 +55,7 @@ func buildInboundFilters(metaRouter *mpclient.MetaRouter, host string) ([]*mpdat
 			}
 		}
 		if metaRouter.Spec.GlobalRateLimit != nil {
-			if filters, err = appendGlobalRateLimitFilter(metaRouter, filters); err != nil {
+			if filters, err = appendGlobalRateLimitFilter(metaRouter, failureMode, filters); err != nil {
 				return filters, err
 			}
 		}
@@ -116,7 +117,7 @@ func appendLocalRateLimitFilter(metaRouter *mpclient.MetaRouter,
 	return filters, nil
 }
 
-func appendGlobalRateLimitFilter(metaRouter *mpclient.MetaRouter,
+func appendGlobalRateLimitFilter(metaRouter *mpclient.MetaRouter, failureMode FailureMode,
 	filters []*mpdataplane.MetaProtocolFilter) ([]*mpdataplane.MetaProtocolFilter, error) {
 	globalRateLimit := metaRouter.Spec.GlobalRateLimit
 
@@ -141,7 +142,7 @@ func appendGlobalRateLimitFilter(metaRouter *mpclient.MetaRouter,
 			Seconds: globalRateLimit.RequestTimeout.Seconds,
 			Nanos:   globalRateLimit.RequestTimeout.Nanos,
 		},
-		FailureModeDeny: globalRateLimit.DenyOnFail,
+		FailureModeDeny: globalRateLimit.DenyOnFail || failureMode == FailClosed,
 		RateLimitService: &envoyrl.RateLimitServiceConfig{
 			GrpcService: &envoycore.GrpcService{
 				TargetSpecifier: &envoycore.GrpcService_EnvoyGrpc_{
@@ -16,6 +16,7 @@ package metaprotocol
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	istionetworking "istio.io/api/networking/v1alpha3"
 	"istio.io/pkg/log"
@@ -27,6 +28,15 @@ import (
 
 var generatorLog = log.RegisterScope("metaprotocol-generator", "metaprotocol generator", 0)
 
+// inlineRoutes emits the routes as an inline route config in the generated EnvoyFilters instead of fetching them from
+// the Aeraki RDS server
+var inlineRoutes atomic.Bool
+
+// SetInlineRoutes enables or disables the inline routes in the generated EnvoyFilters
+func SetInlineRoutes(inline bool) {
+	inlineRoutes.Store(inline)
+}
+
 // Generator defines a MetaProtocol envoyfilter Generator
 type Generator struct {
 }
@@ -37,15 +47,19 @@ func NewGenerator() *Generator {
 }
 
 // Generate create EnvoyFilters for MetaProtocol services
-func (*Generator) Generate(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error) {
+func (g *Generator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
 	if context.Gateway != nil {
-		return generateGatewayEnvoyFilters(context)
+		return g.generateGatewayEnvoyFilters(context)
 	}
-	return generateSidecarEnvoyFilters(context)
+	return g.generateSidecarEnvoyFilters(context)
 }
 
-func generateGatewayEnvoyFilters(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error) {
-	var envoyfilters []*model.EnvoyFilterWrapper
+func (g *Generator) generateGatewayEnvoyFilters(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
+	result := &model.GenerationResult{}
+	patchContext, err := envoyfilter.GatewayPatchContext(context.Gateway)
+	if err != nil {
+		return nil, err
+	}
 	for _, server := range context.Gateway.Spec.Servers {
 		if server.Port == nil {
 			continue
@@ -54,19 +68,19 @@ func generateGatewayEnvoyFilters(context *model.EnvoyFilterContext) ([]*model.En
 			continue
 		}
 		port := trans2Port(server)
-		outboundProxy, err := buildOutboundProxy(context, port)
+		outboundProxy, err := buildOutboundProxy(context, port, inlineRoutes.Load())
 		if err != nil {
 			return nil, err
 		}
-		envoyfilters = append(envoyfilters,
-			envoyfilter.GenerateReplaceNetworkFilter(
-				context.ServiceEntry,
-				port,
-				outboundProxy,
-				nil,
-				"envoy.filters.network.meta_protocol_proxy",
-				"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy")...)
+		result.Merge(envoyfilter.GenerateReplaceNetworkFilter(
+			context.ServiceEntry,
+			port,
+			outboundProxy,
+			nil,
+			"envoy.filters.network.meta_protocol_proxy",
+			"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy"))
 		// append workloadSelector for OutboundListener EnvoyFilter
+		envoyfilters := result.EnvoyFilters
 		for i := range envoyfilters {
 			envoyfilters[i].Name = fmt.Sprintf("aeraki-gateway-outbound-%s.%s-%d", context.Gateway.Name,
 				context.Gateway.Namespace, port.Number)
@@ -75,35 +89,42 @@ func generateGatewayEnvoyFilters(context *model.EnvoyFilterContext) ([]*model.En
 				envoyfilters[i].Envoyfilter.WorkloadSelector = &istionetworking.WorkloadSelector{}
 			}
 			envoyfilters[i].Envoyfilter.WorkloadSelector.Labels = context.Gateway.Spec.Selector
+			envoyfilter.MatchPatchContext(envoyfilters[i], patchContext)
 		}
 	}
-	return envoyfilters, nil
+	return result, nil
 }
 
-func generateSidecarEnvoyFilters(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error) {
-	var envoyfilters []*model.EnvoyFilterWrapper
+func (g *Generator) generateSidecarEnvoyFilters(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
+	result := &model.GenerationResult{}
 	for _, port := range context.ServiceEntry.Spec.Ports {
 		if !protocol.GetLayer7ProtocolFromPortName(port.Name).IsMetaProtocol() {
 			continue
 		}
-		outboundProxy, err := buildOutboundProxy(context, port)
+		outboundProxy, err := buildOutboundProxy(context, port, inlineRoutes.Load())
 		if err != nil {
 			return nil, err
 		}
-		inboundProxy, err := buildInboundProxy(context, port)
+		inboundProxy, err := buildInboundProxy(context, port, currentFailureMode())
 		if err != nil {
 			return nil, err
 		}
-		envoyfilters = append(envoyfilters,
-			envoyfilter.GenerateReplaceNetworkFilter(
-				context.ServiceEntry,
-				port,
-				outboundProxy,
-				inboundProxy,
-				"envoy.filters.network.meta_protocol_proxy",
-				"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy")...)
+		portResult := envoyfilter.GenerateReplaceNetworkFilter(
+			context.ServiceEntry,
+			port,
+			outboundProxy,
+			inboundProxy,
+			"envoy.filters.network.meta_protocol_proxy",
+			"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy")
+		if err := configRetryBudget(context, port, portResult); err != nil {
+			return nil, err
+		}
+		if err := configOutlierDetection(context, port, portResult); err != nil {
+			return nil, err
+		}
+		result.Merge(portResult)
 	}
-	return envoyfilters, nil
+	return result, nil
 }
 
 func trans2Port(server *istionetworking.Server) *istionetworking.Port {
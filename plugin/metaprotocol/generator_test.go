@@ -0,0 +1,160 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"testing"
+
+	istionetworking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerateBuiltinCodecs(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	tests := []struct {
+		name      string
+		host      string
+		port      *istionetworking.Port
+		wantCodec string
+	}{
+		{
+			name:      "dubbo",
+			host:      "dubbo-sample-server.meta-dubbo.svc.cluster.local",
+			port:      &istionetworking.Port{Number: 20880, Name: "tcp-metaprotocol-dubbo"},
+			wantCodec: "aeraki.meta_protocol.codec.dubbo",
+		},
+		{
+			name:      "thrift",
+			host:      "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port:      &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"},
+			wantCodec: "aeraki.meta_protocol.codec.thrift",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Spec: &istionetworking.ServiceEntry{
+						Hosts:     []string{tt.host},
+						Addresses: []string{"10.0.0.1"},
+						Ports:     []*istionetworking.Port{tt.port},
+					},
+				},
+			}
+
+			result, err := NewGenerator().Generate(context)
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if len(result.EnvoyFilters) == 0 {
+				t.Fatalf("Generate() got no EnvoyFilters")
+			}
+			for _, envoyFilter := range result.EnvoyFilters {
+				patch := envoyFilter.Envoyfilter.ConfigPatches[0]
+				if patch.ApplyTo != istionetworking.EnvoyFilter_NETWORK_FILTER ||
+					patch.Patch.Operation != istionetworking.EnvoyFilter_Patch_REPLACE {
+					t.Errorf("patch = %v, want the TCP proxy replaced", patch)
+				}
+				typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue().Fields
+				if got := typedConfig["type_url"].GetStringValue(); got !=
+					"type.googleapis.com/aeraki.meta_protocol_proxy.v1alpha.MetaProtocolProxy" {
+					t.Errorf("typed_config type_url = %v, want MetaProtocolProxy", got)
+				}
+				codec := typedConfig["value"].GetStructValue().GetFields()["codec"].GetStructValue().GetFields()
+				if got := codec["name"].GetStringValue(); got != tt.wantCodec {
+					t.Errorf("codec = %v, want %v", got, tt.wantCodec)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateGatewayPatchContext(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        istionetworking.EnvoyFilter_PatchContext
+		wantErr     bool
+	}{
+		{
+			name: "gateway listeners",
+			want: istionetworking.EnvoyFilter_GATEWAY,
+		},
+		{
+			name:        "sidecar outbound listeners",
+			annotations: map[string]string{constants.GatewayPatchContextAnnotation: "sidecar_outbound"},
+			want:        istionetworking.EnvoyFilter_SIDECAR_OUTBOUND,
+		},
+		{
+			name:        "invalid direction",
+			annotations: map[string]string{constants.GatewayPatchContextAnnotation: "egress"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				Gateway: &model.GatewayWrapper{
+					Meta: istioconfig.Meta{Name: "meta-gateway", Namespace: "istio-system",
+						Annotations: tt.annotations},
+					Spec: &istionetworking.Gateway{
+						Selector: map[string]string{"istio": "ingressgateway"},
+						Servers: []*istionetworking.Server{{
+							Port:  &istionetworking.Port{Number: 20880, Name: "tcp-metaprotocol-dubbo"},
+							Hosts: []string{"*"},
+						}},
+					},
+				},
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Spec: &istionetworking.ServiceEntry{
+						Hosts:     []string{"org.apache.dubbo.samples.basic.api.demoservice"},
+						Addresses: []string{"0.0.0.0"},
+					},
+				},
+			}
+
+			result, err := NewGenerator().Generate(context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(result.EnvoyFilters) == 0 {
+				t.Fatalf("Generate() got no EnvoyFilters")
+			}
+			for _, envoyFilter := range result.EnvoyFilters {
+				for _, patch := range envoyFilter.Envoyfilter.ConfigPatches {
+					if patch.Match.Context != tt.want {
+						t.Errorf("patch context = %v, want %v", patch.Match.Context, tt.want)
+					}
+					if patch.ApplyTo != istionetworking.EnvoyFilter_NETWORK_FILTER {
+						continue
+					}
+					if got := patch.Match.GetListener().GetName(); got != "0.0.0.0_20880" {
+						t.Errorf("listener name = %v, want 0.0.0.0_20880", got)
+					}
+				}
+			}
+		})
+	}
+}
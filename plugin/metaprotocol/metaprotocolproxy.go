@@ -19,8 +19,11 @@ import (
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	envoyconfig "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoytype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
 	istionetworking "istio.io/api/networking/v1alpha3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 	metaprotocolmodel "github.com/aeraki-mesh/aeraki/pkg/model/metaprotocol"
 )
@@ -31,7 +34,7 @@ const (
 )
 
 func buildOutboundProxy(context *model.EnvoyFilterContext,
-	port *istionetworking.Port) (*metaprotocol.MetaProtocolProxy, error) {
+	port *istionetworking.Port, inlineRoutes bool) (*metaprotocol.MetaProtocolProxy, error) {
 	applicationProtocol, err := metaprotocolmodel.GetApplicationProtocolFromPortName(port.Name)
 	if err != nil {
 		return nil, err
@@ -41,24 +44,46 @@ func buildOutboundProxy(context *model.EnvoyFilterContext,
 		return nil, err
 	}
 	metaProtocolProy := &metaprotocol.MetaProtocolProxy{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionOutbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(port.Number)),
-		RouteSpecifier: &metaprotocol.MetaProtocolProxy_Rds{
-			Rds: &metaprotocol.Rds{
-				RouteConfigName: model.BuildMetaProtocolRouteName(context.ServiceEntry.Spec.Hosts[0],
-					int(port.Number)),
-				ConfigSource: &envoyconfig.ConfigSource{
-					ResourceApiVersion: envoyconfig.ApiVersion_V3,
-					ConfigSourceSpecifier: &envoyconfig.ConfigSource_ApiConfigSource{
-						ApiConfigSource: &envoyconfig.ApiConfigSource{
-							ApiType:             envoyconfig.ApiConfigSource_GRPC,
-							TransportApiVersion: envoyconfig.ApiVersion_V3,
-							GrpcServices: []*envoyconfig.GrpcService{
-								{
-									TargetSpecifier: &envoyconfig.GrpcService_EnvoyGrpc_{
-										EnvoyGrpc: &envoyconfig.GrpcService_EnvoyGrpc{
-											ClusterName: "aeraki-xds", // TODO make this configurable
-										},
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionOutbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(port.Number))),
+		ApplicationProtocol: applicationProtocol,
+		Codec: &metaprotocol.Codec{
+			Name: codec,
+		},
+		MetaProtocolFilters: buildOutboundFilters(context.ServiceEntry.Spec.Hosts[0]),
+	}
+	if inlineRoutes {
+		metaProtocolProy.RouteSpecifier = &metaprotocol.MetaProtocolProxy_RouteConfig{
+			RouteConfig: buildOutboundRouteConfig(context, port),
+		}
+	} else {
+		metaProtocolProy.RouteSpecifier = buildOutboundRds(context, port)
+	}
+	if err := configAccessLog(context, metaProtocolProy); err != nil {
+		return nil, err
+	}
+	configTracing(context, metaProtocolProy)
+	configIdleTimeout(context, metaProtocolProy, applicationProtocol)
+	return metaProtocolProy, nil
+}
+
+func buildOutboundRds(context *model.EnvoyFilterContext,
+	port *istionetworking.Port) *metaprotocol.MetaProtocolProxy_Rds {
+	return &metaprotocol.MetaProtocolProxy_Rds{
+		Rds: &metaprotocol.Rds{
+			RouteConfigName: model.BuildMetaProtocolRouteName(context.ServiceEntry.Spec.Hosts[0],
+				int(port.Number)),
+			ConfigSource: &envoyconfig.ConfigSource{
+				ResourceApiVersion: envoyconfig.ApiVersion_V3,
+				ConfigSourceSpecifier: &envoyconfig.ConfigSource_ApiConfigSource{
+					ApiConfigSource: &envoyconfig.ApiConfigSource{
+						ApiType:             envoyconfig.ApiConfigSource_GRPC,
+						TransportApiVersion: envoyconfig.ApiVersion_V3,
+						GrpcServices: []*envoyconfig.GrpcService{
+							{
+								TargetSpecifier: &envoyconfig.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoyconfig.GrpcService_EnvoyGrpc{
+										ClusterName: "aeraki-xds", // TODO make this configurable
 									},
 								},
 							},
@@ -67,19 +92,11 @@ func buildOutboundProxy(context *model.EnvoyFilterContext,
 				},
 			},
 		},
-		ApplicationProtocol: applicationProtocol,
-		Codec: &metaprotocol.Codec{
-			Name: codec,
-		},
-		MetaProtocolFilters: buildOutboundFilters(context.ServiceEntry.Spec.Hosts[0]),
 	}
-	configAccessLog(context, metaProtocolProy)
-	configTracing(context, metaProtocolProy)
-	return metaProtocolProy, nil
 }
 
-func buildInboundProxy(context *model.EnvoyFilterContext,
-	port *istionetworking.Port) (*metaprotocol.MetaProtocolProxy, error) {
+func buildInboundProxy(context *model.EnvoyFilterContext, port *istionetworking.Port,
+	failureMode FailureMode) (*metaprotocol.MetaProtocolProxy, error) {
 	route := buildInboundRouteConfig(context, port)
 	applicationProtocol, err := metaprotocolmodel.GetApplicationProtocolFromPortName(port.
 		Name)
@@ -91,14 +108,14 @@ func buildInboundProxy(context *model.EnvoyFilterContext,
 		return nil, err
 	}
 
-	filters, err := buildInboundFilters(context.MetaRouter, context.ServiceEntry.Spec.Hosts[0])
+	filters, err := buildInboundFilters(context.MetaRouter, context.ServiceEntry.Spec.Hosts[0], failureMode)
 	if err != nil {
 		return nil, err
 	}
 
 	metaProtocolProy := &metaprotocol.MetaProtocolProxy{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionInbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(port.Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionInbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(port.Number))),
 		RouteSpecifier: &metaprotocol.MetaProtocolProxy_RouteConfig{
 			RouteConfig: route,
 		},
@@ -108,17 +125,48 @@ func buildInboundProxy(context *model.EnvoyFilterContext,
 		},
 		MetaProtocolFilters: filters,
 	}
-	configAccessLog(context, metaProtocolProy)
+	if err := configAccessLog(context, metaProtocolProy); err != nil {
+		return nil, err
+	}
 	configTracing(context, metaProtocolProy)
+	configIdleTimeout(context, metaProtocolProy, applicationProtocol)
 	return metaProtocolProy, nil
 }
 
-func configAccessLog(context *model.EnvoyFilterContext, metaProtocolProy *metaprotocol.MetaProtocolProxy) {
-	if context.MeshConfig.Mesh().AccessLogFile != "" {
+// configIdleTimeout sets the idle timeout of the downstream connections, after which the idle connections are closed.
+// The timeout set by the annotation of the service takes precedence over the default one of the application protocol.
+func configIdleTimeout(context *model.EnvoyFilterContext, metaProtocolProy *metaprotocol.MetaProtocolProxy,
+	applicationProtocol string) {
+	if timeout, ok := envoyfilter.IdleTimeout(context.ServiceEntry,
+		constants.DownstreamIdleTimeoutAnnotation); ok {
+		metaProtocolProy.IdleTimeout = durationpb.New(timeout)
+	} else if timeout, ok := metaprotocolmodel.GetDefaultIdleTimeout(applicationProtocol); ok {
+		metaProtocolProy.IdleTimeout = durationpb.New(timeout)
+	}
+}
+
+func configAccessLog(context *model.EnvoyFilterContext, metaProtocolProy *metaprotocol.MetaProtocolProxy) error {
+	debug, err := debugLog(context.ServiceEntry)
+	if err != nil {
+		return err
+	}
+	if debug {
+		// the requests are logged to the standard output if the access log is off in the mesh config
 		metaProtocolProy.AccessLog = []*accesslog.AccessLog{
 			buildFileAccessLogHelper(context.MeshConfig.Mesh().AccessLogFile, context.MeshConfig.Mesh()),
 		}
+		return nil
+	}
+	filter, err := accessLogFilter(context.ServiceEntry)
+	if err != nil {
+		return err
+	}
+	if context.MeshConfig.Mesh().AccessLogFile != "" {
+		accessLog := buildFileAccessLogHelper(context.MeshConfig.Mesh().AccessLogFile, context.MeshConfig.Mesh())
+		accessLog.Filter = filter
+		metaProtocolProy.AccessLog = []*accesslog.AccessLog{accessLog}
 	}
+	return nil
 }
 
 func configTracing(context *model.EnvoyFilterContext, metaProtocolProy *metaprotocol.MetaProtocolProxy) {
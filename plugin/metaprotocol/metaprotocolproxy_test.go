@@ -0,0 +1,227 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"testing"
+	"time"
+
+	metaprotocol "github.com/aeraki-mesh/meta-protocol-control-plane-api/aeraki/meta_protocol_proxy/v1alpha"
+	"google.golang.org/protobuf/proto"
+	istionetworking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+
+	userapi "github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	mpclient "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+	metaprotocolmodel "github.com/aeraki-mesh/aeraki/pkg/model/metaprotocol"
+	"github.com/aeraki-mesh/aeraki/pkg/xds"
+)
+
+func Test_buildOutboundProxyRouteConfigName(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	services := []struct {
+		host string
+		port *istionetworking.Port
+	}{
+		{
+			host: "thrift-sample-server.meta-thrift.svc.cluster.local",
+			port: &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"},
+		},
+		{
+			host: "dubbo-sample-provider.meta-dubbo.svc.cluster.local",
+			port: &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-dubbo"},
+		},
+	}
+
+	names := make(map[string]bool)
+	for _, service := range services {
+		context := &model.EnvoyFilterContext{
+			MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+			ServiceEntry: &model.ServiceEntryWrapper{
+				Spec: &istionetworking.ServiceEntry{
+					Hosts: []string{service.host},
+					Ports: []*istionetworking.Port{service.port},
+				},
+			},
+		}
+		proxy, err := buildOutboundProxy(context, service.port, false)
+		if err != nil {
+			t.Fatalf("buildOutboundProxy() unexpected error: %v", err)
+		}
+		got := proxy.GetRds().GetRouteConfigName()
+		// The RDS server keys the route configurations with the same name
+		want := model.BuildMetaProtocolRouteName(service.host, int(service.port.Number))
+		if got != want {
+			t.Errorf("route config name = %v, want %v", got, want)
+		}
+		if names[got] {
+			t.Errorf("route config name %v is not unique", got)
+		}
+		names[got] = true
+	}
+}
+
+func Test_buildOutboundProxyInlineRoutes(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	host := "thrift-sample-server.meta-thrift.svc.cluster.local"
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name       string
+		metaRouter *mpclient.MetaRouter
+	}{
+		{
+			name: "default route",
+		},
+		{
+			name: "meta router",
+			metaRouter: &mpclient.MetaRouter{
+				Spec: userapi.MetaRouter{
+					Hosts: []string{host},
+					Routes: []*userapi.MetaRoute{
+						{
+							Name: "v1",
+							Match: &userapi.MetaRouteMatch{
+								Attributes: map[string]*userapi.StringMatch{
+									"method": {MatchType: &userapi.StringMatch_Exact{Exact: "sayHello"}},
+								},
+							},
+							Route: []*userapi.MetaRouteDestination{
+								{Destination: &userapi.Destination{Host: host, Subset: "v1"}, Weight: 20},
+								{Destination: &userapi.Destination{Host: host, Subset: "v2"}, Weight: 80},
+							},
+							RequestMutation: []*userapi.KeyValue{{Key: "foo", Value: "bar"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Spec: &istionetworking.ServiceEntry{
+						Hosts: []string{host},
+						Ports: []*istionetworking.Port{port},
+					},
+				},
+				MetaRouter: tt.metaRouter,
+			}
+			// the route config served by the RDS server is the reference of the inline route config
+			want := xds.BuildMetaRouteConfiguration(context.ServiceEntry.Spec, port, tt.metaRouter, nil)
+
+			rdsProxy, err := buildOutboundProxy(context, port, false)
+			if err != nil {
+				t.Fatalf("buildOutboundProxy() unexpected error: %v", err)
+			}
+			if got := rdsProxy.GetRds().GetRouteConfigName(); got != want.Name {
+				t.Errorf("rds route config name = %v, want %v", got, want.Name)
+			}
+
+			inlineProxy, err := buildOutboundProxy(context, port, true)
+			if err != nil {
+				t.Fatalf("buildOutboundProxy() unexpected error: %v", err)
+			}
+			if inlineProxy.GetRds() != nil {
+				t.Errorf("inline route proxy should not use rds")
+			}
+			if got := inlineProxy.GetRouteConfig(); !proto.Equal(got, want) {
+				t.Errorf("inline route config = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_buildProxyDownstreamIdleTimeout(t *testing.T) {
+	meshConfig := mesh.DefaultMeshConfig()
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		defaults    map[string]time.Duration
+		want        time.Duration
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:        "downstream",
+			annotations: map[string]string{constants.DownstreamIdleTimeoutAnnotation: "30s"},
+			want:        30 * time.Second,
+		},
+		{
+			name:     "default of the protocol",
+			defaults: map[string]time.Duration{"thrift": 5 * time.Minute},
+			want:     5 * time.Minute,
+		},
+		{
+			name:        "annotation over the default",
+			annotations: map[string]string{constants.DownstreamIdleTimeoutAnnotation: "30s"},
+			defaults:    map[string]time.Duration{"thrift": 5 * time.Minute},
+			want:        30 * time.Second,
+		},
+		{
+			name:     "default of another protocol",
+			defaults: map[string]time.Duration{"dubbo": 5 * time.Minute},
+		},
+		{
+			name:        "upstream only",
+			annotations: map[string]string{constants.UpstreamIdleTimeoutAnnotation: "1m"},
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{constants.DownstreamIdleTimeoutAnnotation: "30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metaprotocolmodel.SetDefaultIdleTimeouts(tt.defaults)
+			defer metaprotocolmodel.SetDefaultIdleTimeouts(nil)
+			context := &model.EnvoyFilterContext{
+				MeshConfig: mesh.NewFixedWatcher(&meshConfig),
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Meta: istioconfig.Meta{Annotations: tt.annotations},
+					Spec: &istionetworking.ServiceEntry{
+						Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+						Ports: []*istionetworking.Port{port},
+					},
+				},
+			}
+			outboundProxy, err := buildOutboundProxy(context, port, false)
+			if err != nil {
+				t.Fatalf("buildOutboundProxy() unexpected error: %v", err)
+			}
+			inboundProxy, err := buildInboundProxy(context, port, FailOpen)
+			if err != nil {
+				t.Fatalf("buildInboundProxy() unexpected error: %v", err)
+			}
+			for _, proxy := range []*metaprotocol.MetaProtocolProxy{outboundProxy, inboundProxy} {
+				if tt.want == 0 {
+					if proxy.IdleTimeout != nil {
+						t.Errorf("idle timeout = %v, want nil", proxy.IdleTimeout)
+					}
+					continue
+				}
+				if got := proxy.IdleTimeout.AsDuration(); got != tt.want {
+					t.Errorf("idle timeout = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
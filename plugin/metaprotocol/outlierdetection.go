@@ -0,0 +1,99 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/protobuf/types/known/durationpb"
+	istionetworking "istio.io/api/networking/v1alpha3"
+
+	userapi "github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// buildOutlierDetection converts the outlier detection of a MetaRouter to the Envoy one. The MetaProtocol proxy
+// reports both the connection failures and the error responses as 5xx errors, so the consecutive errors are counted
+// by consecutive_5xx. The success rate based ejection is disabled as Istio does.
+func buildOutlierDetection(outlier *userapi.OutlierDetection) (*cluster.OutlierDetection, error) {
+	outlierDetection := &cluster.OutlierDetection{
+		Consecutive_5Xx:      &wrappers.UInt32Value{Value: outlier.ConsecutiveErrors},
+		EnforcingSuccessRate: &wrappers.UInt32Value{Value: 0},
+	}
+	if outlier.Interval != nil {
+		interval, err := types.DurationFromProto(outlier.Interval)
+		if err != nil {
+			return nil, err
+		}
+		outlierDetection.Interval = durationpb.New(interval)
+	}
+	if outlier.BaseEjectionTime != nil {
+		baseEjectionTime, err := types.DurationFromProto(outlier.BaseEjectionTime)
+		if err != nil {
+			return nil, err
+		}
+		outlierDetection.BaseEjectionTime = durationpb.New(baseEjectionTime)
+	}
+	if outlier.MaxEjectionPercent > 0 {
+		outlierDetection.MaxEjectionPercent = &wrappers.UInt32Value{Value: outlier.MaxEjectionPercent}
+	}
+	return outlierDetection, nil
+}
+
+// configOutlierDetection adds the patch which sets the outlier detection of the MetaRouter on the clusters of a
+// service port to the first outbound EnvoyFilter of the result, an invalid outlier detection is reported as a warning
+func configOutlierDetection(context *model.EnvoyFilterContext, port *istionetworking.Port,
+	result *model.GenerationResult) error {
+	if context.MetaRouter == nil || context.MetaRouter.Spec.OutlierDetection == nil {
+		return nil
+	}
+	outlier := context.MetaRouter.Spec.OutlierDetection
+	if outlier.ConsecutiveErrors < 1 || outlier.MaxEjectionPercent > 100 {
+		result.AddWarning("invalid outlier detection of MetaRouter %s/%s: consecutiveErrors must be greater than 0 "+
+			"and maxEjectionPercent must be in the range [0, 100]", context.MetaRouter.Namespace,
+			context.MetaRouter.Name)
+		return nil
+	}
+	outlierDetection, err := buildOutlierDetection(outlier)
+	if err != nil {
+		result.AddWarning("invalid outlier detection of MetaRouter %s/%s: %v", context.MetaRouter.Namespace,
+			context.MetaRouter.Name, err)
+		return nil
+	}
+	value, err := envoyfilter.StructValue(&cluster.Cluster{OutlierDetection: outlierDetection})
+	if err != nil {
+		return err
+	}
+	patch := &istionetworking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: istionetworking.EnvoyFilter_CLUSTER,
+		Match: &istionetworking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: istionetworking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &istionetworking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &istionetworking.EnvoyFilter_ClusterMatch{
+					PortNumber: port.Number,
+					Service:    context.ServiceEntry.Spec.Hosts[0],
+				},
+			},
+		},
+		Patch: &istionetworking.EnvoyFilter_Patch{
+			Operation: istionetworking.EnvoyFilter_Patch_MERGE,
+			Value:     value,
+		},
+	}
+	appendOutboundPatch(result, patch)
+	return nil
+}
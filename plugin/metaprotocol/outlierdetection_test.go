@@ -0,0 +1,124 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	istionetworking "istio.io/api/networking/v1alpha3"
+
+	userapi "github.com/aeraki-mesh/aeraki/api/metaprotocol/v1alpha1"
+	mpclient "github.com/aeraki-mesh/aeraki/client-go/pkg/apis/metaprotocol/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func Test_configOutlierDetection(t *testing.T) {
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name             string
+		outlierDetection *userapi.OutlierDetection
+		wantPatch        bool
+		wantWarning      bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name: "outlier detection",
+			outlierDetection: &userapi.OutlierDetection{
+				ConsecutiveErrors:  5,
+				Interval:           &types.Duration{Seconds: 10},
+				BaseEjectionTime:   &types.Duration{Seconds: 30},
+				MaxEjectionPercent: 50,
+			},
+			wantPatch: true,
+		},
+		{
+			name:             "zero consecutive errors",
+			outlierDetection: &userapi.OutlierDetection{},
+			wantWarning:      true,
+		},
+		{
+			name: "max ejection percent greater than 100",
+			outlierDetection: &userapi.OutlierDetection{
+				ConsecutiveErrors:  5,
+				MaxEjectionPercent: 120,
+			},
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Spec: &istionetworking.ServiceEntry{
+					Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+					Ports: []*istionetworking.Port{port},
+				},
+			}
+			metaRouter := &mpclient.MetaRouter{
+				Spec: userapi.MetaRouter{OutlierDetection: tt.outlierDetection},
+			}
+			inbound := &model.EnvoyFilterWrapper{Envoyfilter: &istionetworking.EnvoyFilter{
+				WorkloadSelector: &istionetworking.WorkloadSelector{},
+			}}
+			outbound := &model.EnvoyFilterWrapper{Envoyfilter: &istionetworking.EnvoyFilter{}}
+			result := &model.GenerationResult{EnvoyFilters: []*model.EnvoyFilterWrapper{inbound, outbound}}
+
+			context := &model.EnvoyFilterContext{ServiceEntry: service, MetaRouter: metaRouter}
+			if err := configOutlierDetection(context, port, result); err != nil {
+				t.Fatalf("configOutlierDetection() error = %v", err)
+			}
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+			if len(inbound.Envoyfilter.ConfigPatches) != 0 {
+				t.Errorf("the inbound EnvoyFilter should not be patched")
+			}
+			if !tt.wantPatch {
+				if len(outbound.Envoyfilter.ConfigPatches) != 0 {
+					t.Errorf("patches = %v, want none", outbound.Envoyfilter.ConfigPatches)
+				}
+				return
+			}
+			if len(outbound.Envoyfilter.ConfigPatches) != 1 {
+				t.Fatalf("patches = %v, want one cluster patch", outbound.Envoyfilter.ConfigPatches)
+			}
+			patch := outbound.Envoyfilter.ConfigPatches[0]
+			if patch.ApplyTo != istionetworking.EnvoyFilter_CLUSTER ||
+				patch.Patch.Operation != istionetworking.EnvoyFilter_Patch_MERGE ||
+				patch.Match.GetCluster().Service != service.Spec.Hosts[0] ||
+				patch.Match.GetCluster().PortNumber != port.Number {
+				t.Errorf("patch = %v, want a merge into the clusters of the service port", patch)
+			}
+			outlier := patch.Patch.Value.Fields["outlierDetection"].GetStructValue().Fields
+			if got := outlier["consecutive5xx"].GetNumberValue(); got != 5 {
+				t.Errorf("consecutive 5xx = %v, want 5", got)
+			}
+			if got := outlier["interval"].GetStringValue(); got != "10s" {
+				t.Errorf("interval = %v, want 10s", got)
+			}
+			if got := outlier["baseEjectionTime"].GetStringValue(); got != "30s" {
+				t.Errorf("base ejection time = %v, want 30s", got)
+			}
+			if got := outlier["maxEjectionPercent"].GetNumberValue(); got != 50 {
+				t.Errorf("max ejection percent = %v, want 50", got)
+			}
+			if got := outlier["enforcingSuccessRate"].GetNumberValue(); got != 0 {
+				t.Errorf("enforcing success rate = %v, want 0", got)
+			}
+		})
+	}
+}
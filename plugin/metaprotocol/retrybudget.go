@@ -0,0 +1,185 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	istionetworking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// retryBudgetPercent returns the retry budget set by the annotation of the service, which is the percentage of the
+// active requests allowed to be retries. The percent must be in the range (0, 100].
+func retryBudgetPercent(service *model.ServiceEntryWrapper) (float64, bool, error) {
+	value, ok := service.Annotations[constants.RetryBudgetPercentAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, false, fmt.Errorf("invalid %s annotation: %s, it should be a percent in the range (0, 100]",
+			constants.RetryBudgetPercentAnnotation, value)
+	}
+	return percent, true, nil
+}
+
+// buildRetryBudgetThresholds builds the circuit breaker thresholds with a retry budget. Envoy picks the last
+// thresholds of a priority, so the thresholds replace the ones generated by Istio for the default priority, the other
+// limits are therefore copied from the connection pool settings, as Istio does.
+func buildRetryBudgetThresholds(pool *istionetworking.ConnectionPoolSettings,
+	percent float64) *cluster.CircuitBreakers_Thresholds {
+	thresholds := &cluster.CircuitBreakers_Thresholds{
+		MaxRetries:         &wrappers.UInt32Value{Value: math.MaxUint32},
+		MaxRequests:        &wrappers.UInt32Value{Value: math.MaxUint32},
+		MaxConnections:     &wrappers.UInt32Value{Value: math.MaxUint32},
+		MaxPendingRequests: &wrappers.UInt32Value{Value: math.MaxUint32},
+		RetryBudget: &cluster.CircuitBreakers_Thresholds_RetryBudget{
+			BudgetPercent: &envoytype.Percent{Value: percent},
+		},
+		TrackRemaining: true,
+	}
+	if pool == nil {
+		return thresholds
+	}
+	if pool.Tcp != nil && pool.Tcp.MaxConnections > 0 {
+		thresholds.MaxConnections.Value = uint32(pool.Tcp.MaxConnections)
+	}
+	if pool.Http != nil {
+		if pool.Http.Http1MaxPendingRequests > 0 {
+			thresholds.MaxPendingRequests.Value = uint32(pool.Http.Http1MaxPendingRequests)
+		}
+		if pool.Http.Http2MaxRequests > 0 {
+			thresholds.MaxRequests.Value = uint32(pool.Http.Http2MaxRequests)
+		}
+		if pool.Http.MaxRetries > 0 {
+			thresholds.MaxRetries.Value = uint32(pool.Http.MaxRetries)
+		}
+	}
+	return thresholds
+}
+
+// connectionPool returns the connection pool settings of the DestinationRule
+func connectionPool(dr *model.DestinationRuleWrapper) *istionetworking.ConnectionPoolSettings {
+	if dr == nil || dr.Spec == nil {
+		return nil
+	}
+	return dr.Spec.GetTrafficPolicy().GetConnectionPool()
+}
+
+// buildRetryBudgetClusterPatch builds the patch which sets the retry budget on the clusters of a service port. The
+// patch applies to the cluster of the given subset, or to all the subset clusters when subset is empty.
+func buildRetryBudgetClusterPatch(context *model.EnvoyFilterContext, port *istionetworking.Port, subset string,
+	pool *istionetworking.ConnectionPoolSettings, percent float64) (*istionetworking.EnvoyFilter_EnvoyConfigObjectPatch,
+	error) {
+	value, err := envoyfilter.StructValue(&cluster.Cluster{
+		CircuitBreakers: &cluster.CircuitBreakers{
+			Thresholds: []*cluster.CircuitBreakers_Thresholds{
+				buildRetryBudgetThresholds(pool, percent),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &istionetworking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: istionetworking.EnvoyFilter_CLUSTER,
+		Match: &istionetworking.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: istionetworking.EnvoyFilter_SIDECAR_OUTBOUND,
+			ObjectTypes: &istionetworking.EnvoyFilter_EnvoyConfigObjectMatch_Cluster{
+				Cluster: &istionetworking.EnvoyFilter_ClusterMatch{
+					PortNumber: port.Number,
+					Service:    context.ServiceEntry.Spec.Hosts[0],
+					Subset:     subset,
+				},
+			},
+		},
+		Patch: &istionetworking.EnvoyFilter_Patch{
+			Operation: istionetworking.EnvoyFilter_Patch_MERGE,
+			Value:     value,
+		},
+	}, nil
+}
+
+// buildSubsetRetryBudgetClusterPatches builds the retry budget patches of a service port. Istio already generates the
+// circuit breaker thresholds of each subset cluster from the connection pool of the subset, but the retry budget
+// thresholds replace them, so the first patch carries the connection pool of the DestinationRule and applies to all
+// the subset clusters, and it's followed by a patch for each subset which overrides the connection pool. Aeraki
+// doesn't generate any per-subset connection pool config without a retry budget.
+func buildSubsetRetryBudgetClusterPatches(context *model.EnvoyFilterContext, port *istionetworking.Port,
+	percent float64) ([]*istionetworking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	patch, err := buildRetryBudgetClusterPatch(context, port, "", connectionPool(context.DestinationRule), percent)
+	if err != nil {
+		return nil, err
+	}
+	patches := []*istionetworking.EnvoyFilter_EnvoyConfigObjectPatch{patch}
+	if context.DestinationRule == nil || context.DestinationRule.Spec == nil {
+		return patches, nil
+	}
+	for _, subset := range context.DestinationRule.Spec.Subsets {
+		// Istio replaces the connection pool of the DestinationRule with the one of the subset as a whole
+		pool := subset.GetTrafficPolicy().GetConnectionPool()
+		if pool == nil {
+			continue
+		}
+		patch, err := buildRetryBudgetClusterPatch(context, port, subset.Name, pool, percent)
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, patch)
+	}
+	return patches, nil
+}
+
+// configRetryBudget adds the retry budget patches of a service port to the first outbound EnvoyFilter of the result,
+// an invalid retry budget is reported as a warning
+func configRetryBudget(context *model.EnvoyFilterContext, port *istionetworking.Port,
+	result *model.GenerationResult) error {
+	percent, ok, err := retryBudgetPercent(context.ServiceEntry)
+	if err != nil {
+		result.AddWarning("%v", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	patches, err := buildSubsetRetryBudgetClusterPatches(context, port, percent)
+	if err != nil {
+		return err
+	}
+	for _, patch := range patches {
+		appendOutboundPatch(result, patch)
+	}
+	return nil
+}
+
+// appendOutboundPatch appends a patch to the first outbound EnvoyFilter of the result
+func appendOutboundPatch(result *model.GenerationResult, patch *istionetworking.EnvoyFilter_EnvoyConfigObjectPatch) {
+	// the outbound EnvoyFilters don't have a workload selector, the clusters are shared by all of them
+	for _, wrapper := range result.EnvoyFilters {
+		if wrapper.Envoyfilter.WorkloadSelector == nil {
+			wrapper.Envoyfilter.ConfigPatches = append(wrapper.Envoyfilter.ConfigPatches, patch)
+			break
+		}
+	}
+}
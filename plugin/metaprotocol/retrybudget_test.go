@@ -0,0 +1,237 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metaprotocol
+
+import (
+	"math"
+	"testing"
+
+	istionetworking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func Test_configRetryBudget(t *testing.T) {
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	tests := []struct {
+		name        string
+		annotation  string
+		wantPercent float64
+		wantWarning bool
+	}{
+		{
+			name:        "retry budget",
+			annotation:  "25",
+			wantPercent: 25,
+		},
+		{
+			name: "not set",
+		},
+		{
+			name:        "zero",
+			annotation:  "0",
+			wantWarning: true,
+		},
+		{
+			name:        "greater than 100",
+			annotation:  "120",
+			wantWarning: true,
+		},
+		{
+			name:        "not a number",
+			annotation:  "ten",
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &model.ServiceEntryWrapper{
+				Spec: &istionetworking.ServiceEntry{
+					Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+					Ports: []*istionetworking.Port{port},
+				},
+			}
+			if tt.annotation != "" {
+				service.Annotations = map[string]string{constants.RetryBudgetPercentAnnotation: tt.annotation}
+			}
+			inbound := &model.EnvoyFilterWrapper{Envoyfilter: &istionetworking.EnvoyFilter{
+				WorkloadSelector: &istionetworking.WorkloadSelector{},
+			}}
+			outbound := &model.EnvoyFilterWrapper{Envoyfilter: &istionetworking.EnvoyFilter{}}
+			result := &model.GenerationResult{EnvoyFilters: []*model.EnvoyFilterWrapper{inbound, outbound}}
+
+			err := configRetryBudget(&model.EnvoyFilterContext{ServiceEntry: service}, port, result)
+			if err != nil {
+				t.Fatalf("configRetryBudget() error = %v", err)
+			}
+			if got := len(result.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", result.Warnings, tt.wantWarning)
+			}
+			if len(inbound.Envoyfilter.ConfigPatches) != 0 {
+				t.Errorf("the inbound EnvoyFilter should not be patched")
+			}
+			if tt.wantPercent == 0 {
+				if len(outbound.Envoyfilter.ConfigPatches) != 0 {
+					t.Errorf("patches = %v, want none", outbound.Envoyfilter.ConfigPatches)
+				}
+				return
+			}
+			if len(outbound.Envoyfilter.ConfigPatches) != 1 {
+				t.Fatalf("patches = %v, want one cluster patch", outbound.Envoyfilter.ConfigPatches)
+			}
+			patch := outbound.Envoyfilter.ConfigPatches[0]
+			if patch.ApplyTo != istionetworking.EnvoyFilter_CLUSTER ||
+				patch.Match.GetCluster().Service != service.Spec.Hosts[0] ||
+				patch.Match.GetCluster().PortNumber != port.Number {
+				t.Errorf("patch match = %v, want the clusters of the service port", patch.Match)
+			}
+			thresholds := patch.Patch.Value.Fields["circuitBreakers"].GetStructValue().Fields["thresholds"].
+				GetListValue().Values[0].GetStructValue()
+			percent := thresholds.Fields["retryBudget"].GetStructValue().Fields["budgetPercent"].GetStructValue().
+				Fields["value"].GetNumberValue()
+			if percent != tt.wantPercent {
+				t.Errorf("budget percent = %v, want %v", percent, tt.wantPercent)
+			}
+		})
+	}
+}
+
+func Test_buildRetryBudgetThresholds(t *testing.T) {
+	pool := &istionetworking.ConnectionPoolSettings{
+		Tcp: &istionetworking.ConnectionPoolSettings_TCPSettings{MaxConnections: 100},
+		Http: &istionetworking.ConnectionPoolSettings_HTTPSettings{
+			Http1MaxPendingRequests: 10,
+			MaxRetries:              5,
+		},
+	}
+	tests := []struct {
+		name               string
+		pool               *istionetworking.ConnectionPoolSettings
+		maxConnections     uint32
+		maxPendingRequests uint32
+		maxRequests        uint32
+		maxRetries         uint32
+	}{
+		{
+			name:               "without connection pool",
+			maxConnections:     math.MaxUint32,
+			maxPendingRequests: math.MaxUint32,
+			maxRequests:        math.MaxUint32,
+			maxRetries:         math.MaxUint32,
+		},
+		{
+			name:               "with connection pool",
+			pool:               pool,
+			maxConnections:     100,
+			maxPendingRequests: 10,
+			maxRequests:        math.MaxUint32,
+			maxRetries:         5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildRetryBudgetThresholds(tt.pool, 20)
+			if got.MaxConnections.Value != tt.maxConnections || got.MaxPendingRequests.Value != tt.maxPendingRequests ||
+				got.MaxRequests.Value != tt.maxRequests || got.MaxRetries.Value != tt.maxRetries {
+				t.Errorf("buildRetryBudgetThresholds() = %v", got)
+			}
+			if got.RetryBudget.BudgetPercent.Value != 20 {
+				t.Errorf("budget percent = %v, want 20", got.RetryBudget.BudgetPercent.Value)
+			}
+		})
+	}
+}
+
+func Test_buildSubsetRetryBudgetClusterPatches(t *testing.T) {
+	port := &istionetworking.Port{Number: 9090, Name: "tcp-metaprotocol-thrift"}
+	service := &model.ServiceEntryWrapper{
+		Spec: &istionetworking.ServiceEntry{
+			Hosts: []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+			Ports: []*istionetworking.Port{port},
+		},
+	}
+	dr := &model.DestinationRuleWrapper{
+		Spec: &istionetworking.DestinationRule{
+			TrafficPolicy: &istionetworking.TrafficPolicy{
+				ConnectionPool: &istionetworking.ConnectionPoolSettings{
+					Tcp: &istionetworking.ConnectionPoolSettings_TCPSettings{MaxConnections: 100},
+				},
+			},
+			Subsets: []*istionetworking.Subset{
+				{
+					Name: "v1",
+					TrafficPolicy: &istionetworking.TrafficPolicy{
+						ConnectionPool: &istionetworking.ConnectionPoolSettings{
+							Tcp: &istionetworking.ConnectionPoolSettings_TCPSettings{MaxConnections: 10},
+						},
+					},
+				},
+				{
+					Name: "v2",
+					TrafficPolicy: &istionetworking.TrafficPolicy{
+						ConnectionPool: &istionetworking.ConnectionPoolSettings{
+							Tcp: &istionetworking.ConnectionPoolSettings_TCPSettings{MaxConnections: 20},
+						},
+					},
+				},
+				{
+					Name: "v3",
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name               string
+		dr                 *model.DestinationRuleWrapper
+		wantSubsets        []string
+		wantMaxConnections []float64
+	}{
+		{
+			name:               "without destination rule",
+			wantSubsets:        []string{""},
+			wantMaxConnections: []float64{math.MaxUint32},
+		},
+		{
+			name:               "subsets with connection pools",
+			dr:                 dr,
+			wantSubsets:        []string{"", "v1", "v2"},
+			wantMaxConnections: []float64{100, 10, 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{ServiceEntry: service, DestinationRule: tt.dr}
+			patches, err := buildSubsetRetryBudgetClusterPatches(context, port, 25)
+			if err != nil {
+				t.Fatalf("buildSubsetRetryBudgetClusterPatches() error = %v", err)
+			}
+			if len(patches) != len(tt.wantSubsets) {
+				t.Fatalf("patches = %v, want %d patches", patches, len(tt.wantSubsets))
+			}
+			for i, patch := range patches {
+				if subset := patch.Match.GetCluster().Subset; subset != tt.wantSubsets[i] {
+					t.Errorf("patch %d subset = %q, want %q", i, subset, tt.wantSubsets[i])
+				}
+				thresholds := patch.Patch.Value.Fields["circuitBreakers"].GetStructValue().Fields["thresholds"].
+					GetListValue().Values[0].GetStructValue()
+				maxConnections := thresholds.Fields["maxConnections"].GetNumberValue()
+				if maxConnections != tt.wantMaxConnections[i] {
+					t.Errorf("patch %d max connections = %v, want %v", i, maxConnections, tt.wantMaxConnections[i])
+				}
+			}
+		})
+	}
+}
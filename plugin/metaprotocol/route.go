@@ -19,13 +19,23 @@ import (
 
 	metaroute "github.com/aeraki-mesh/meta-protocol-control-plane-api/aeraki/meta_protocol_proxy/config/route/v1alpha"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
+	"github.com/aeraki-mesh/aeraki/pkg/xds"
 )
 
+// buildOutboundRouteConfig builds the same route config as the one served by the Aeraki RDS server, so it can be
+// inlined in the EnvoyFilter
+func buildOutboundRouteConfig(context *model.EnvoyFilterContext,
+	port *istionetworking.Port) *metaroute.RouteConfiguration {
+	return xds.BuildMetaRouteConfiguration(context.ServiceEntry.Spec, port, context.MetaRouter,
+		context.DestinationRule)
+}
+
 func buildInboundRouteConfig(context *model.EnvoyFilterContext,
 	port *istionetworking.Port) *metaroute.RouteConfiguration {
 	clusterName := model.BuildClusterName(model.TrafficDirectionInbound, "",
-		context.ServiceEntry.Spec.Hosts[0], int(port.Number))
+		context.ServiceEntry.Spec.Hosts[0], int(envoyfilter.InboundPort(context.ServiceEntry, port)))
 
 	return &metaroute.RouteConfiguration{
 		Name: clusterName,
@@ -0,0 +1,75 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raw
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+// typeField is the field of the typed_config JSON which holds the type URL of the config
+const typeField = "@type"
+
+// Generator defines a Generator of the EnvoyFilters for the experimental protocols without a dedicated generator, the
+// filter config is supplied by the annotations of the ServiceEntry as raw JSON
+type Generator struct {
+}
+
+// NewGenerator creates an new raw Generator instance
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate create EnvoyFilters which insert the filter supplied as raw JSON before the tcp proxy of a service, the
+// same filter config is used for both the outbound and inbound traffic
+func (*Generator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
+	filterName, filterType, config, err := rawFilter(context.ServiceEntry)
+	if err != nil {
+		return nil, err
+	}
+	return envoyfilter.GenerateInsertBeforeNetworkFilter(context.ServiceEntry, config, config, filterName,
+		filterType), nil
+}
+
+// rawFilter parses the name and the typed_config of the filter of a service, the @type field is taken out of the
+// typed_config as the type URL of the config
+func rawFilter(service *model.ServiceEntryWrapper) (string, string, *structpb.Struct, error) {
+	filterName := service.Annotations[constants.RawFilterNameAnnotation]
+	if filterName == "" {
+		return "", "", nil, fmt.Errorf("the %s annotation is required by the raw protocol",
+			constants.RawFilterNameAnnotation)
+	}
+	typedConfig, ok := service.Annotations[constants.RawFilterTypedConfigAnnotation]
+	if !ok {
+		return "", "", nil, fmt.Errorf("the %s annotation is required by the raw protocol",
+			constants.RawFilterTypedConfigAnnotation)
+	}
+	config := &structpb.Struct{}
+	if err := config.UnmarshalJSON([]byte(typedConfig)); err != nil {
+		return "", "", nil, fmt.Errorf("invalid %s annotation: %v", constants.RawFilterTypedConfigAnnotation, err)
+	}
+	filterType := config.Fields[typeField].GetStringValue()
+	if filterType == "" {
+		return "", "", nil, fmt.Errorf("invalid %s annotation: the %s field is required",
+			constants.RawFilterTypedConfigAnnotation, typeField)
+	}
+	delete(config.Fields, typeField)
+	return filterName, filterType, config, nil
+}
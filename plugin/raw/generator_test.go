@@ -0,0 +1,120 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raw
+
+import (
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	istioconfig "istio.io/istio/pkg/config"
+
+	"github.com/aeraki-mesh/aeraki/pkg/config/constants"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerate(t *testing.T) {
+	const filterType = "type.googleapis.com/envoy.extensions.filters.network.echo.v3.Echo"
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "valid typed config",
+			annotations: map[string]string{
+				constants.RawFilterNameAnnotation:        "envoy.filters.network.echo",
+				constants.RawFilterTypedConfigAnnotation: `{"@type": "` + filterType + `", "stat_prefix": "echo"}`,
+			},
+		},
+		{
+			name: "invalid JSON",
+			annotations: map[string]string{
+				constants.RawFilterNameAnnotation:        "envoy.filters.network.echo",
+				constants.RawFilterTypedConfigAnnotation: `{"@type": "` + filterType + `",`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a JSON object",
+			annotations: map[string]string{
+				constants.RawFilterNameAnnotation:        "envoy.filters.network.echo",
+				constants.RawFilterTypedConfigAnnotation: `["echo"]`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no type",
+			annotations: map[string]string{
+				constants.RawFilterNameAnnotation:        "envoy.filters.network.echo",
+				constants.RawFilterTypedConfigAnnotation: `{"stat_prefix": "echo"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no filter name",
+			annotations: map[string]string{
+				constants.RawFilterTypedConfigAnnotation: `{"@type": "` + filterType + `"}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				ServiceEntry: &model.ServiceEntryWrapper{
+					Meta: istioconfig.Meta{Annotations: tt.annotations},
+					Spec: &networking.ServiceEntry{
+						Hosts:            []string{"echo.example.com"},
+						Addresses:        []string{"10.0.0.1"},
+						Ports:            []*networking.Port{{Number: 9000, Name: "tcp-raw-echo"}},
+						WorkloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "echo"}},
+					},
+				},
+			}
+			result, err := NewGenerator().Generate(context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(result.EnvoyFilters) != 2 {
+				t.Fatalf("Generate() got %d EnvoyFilters, want the outbound and the inbound ones",
+					len(result.EnvoyFilters))
+			}
+			for _, envoyFilter := range result.EnvoyFilters {
+				patch := envoyFilter.Envoyfilter.ConfigPatches[0]
+				if patch.Patch.Operation != networking.EnvoyFilter_Patch_INSERT_BEFORE {
+					t.Errorf("patch operation = %v, want the filter inserted before the tcp proxy",
+						patch.Patch.Operation)
+				}
+				if got := patch.Patch.Value.Fields["name"].GetStringValue(); got != "envoy.filters.network.echo" {
+					t.Errorf("filter name = %v, want envoy.filters.network.echo", got)
+				}
+				typedConfig := patch.Patch.Value.Fields["typed_config"].GetStructValue().Fields
+				if got := typedConfig["type_url"].GetStringValue(); got != filterType {
+					t.Errorf("typed_config type_url = %v, want %v", got, filterType)
+				}
+				value := typedConfig["value"].GetStructValue().GetFields()
+				if _, ok := value["@type"]; ok {
+					t.Errorf("typed_config value = %v, want the @type field taken out", value)
+				}
+				if got := value["stat_prefix"].GetStringValue(); got != "echo" {
+					t.Errorf("stat_prefix = %v, want echo", got)
+				}
+			}
+		})
+	}
+}
@@ -75,23 +75,21 @@ type Generator struct {
 const Timeout = time.Second * 10
 
 // Generate redis envoy filter
-func (g *Generator) Generate(filterContext *model.EnvoyFilterContext) (filters []*model.EnvoyFilterWrapper, err error) {
+func (g *Generator) Generate(filterContext *model.EnvoyFilterContext) (*model.GenerationResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
+	result := &model.GenerationResult{}
 	se := filterContext.ServiceEntry.Spec
 	for _, port := range se.Ports {
 		if strings.HasPrefix(port.Name, "tcp-redis") {
-			portFilters := g.generate(ctx, filterContext, port)
-			if portFilters != nil {
-				filters = append(filters, portFilters...)
-			}
+			result.Merge(g.generate(ctx, filterContext, port))
 		}
 	}
-	return filters, nil
+	return result, nil
 }
 
 func (g *Generator) generate(ctx context.Context, filterContext *model.EnvoyFilterContext,
-	targetPort *networking.Port) []*model.EnvoyFilterWrapper {
+	targetPort *networking.Port) *model.GenerationResult {
 	port := targetPort.Number
 	portName := targetPort.Name
 	generatorLog.Debugf("generate %s/%s/%s", filterContext.ServiceEntry.Namespace,
@@ -99,7 +97,7 @@ func (g *Generator) generate(ctx context.Context, filterContext *model.EnvoyFilt
 	// copy and replace ports
 	spec := *filterContext.ServiceEntry.Spec
 	spec.Ports = []*networking.Port{targetPort}
-	filters := envoyfilter.GenerateReplaceNetworkFilter(
+	result := envoyfilter.GenerateReplaceNetworkFilter(
 		filterContext.ServiceEntry,
 		filterContext.ServiceEntry.Spec.Ports[0],
 		g.buildOutboundProxyWithFallback(ctx, filterContext, port, portName),
@@ -109,7 +107,7 @@ func (g *Generator) generate(ctx context.Context, filterContext *model.EnvoyFilt
 
 	cluster := g.buildOutboundCluster(ctx, filterContext, port)
 	if cluster != nil {
-		for _, filter := range filters {
+		for _, filter := range result.EnvoyFilters {
 			if filter.Envoyfilter.WorkloadSelector == nil {
 				filter.Envoyfilter.ConfigPatches = append(filter.Envoyfilter.ConfigPatches,
 					ReplaceClusterPatches(cluster)...)
@@ -117,10 +115,10 @@ func (g *Generator) generate(ctx context.Context, filterContext *model.EnvoyFilt
 		}
 	}
 	if generatorLog.DebugEnabled() {
-		fdata, _ := json.Marshal(filters)
+		fdata, _ := json.Marshal(result.EnvoyFilters)
 		generatorLog.Infof("%s", string(fdata))
 	}
-	return filters
+	return result
 }
 
 // ReplaceClusterPatches create a `replace` operation patch on `cluster`
@@ -17,13 +17,14 @@ package redis
 import (
 	redis "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/redis_proxy/v3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
 func (g *Generator) buildInboundProxy(context *model.EnvoyFilterContext) *redis.RedisProxy {
 	name := model.BuildClusterName(model.TrafficDirectionInbound, "", "", int(context.ServiceEntry.Spec.Ports[0].Number))
 	proxy := &redis.RedisProxy{
-		StatPrefix: name,
+		StatPrefix: envoyfilter.StatPrefix(name),
 		Settings: &redis.RedisProxy_ConnPoolSettings{
 			OpTimeout: defaultInboundOpTimeout,
 		},
@@ -31,6 +31,7 @@ import (
 	"istio.io/istio/pkg/config/schema/collections"
 
 	"github.com/aeraki-mesh/aeraki/client-go/pkg/apis/redis/v1alpha1"
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 
 	redis "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/redis_proxy/v3"
@@ -59,7 +60,7 @@ func (g *Generator) buildOutboundProxyWithFallback(ctx context.Context, c *model
 			Settings: &redis.RedisProxy_ConnPoolSettings{
 				OpTimeout: defaultOpTimeout,
 			},
-			StatPrefix: outboundClusterName(c.ServiceEntry.Spec.Hosts[0], listenPort),
+			StatPrefix: envoyfilter.StatPrefix(outboundClusterName(c.ServiceEntry.Spec.Hosts[0], listenPort)),
 			PrefixRoutes: &redis.RedisProxy_PrefixRoutes{
 				CatchAllRoute: &redis.RedisProxy_PrefixRoutes_Route{
 					Cluster: outboundClusterName(c.ServiceEntry.Spec.Hosts[0], listenPort),
@@ -84,7 +85,7 @@ func (g *Generator) buildOutboundProxy(ctx context.Context, c *model.EnvoyFilter
 	hostServices := g.hostServices(c.ServiceEntry.Namespace)
 
 	proxy := &redis.RedisProxy{
-		StatPrefix:   outboundClusterName(targetHost, listenPort),
+		StatPrefix:   envoyfilter.StatPrefix(outboundClusterName(targetHost, listenPort)),
 		PrefixRoutes: &redis.RedisProxy_PrefixRoutes{},
 		Settings:     &redis.RedisProxy_ConnPoolSettings{OpTimeout: defaultOpTimeout},
 	}
@@ -107,6 +108,8 @@ func (g *Generator) buildOutboundProxy(ctx context.Context, c *model.EnvoyFilter
 		return proxy, nil
 	}
 	generatorLog.Debugf("redis service: %s", rs.Spec)
+	// The routes only match the keys of the commands. The Envoy redis proxy doesn't support the pub/sub commands,
+	// so they can't be routed to a cluster of their own.
 	for _, r := range rs.Spec.Redis {
 		route, all := g.buildPrefixRoute(r, hostServices, listenPort, listenPortName)
 		if all {
@@ -38,6 +38,7 @@ import (
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	redis "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/redis_proxy/v3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
@@ -150,6 +151,16 @@ L:
 				"envoy.filters.network.redis_proxy": RedisProtocolOptions,
 			}
 		}
+		// the Redis AUTH password can only be a data source in Envoy, so only the TLS credentials are served over SDS
+		if connPool.Redis.CredentialName != "" {
+			transportSocket, err := envoyfilter.UpstreamTLSTransportSocket(connPool.Redis.CredentialName,
+				c.ServiceEntry.Namespace, c.ServiceEntry.Spec.Hosts[0])
+			if err != nil {
+				generatorLog.Errorf("TLS transport socket create failed: %e", err)
+				return nil
+			}
+			cl.TransportSocket = transportSocket
+		}
 	}
 	if connPool.Tcp != nil {
 		threshold := getDefaultCircuitBreakerThresholds()
@@ -29,7 +29,7 @@ func NewGenerator() *Generator {
 }
 
 // Generate create EnvoyFilters for Thrift services
-func (*Generator) Generate(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error) {
+func (*Generator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
 	return envoyfilter.GenerateReplaceNetworkFilter(
 		context.ServiceEntry,
 		context.ServiceEntry.Spec.Ports[0],
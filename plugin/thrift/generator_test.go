@@ -0,0 +1,168 @@
+// Copyright Aeraki Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package thrift
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
+	"github.com/aeraki-mesh/aeraki/pkg/model"
+)
+
+func TestGenerateReferencesExpectedCluster(t *testing.T) {
+	service := &networking.ServiceEntry{
+		Hosts:     []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+		Addresses: []string{"10.0.0.1"},
+		Ports: []*networking.Port{
+			{
+				Number: 9090,
+				Name:   "tcp-thrift",
+			},
+		},
+	}
+	destination := func(subset string) *networking.HTTPRouteDestination {
+		return &networking.HTTPRouteDestination{
+			Destination: &networking.Destination{Host: service.Hosts[0], Subset: subset},
+			Weight:      50,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		virtualService *model.VirtualServiceWrapper
+		subsets        []string
+	}{
+		{
+			name:    "default route",
+			subsets: []string{""},
+		},
+		{
+			name: "single cluster",
+			virtualService: &model.VirtualServiceWrapper{
+				Spec: &networking.VirtualService{
+					Http: []*networking.HTTPRoute{{Route: []*networking.HTTPRouteDestination{destination("v1")}}},
+				},
+			},
+			subsets: []string{"v1"},
+		},
+		{
+			name: "weighted clusters",
+			virtualService: &model.VirtualServiceWrapper{
+				Spec: &networking.VirtualService{
+					Http: []*networking.HTTPRoute{
+						{Route: []*networking.HTTPRouteDestination{destination("v1"), destination("v2")}},
+					},
+				},
+			},
+			subsets: []string{"v1", "v2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &model.EnvoyFilterContext{
+				ServiceEntry:   &model.ServiceEntryWrapper{Spec: service},
+				VirtualService: tt.virtualService,
+			}
+			result, err := NewGenerator().Generate(context)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			var want []string
+			for _, subset := range tt.subsets {
+				want = append(want, envoyfilter.ExpectedOutboundClusterName(service, service.Ports[0], subset))
+			}
+			var got []string
+			for _, envoyFilter := range result.EnvoyFilters {
+				for _, patch := range envoyFilter.Envoyfilter.ConfigPatches {
+					if patch.ApplyTo == networking.EnvoyFilter_NETWORK_FILTER &&
+						patch.Match.GetListener().Name == "10.0.0.1_9090" {
+						got = append(got, routeClusters(patch.Patch.Value)...)
+					}
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("typed_config references clusters %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// routeClusters extracts the clusters referenced by the route of a thrift proxy typed_config
+func routeClusters(value *types.Struct) []string {
+	proxy := value.Fields["typed_config"].GetStructValue().Fields["value"].GetStructValue()
+	routes := proxy.Fields["routeConfig"].GetStructValue().Fields["routes"].GetListValue()
+
+	var clusters []string
+	for _, route := range routes.Values {
+		action := route.GetStructValue().Fields["route"].GetStructValue()
+		if cluster, ok := action.Fields["cluster"]; ok {
+			clusters = append(clusters, cluster.GetStringValue())
+			continue
+		}
+		weighted := action.Fields["weightedClusters"].GetStructValue().Fields["clusters"].GetListValue()
+		for _, cluster := range weighted.Values {
+			clusters = append(clusters, cluster.GetStructValue().Fields["name"].GetStringValue())
+		}
+	}
+	return clusters
+}
+
+func TestGenerateStatsNamespace(t *testing.T) {
+	if err := envoyfilter.SetStatsNamespace("tenant-a"); err != nil {
+		t.Fatalf("SetStatsNamespace() unexpected error: %v", err)
+	}
+	defer func() {
+		_ = envoyfilter.SetStatsNamespace("")
+	}()
+	context := &model.EnvoyFilterContext{
+		ServiceEntry: &model.ServiceEntryWrapper{
+			Spec: &networking.ServiceEntry{
+				Hosts:     []string{"thrift-sample-server.meta-thrift.svc.cluster.local"},
+				Addresses: []string{"10.0.0.1"},
+				Ports:     []*networking.Port{{Number: 9090, Name: "tcp-thrift"}},
+				WorkloadSelector: &networking.WorkloadSelector{
+					Labels: map[string]string{"app": "thrift-sample-server"},
+				},
+			},
+		},
+	}
+	result, err := NewGenerator().Generate(context)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := map[string]bool{
+		"tenant-a.outbound|9090||thrift-sample-server.meta-thrift.svc.cluster.local": true,
+		"tenant-a.inbound|9090||": true,
+	}
+	got := make(map[string]bool)
+	for _, envoyFilter := range result.EnvoyFilters {
+		for _, patch := range envoyFilter.Envoyfilter.ConfigPatches {
+			if patch.ApplyTo != networking.EnvoyFilter_NETWORK_FILTER {
+				continue
+			}
+			proxy := patch.Patch.Value.Fields["typed_config"].GetStructValue().Fields["value"].GetStructValue()
+			got[proxy.Fields["statPrefix"].GetStringValue()] = true
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stat prefixes = %v, want %v", got, want)
+	}
+}
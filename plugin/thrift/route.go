@@ -19,6 +19,7 @@ import (
 	"github.com/golang/protobuf/ptypes/wrappers"
 	networking "istio.io/api/networking/v1alpha3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
@@ -41,7 +42,8 @@ func buildOutboundRouteConfig(context *model.EnvoyFilterContext) *thrift.RouteCo
 
 func buildInboundRouteConfig(context *model.EnvoyFilterContext) *thrift.RouteConfiguration {
 	clusterName := model.BuildClusterName(model.TrafficDirectionInbound, "",
-		context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))
+		context.ServiceEntry.Spec.Hosts[0],
+		int(envoyfilter.InboundPort(context.ServiceEntry, context.ServiceEntry.Spec.Ports[0])))
 
 	return &thrift.RouteConfiguration{
 		Name: clusterName,
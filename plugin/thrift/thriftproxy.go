@@ -17,6 +17,7 @@ package thrift
 import (
 	thrift "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/thrift_proxy/v3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
@@ -34,8 +35,8 @@ func buildInboundProxy(context *model.EnvoyFilterContext) *thrift.ThriftProxy {
 func newThriftProxy(context *model.EnvoyFilterContext, route *thrift.RouteConfiguration,
 	trafficDirection model.TrafficDirection) *thrift.ThriftProxy {
 	return &thrift.ThriftProxy{
-		StatPrefix: model.BuildClusterName(trafficDirection, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(trafficDirection, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 		Transport:   thrift.TransportType_AUTO_TRANSPORT,
 		Protocol:    thrift.ProtocolType_AUTO_PROTOCOL,
 		RouteConfig: route,
@@ -29,7 +29,7 @@ func NewGenerator() *Generator {
 }
 
 // Generate create EnvoyFilters for Dubbo services
-func (*Generator) Generate(context *model.EnvoyFilterContext) ([]*model.EnvoyFilterWrapper, error) {
+func (*Generator) Generate(context *model.EnvoyFilterContext) (*model.GenerationResult, error) {
 	return envoyfilter.GenerateInsertBeforeNetworkFilter(
 		context.ServiceEntry,
 		buildOutboundProxy(context),
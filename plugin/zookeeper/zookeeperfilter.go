@@ -17,19 +17,20 @@ package zookeeper
 import (
 	zookeeper "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/zookeeper_proxy/v3"
 
+	"github.com/aeraki-mesh/aeraki/pkg/envoyfilter"
 	"github.com/aeraki-mesh/aeraki/pkg/model"
 )
 
 func buildOutboundProxy(context *model.EnvoyFilterContext) *zookeeper.ZooKeeperProxy {
 	return &zookeeper.ZooKeeperProxy{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionOutbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionOutbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 	}
 }
 
 func buildInboundProxy(context *model.EnvoyFilterContext) *zookeeper.ZooKeeperProxy {
 	return &zookeeper.ZooKeeperProxy{
-		StatPrefix: model.BuildClusterName(model.TrafficDirectionInbound, "",
-			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number)),
+		StatPrefix: envoyfilter.StatPrefix(model.BuildClusterName(model.TrafficDirectionInbound, "",
+			context.ServiceEntry.Spec.Hosts[0], int(context.ServiceEntry.Spec.Ports[0].Number))),
 	}
 }